@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/chocoby/zweg/internal/cli"
+	"github.com/chocoby/zweg/internal/config"
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/i18n"
+)
+
+// defaultConfig returns the settings used where the configuration files
+// say nothing.
+func defaultConfig() config.Config {
+	return config.Config{
+		Timezone:         "+00:00",
+		FilenameTemplate: cli.DefaultFilenameTemplate,
+		Lang:             string(i18n.English),
+		Creator:          converter.DefaultConfig().Creator,
+	}
+}
+
+// loadConfig reads the configuration files and applies the configured
+// language. Flags are defined with the loaded values as their defaults, so
+// a flag given on the command line overrides the files.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(defaultConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	if lang, err = i18n.Parse(cfg.Lang); err != nil {
+		return nil, fmt.Errorf("%s: %w", cfg.Source("lang"), err)
+	}
+	if _, err := cli.ParseFilenameTemplate(cfg.FilenameTemplate); err != nil {
+		return nil, fmt.Errorf("%s: %w", cfg.Source("filename_template"), err)
+	}
+	return cfg, nil
+}
+
+var configCommand = &command{
+	name:    "config",
+	args:    "show",
+	summary: "Show the configuration from config.toml and " + config.ProjectFile,
+	help: "Print the configuration merged from the user file (~/.config/zweg/config.toml)\n" +
+		"and the nearest " + config.ProjectFile + ", with the source of each value.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		return func(args []string) error {
+			if len(args) != 1 || args[0] != "show" {
+				fs.Usage()
+				return usageErrorf("unknown config command (expected show)")
+			}
+			return cfg.Write(os.Stdout)
+		}
+	},
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/chocoby/zweg/internal/cli"
+	"github.com/chocoby/zweg/internal/config"
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/resample"
+	"github.com/chocoby/zweg/internal/smooth"
+	"github.com/chocoby/zweg/internal/watch"
+)
+
+// conversionFlags are the flags that decide what a conversion writes,
+// shared by convert and verify.
+type conversionFlags struct {
+	out              *outputFlags
+	trackName        *string
+	outputFormat     *string
+	defaultFormat    string
+	inputFormat      *string
+	creator          *string
+	author           *string
+	copyright        *string
+	license          *string
+	timeShift        *string
+	smoothMethod     *string
+	smoothAccel      *float64
+	fillElevation    *bool
+	stops            *bool
+	stopFlags        *stopFlags
+	lapFlags         *lapFlags
+	resampleInterval *time.Duration
+	resampleMethod   *string
+	resampleMaxGap   *time.Duration
+	compress         *string
+}
+
+func defineConversionFlags(fs *flag.FlagSet, cfg *config.Config) *conversionFlags {
+	return &conversionFlags{
+		trackName:        fs.String("track-name", "", "Name for the GPS track (defaults to the recorded tl, or \"Track\" if absent)"),
+		out:              defineOutputFlags(fs, cfg, "Output directory (ignored if output file is specified)"),
+		outputFormat:     fs.String("format", "", "Output format (defaults to the output file extension, then format from the config file, or gpx; see \"zweg formats\")"),
+		defaultFormat:    cfg.Format,
+		inputFormat:      fs.String("input-format", cfg.InputFormat, "Input format (defaults to detection from extension or content)"),
+		creator:          fs.String("creator", cfg.Creator, "Creator attribute of the GPX document"),
+		author:           fs.String("author", cfg.Author, "Author name for the GPX metadata"),
+		copyright:        fs.String("copyright", cfg.Copyright, "Copyright holder for the GPX metadata (year is taken from the track)"),
+		license:          fs.String("license", cfg.License, "License URL for the GPX copyright element"),
+		timeShift:        fs.String("time-shift", "", "Correct timestamps by a duration (e.g. -9h) or align the first point to a timestamp (e.g. 2024-05-01T09:30:00+09:00)"),
+		smoothMethod:     fs.String("smooth", "", "Smooth jittery positions: kalman (weighs each point by its recorded accuracy)"),
+		smoothAccel:      fs.Float64("smooth-acceleration", smooth.DefaultAcceleration, "Typical change of speed in m/s² allowed by --smooth; lower is smoother"),
+		fillElevation:    fs.Bool("fill-elevation", false, "Interpolate missing altitudes between the neighbouring recorded ones instead of omitting them"),
+		stops:            fs.Bool("stops", false, "Add a waypoint for every place the track stayed, with arrival, departure and stay in its description"),
+		stopFlags:        defineStopFlags(fs),
+		lapFlags:         defineLapFlags(fs),
+		resampleInterval: fs.Duration("resample", 0, "Resample the track to a fixed interval, e.g. 5s"),
+		resampleMethod:   fs.String("resample-method", string(resample.Linear), "Resampling: linear (interpolate) or nearest (pick recorded points)"),
+		resampleMaxGap:   fs.Duration("resample-max-gap", 0, "Longest recording gap to resample across (default 1m, or twice the interval)"),
+		compress:         fs.String("compress", "", "Compress each output: gzip (adds .gz to generated names)"),
+	}
+}
+
+// options parses the flags into the options of a conversion, without
+// input and output files, and the GPX settings.
+func (f *conversionFlags) options(cfg *config.Config) (cli.Options, *converter.Config, error) {
+	loc, err := f.out.location()
+	if err != nil {
+		return cli.Options{}, nil, err
+	}
+
+	var timeShift cli.TimeShift
+	if *f.timeShift != "" {
+		timeShift, err = cli.ParseTimeShift(*f.timeShift, loc)
+		if err != nil {
+			return cli.Options{}, nil, usageError{err}
+		}
+	}
+
+	var smoothOpts *smooth.Options
+	if *f.smoothMethod != "" {
+		method, err := smooth.ParseMethod(*f.smoothMethod)
+		if err != nil {
+			return cli.Options{}, nil, usageError{err}
+		}
+		if *f.smoothAccel <= 0 {
+			return cli.Options{}, nil, usageErrorf("--smooth-acceleration must be positive")
+		}
+		smoothOpts = &smooth.Options{Method: method, Acceleration: *f.smoothAccel}
+	}
+
+	var resampleOpts *resample.Options
+	if *f.resampleInterval != 0 {
+		method, err := resample.ParseMethod(*f.resampleMethod)
+		if err != nil {
+			return cli.Options{}, nil, usageError{err}
+		}
+		resampleOpts = &resample.Options{Interval: *f.resampleInterval, MaxGap: *f.resampleMaxGap, Method: method}
+	}
+
+	gpx := gpxConfig(cfg)
+	gpx.Creator = *f.creator
+	gpx.Author = *f.author
+	gpx.Copyright = *f.copyright
+	gpx.License = *f.license
+	gpx.Lang = lang
+	if *f.stops {
+		stopOpts, err := f.stopFlags.options()
+		if err != nil {
+			return cli.Options{}, nil, err
+		}
+		gpx.Stops = &stopOpts
+	}
+	if gpx.Laps, err = f.lapFlags.options(); err != nil {
+		return cli.Options{}, nil, err
+	}
+
+	return cli.Options{
+		OutputDir:           f.out.dir,
+		TrackName:           *f.trackName,
+		Location:            loc,
+		InputFormat:         *f.inputFormat,
+		OutputFormat:        *f.outputFormat,
+		DefaultOutputFormat: f.defaultFormat,
+		TimeShift:           timeShift,
+		Smooth:              smoothOpts,
+		FillElevation:       *f.fillElevation,
+		Resample:            resampleOpts,
+	}, gpx, nil
+}
+
+// gzip parses --compress.
+func (f *conversionFlags) gzip() (bool, error) {
+	switch *f.compress {
+	case "":
+		return false, nil
+	case "gzip":
+		return true, nil
+	default:
+		return false, usageErrorf("unknown compression %q (expected gzip)", *f.compress)
+	}
+}
+
+var convertCommand = &command{
+	name:    "convert",
+	args:    "<input.json> [output.gpx] | --bundle <out.zip> <input.json>...",
+	summary: "Convert a track to GPX or another format",
+	help: "Convert a ZweiteGPS log, or any readable format, to GPX or the format given by\n" +
+		"--format. Without an output file, the file is named by --filename-template\n" +
+		"(YYYYMMDD-HHMMSS.<ext> from the track start time by default) and written next\n" +
+		"to the input or into --output-dir. With --bundle, every input is converted into\n" +
+		"one zip archive with a manifest.json instead.\n\n" +
+		"\"zweg [options] <input.json>\" without a command is the same as convert.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		conv := defineConversionFlags(fs, cfg)
+		bundle := fs.String("bundle", "", "Write every output into this zip archive with a manifest.json; takes any number of inputs")
+		checksum := fs.Bool("checksum", false, "Write a .sha256 checksum file next to each output (or the bundle)")
+		resultFormat := defineResultFlag(fs)
+		versionFlag := fs.Bool("version", false, "Show version information")
+
+		return func(args []string) error {
+			if *versionFlag {
+				return versionCommand.run(cfg, nil)
+			}
+			if *bundle != "" && len(args) < 1 {
+				fs.Usage()
+				return usageErrorf("at least 1 argument required (input files)")
+			}
+			if *bundle == "" && (len(args) < 1 || len(args) > 2) {
+				fs.Usage()
+				return usageErrorf("1 or 2 arguments required (input file and optional output file)")
+			}
+
+			gzip, err := conv.gzip()
+			if err != nil {
+				return err
+			}
+			asJSON, err := jsonResults(*resultFormat)
+			if err != nil {
+				return err
+			}
+			opts, gpx, err := conv.options(cfg)
+			if err != nil {
+				return err
+			}
+
+			if *bundle == "" {
+				c, err := conv.out.newCLI(gpx, asJSON, gzip, *checksum, nil)
+				if err != nil {
+					return err
+				}
+				opts.InputFile = args[0]
+				if len(args) == 2 {
+					opts.OutputFile = args[1]
+				}
+				return c.Convert(&opts)
+			}
+			return convertBundle(conv.out, gpx, asJSON, gzip, *checksum, *bundle, args, opts)
+		}
+	},
+}
+
+var verifyCommand = &command{
+	name:    "verify",
+	args:    "<input.json> <output.gpx>",
+	summary: "Check that a stored output still matches its input",
+	help: "Convert input again with the given options and check that output has exactly the\n" +
+		"same content, and that it matches its .sha256 checksum file if there is one.\n" +
+		"Pass the options the output was converted with.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		conv := defineConversionFlags(fs, cfg)
+
+		return func(args []string) error {
+			if len(args) != 2 {
+				fs.Usage()
+				return usageErrorf("exactly 2 arguments required (input file and output file)")
+			}
+			gzip, err := conv.gzip()
+			if err != nil {
+				return err
+			}
+			opts, gpx, err := conv.options(cfg)
+			if err != nil {
+				return err
+			}
+			c, err := conv.out.newCLI(gpx, false, gzip, false, nil)
+			if err != nil {
+				return err
+			}
+			opts.InputFile, opts.OutputFile = args[0], args[1]
+			return c.Verify(&opts)
+		}
+	},
+}
+
+// convertBundle converts every input into the zip archive bundle. An
+// input that fails does not stop the others, and the bundle of those that
+// succeeded still gets its checksum.
+func convertBundle(out *outputFlags, gpx *converter.Config, asJSON, gzip, checksum bool, bundle string, inputs []string, opts cli.Options) (err error) {
+	b, err := fileio.CreateBundle(bundle)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := b.Close()
+		if closeErr == nil && checksum {
+			closeErr = fileio.WriteChecksum(bundle)
+		}
+		err = errors.Join(err, closeErr)
+	}()
+
+	c, err := out.newCLI(gpx, asJSON, gzip, false, b)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, input := range inputs {
+		opts := opts
+		opts.InputFile = input
+		if err := c.Convert(&opts); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var watchCommand = &command{
+	name:    "watch",
+	args:    "<dir>",
+	summary: "Convert new logs in a directory as they appear",
+	help:    "Convert each new or modified .json log in dir once it has stopped changing.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		out := defineOutputFlags(fs, cfg, "Output directory (defaults to the watched directory)")
+		outputFormat := fs.String("format", cfg.Format, "Output format (defaults to gpx; see \"zweg formats\")")
+		stateFile := fs.String("state", "", "File recording already converted logs (defaults to <dir>/"+watch.DefaultStateFile+")")
+		settle := fs.Duration("settle", watch.DefaultSettle, "How long a file must stay unchanged before it is converted")
+		interval := fs.Duration("interval", watch.DefaultInterval, "How often to check for changes")
+		poll := fs.Bool("poll", false, "Always poll instead of using file system notifications")
+		resultFormat := defineResultFlag(fs)
+
+		return func(args []string) error {
+			if len(args) != 1 {
+				fs.Usage()
+				return usageErrorf("exactly 1 argument required (directory to watch)")
+			}
+
+			loc, err := out.location()
+			if err != nil {
+				return err
+			}
+			asJSON, err := jsonResults(*resultFormat)
+			if err != nil {
+				return err
+			}
+
+			gpx := gpxConfig(cfg)
+			gpx.Lang = lang
+			c, err := out.newCLI(gpx, asJSON, false, false, nil)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			w := watch.New(&watch.Config{
+				Dir:       args[0],
+				StateFile: *stateFile,
+				Interval:  *interval,
+				Settle:    *settle,
+				Poll:      *poll,
+				Log:       os.Stderr,
+				Convert: func(path string) error {
+					return c.Convert(&cli.Options{
+						InputFile:    path,
+						OutputDir:    out.dir,
+						Location:     loc,
+						OutputFormat: *outputFormat,
+					})
+				},
+			})
+			return w.Run(ctx)
+		}
+	},
+}
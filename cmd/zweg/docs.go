@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/chocoby/zweg/internal/clidoc"
+	"github.com/chocoby/zweg/internal/config"
+	"github.com/chocoby/zweg/internal/format"
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/render"
+	"github.com/chocoby/zweg/internal/resample"
+	"github.com/chocoby/zweg/internal/smooth"
+	"github.com/chocoby/zweg/internal/stats"
+)
+
+var completionCommand = &command{
+	name:    "completion",
+	args:    "bash|zsh|fish",
+	summary: "Print a shell completion script",
+	help: "Print a completion script for bash, zsh or fish. For example:\n\n" +
+		"  zweg completion bash > /etc/bash_completion.d/zweg\n" +
+		"  zweg completion zsh > \"${fpath[1]}/_zweg\"\n" +
+		"  zweg completion fish > ~/.config/fish/completions/zweg.fish",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		return func(args []string) error {
+			if len(args) != 1 {
+				fs.Usage()
+				return usageErrorf("exactly 1 argument required (bash, zsh or fish)")
+			}
+			switch args[0] {
+			case "bash":
+				return clidoc.Bash(os.Stdout, program())
+			case "zsh":
+				return clidoc.Zsh(os.Stdout, program())
+			case "fish":
+				return clidoc.Fish(os.Stdout, program())
+			default:
+				return usageErrorf("unknown shell %q (expected bash, zsh or fish)", args[0])
+			}
+		}
+	},
+}
+
+var manCommand = &command{
+	name:    "man",
+	summary: "Print the man page",
+	help:    "Print the zweg(1) man page in roff. Read it with \"zweg man | man -l -\".",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		return func(args []string) error {
+			if len(args) != 0 {
+				fs.Usage()
+				return usageErrorf("man takes no arguments")
+			}
+			return clidoc.Man(os.Stdout, program())
+		}
+	},
+}
+
+// program describes the commands and their flags for the completion
+// scripts and the man page. Flags show the built-in defaults, not those of
+// the configuration files, since the output is meant to be shipped.
+func program() *clidoc.Program {
+	p := &clidoc.Program{
+		Name:    "zweg",
+		Version: version,
+		Summary: "convert and inspect ZweiteGPS logs",
+		Description: "zweg converts ZweiteGPS JSON logs to GPX and other formats, prints track\n" +
+			"statistics, draws maps and profiles, and geotags photos. Without a command,\n" +
+			"zweg converts.\n\n" +
+			"Option defaults can be set in the configuration files; options given on the\n" +
+			"command line override them. Run \"zweg help <command>\" for the options of a\n" +
+			"command.",
+		DefaultCommand: convertCommand.name,
+		Files: []clidoc.File{
+			{Path: "~/.config/zweg/config.toml", Description: "User configuration file, or $XDG_CONFIG_HOME/zweg/config.toml."},
+			{Path: config.ProjectFile, Description: "Project configuration file, looked up from the current directory upwards.\nIts settings override the user file."},
+		},
+	}
+
+	for _, s := range exitStatuses {
+		p.ExitStatus = append(p.ExitStatus, clidoc.ExitStatus{Code: s.code, Description: s.description})
+	}
+
+	cfg := defaultConfig()
+	zones := timezoneNames()
+	for _, c := range commands {
+		fset, _ := c.flagSet(&cfg)
+		doc := clidoc.Command{
+			Name:     c.name,
+			Aliases:  c.aliases,
+			Synopsis: c.args,
+			Summary:  c.summary,
+			Help:     c.help,
+			// An argument in angle brackets names a file; the others
+			// are words such as "show".
+			Files: strings.Contains(c.args, "<"),
+		}
+		if c.args != "" && !doc.Files {
+			doc.Args = strings.FieldsFunc(c.args, func(r rune) bool { return r == '|' || r == ' ' })
+		}
+		fset.VisitAll(func(f *flag.Flag) {
+			arg, usage := flag.UnquoteUsage(f)
+			def := f.DefValue
+			if f.Name == "lang" {
+				// --lang shows the language already in use.
+				def = cfg.Lang
+			}
+			switch {
+			case def == "0" || def == "false" || def == "0s":
+				def = ""
+			case arg == "string" && def != "":
+				def = fmt.Sprintf("%q", def)
+			}
+			values, files := flagValues(c, f.Name, zones)
+			doc.Flags = append(doc.Flags, clidoc.Flag{
+				Name:    f.Name,
+				Arg:     arg,
+				Usage:   usage,
+				Default: def,
+				Values:  values,
+				Files:   files,
+			})
+		})
+		p.Commands = append(p.Commands, doc)
+	}
+	return p
+}
+
+// flagValues returns the values completed for a flag of c, and whether
+// the flag takes a file name.
+func flagValues(c *command, name string, zones []string) (values []string, files bool) {
+	switch name {
+	case "format":
+		if c == renderCommand {
+			return []string{"svg", "png"}, false
+		}
+		return formatNames((*format.Format).CanWrite), false
+	case "input-format":
+		return formatNames((*format.Format).CanRead), false
+	case "timezone-offset", "camera-tz":
+		return zones, false
+	case "lang":
+		return []string{string(i18n.English), string(i18n.Japanese)}, false
+	case "color-by":
+		return []string{string(render.ColorBySolid), string(render.ColorBySpeed), string(render.ColorByElevation), string(render.ColorByMeans)}, false
+	case "metric":
+		return []string{string(render.MetricElevation), string(render.MetricSpeed), string(render.MetricCadence)}, false
+	case "output-format":
+		return []string{"text", "json"}, false
+	case "compress":
+		return []string{"gzip"}, false
+	case "splits":
+		return []string{string(stats.Kilometer), string(stats.Mile)}, false
+	case "smooth":
+		return []string{string(smooth.Kalman)}, false
+	case "resample-method":
+		return []string{string(resample.Linear), string(resample.Nearest)}, false
+	case "d", "output-dir", "log", "state", "bundle":
+		return nil, true
+	}
+	return nil, false
+}
+
+func formatNames(want func(*format.Format) bool) []string {
+	var names []string
+	for _, f := range format.Default.Formats() {
+		if want(f) {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// zoneinfoDirs are where Unix systems keep the IANA time zone database.
+var zoneinfoDirs = []string{"/usr/share/zoneinfo", "/usr/share/lib/zoneinfo", "/usr/lib/locale/TZ"}
+
+// timezoneNames lists the IANA time zone names of the first zoneinfo
+// directory found, or $ZONEINFO. The time package cannot list its zones,
+// so completion offers those of the system generating the script.
+func timezoneNames() []string {
+	dirs := zoneinfoDirs
+	if dir := os.Getenv("ZONEINFO"); dir != "" {
+		dirs = append([]string{dir}, dirs...)
+	}
+	for _, dir := range dirs {
+		if names := zoneFiles(dir); len(names) > 0 {
+			return names
+		}
+	}
+	return nil
+}
+
+func zoneFiles(dir string) []string {
+	var names []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return nil
+		}
+		name := filepath.ToSlash(strings.TrimPrefix(path, dir+string(filepath.Separator)))
+		// Zone names start with an upper-case letter; posix/ and right/
+		// repeat the database with other leap second handling.
+		if c := d.Name()[0]; c < 'A' || c > 'Z' || name == "posix" || name == "right" || name == "Factory" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err == nil && bytes.HasPrefix(data, []byte("TZif")) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chocoby/zweg/internal/cli"
+	"github.com/chocoby/zweg/internal/config"
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/privacy"
+	"github.com/chocoby/zweg/internal/stats"
+)
+
+// zoneList collects repeated --privacy-zone flags on top of the zones
+// from the configuration files.
+type zoneList []privacy.Zone
+
+func (z *zoneList) String() string {
+	if z == nil {
+		return ""
+	}
+	parts := make([]string, len(*z))
+	for i, zone := range *z {
+		parts[i] = zone.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (z *zoneList) Set(s string) error {
+	zone, err := privacy.ParseZone(s)
+	if err != nil {
+		return err
+	}
+	*z = append(*z, zone)
+	return nil
+}
+
+// outputFlags are the flags shared by the commands that generate output
+// filenames.
+type outputFlags struct {
+	dir              string
+	timezone         string
+	filenameTemplate string
+	zones            *zoneList
+	lenient          *bool
+}
+
+func defineOutputFlags(fs *flag.FlagSet, cfg *config.Config, dirUsage string) *outputFlags {
+	o := &outputFlags{}
+	fs.StringVar(&o.dir, "d", cfg.OutputDir, dirUsage)
+	fs.StringVar(&o.dir, "output-dir", cfg.OutputDir, dirUsage)
+	fs.StringVar(&o.timezone, "timezone-offset", cfg.Timezone, "Time zone for timestamps and generated filenames, as ±HH:MM or an IANA name (e.g., +09:00, Asia/Tokyo)")
+	fs.StringVar(&o.filenameTemplate, "filename-template", cfg.FilenameTemplate, "Go template for generated output filenames, without the extension")
+	o.zones = definePrivacyZoneFlag(fs, cfg, "Drop points within a circle, as `lat,lon,radius_m[,name]`; repeatable, adds to the configured zones")
+	o.lenient = defineLenientFlag(fs)
+	return o
+}
+
+// definePrivacyZoneFlag defines --privacy-zone, starting from the zones in
+// the configuration file.
+func definePrivacyZoneFlag(fs *flag.FlagSet, cfg *config.Config, usage string) *zoneList {
+	zones := zoneList(cfg.Zones())
+	fs.Var(&zones, "privacy-zone", usage)
+	return &zones
+}
+
+// defineLenientFlag defines --lenient for the commands that read a track.
+func defineLenientFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("lenient", false, "Treat altitude, speed and distance values that are not numbers as not recorded, with a warning, instead of failing")
+}
+
+// stopFlags tune stop detection.
+type stopFlags struct {
+	radius   *float64
+	duration *time.Duration
+}
+
+func defineStopFlags(fs *flag.FlagSet) *stopFlags {
+	return &stopFlags{
+		radius:   fs.Float64("stop-radius", stats.DefaultStopRadius, "Distance in meters the position may wander during a stop"),
+		duration: fs.Duration("stop-duration", stats.DefaultStopDuration, "Shortest stay that counts as a stop"),
+	}
+}
+
+// options parses --stop-radius and --stop-duration.
+func (f *stopFlags) options() (stats.StopOptions, error) {
+	if *f.radius <= 0 {
+		return stats.StopOptions{}, usageErrorf("--stop-radius must be positive")
+	}
+	if *f.duration <= 0 {
+		return stats.StopOptions{}, usageErrorf("--stop-duration must be positive")
+	}
+	return stats.StopOptions{Radius: *f.radius, MinDuration: *f.duration}, nil
+}
+
+// lapFlags divide a track into laps.
+type lapFlags struct {
+	splits         *string
+	distance       *float64
+	time           *time.Duration
+	deviceDistance *bool
+}
+
+func defineLapFlags(fs *flag.FlagSet) *lapFlags {
+	return &lapFlags{
+		splits:         fs.String("splits", "", "Split the track every `km` or mi, with the pace in that unit"),
+		distance:       fs.Float64("lap-distance", 0, "Start a lap every this many meters"),
+		time:           fs.Duration("lap-time", 0, "Start a lap every this duration, e.g. 5m"),
+		deviceDistance: fs.Bool("device-distance", false, "Measure laps by the distance the device recorded (ds) instead of between positions; alone, it implies --splits km"),
+	}
+}
+
+// options parses the lap flags. It returns nil when none of them is
+// given; --device-distance alone splits by kilometer.
+func (f *lapFlags) options() (*stats.LapOptions, error) {
+	if *f.splits == "" && *f.distance == 0 && *f.time == 0 && !*f.deviceDistance {
+		return nil, nil
+	}
+	unit, err := stats.ParseUnit(*f.splits)
+	if err != nil {
+		return nil, usageError{err}
+	}
+	if *f.distance < 0 || *f.time < 0 {
+		return nil, usageErrorf("--lap-distance and --lap-time must be positive")
+	}
+	if *f.distance > 0 && *f.time > 0 {
+		return nil, usageErrorf("--lap-distance and --lap-time cannot be combined")
+	}
+	return &stats.LapOptions{Unit: unit, Distance: *f.distance, Time: *f.time, DeviceDistance: *f.deviceDistance}, nil
+}
+
+// location parses --timezone-offset.
+func (o *outputFlags) location() (*time.Location, error) {
+	loc, err := cli.ParseLocation(o.timezone)
+	if err != nil {
+		return nil, usageErrorf("invalid timezone offset: %w", err)
+	}
+	return loc, nil
+}
+
+// newCLI creates a CLI that names and filters output as the flags say.
+// gpx may be nil for commands that write no GPX; asJSON reports each
+// conversion as JSON, gzip compresses the outputs, checksum writes their
+// sidecars and bundle, if not nil, collects them.
+func (o *outputFlags) newCLI(gpx *converter.Config, asJSON, gzip, checksum bool, bundle *fileio.Bundle) (*cli.CLI, error) {
+	if _, err := cli.ParseFilenameTemplate(o.filenameTemplate); err != nil {
+		return nil, usageError{err}
+	}
+	return cli.New(&cli.Config{
+		GPX:              gpx,
+		Lang:             lang,
+		Stdout:           os.Stdout,
+		Stderr:           os.Stderr,
+		FilenameTemplate: o.filenameTemplate,
+		PrivacyZones:     *o.zones,
+		JSON:             asJSON,
+		Lenient:          *o.lenient,
+		Gzip:             gzip,
+		Bundle:           bundle,
+		Checksum:         checksum,
+	}), nil
+}
+
+// defineResultFlag defines --output-format for the commands that convert.
+func defineResultFlag(fs *flag.FlagSet) *string {
+	return fs.String("output-format", "text", "How to report each conversion: text, or json for one JSON object per input")
+}
+
+// jsonResults parses --output-format.
+func jsonResults(s string) (bool, error) {
+	switch s {
+	case "text":
+		return false, nil
+	case "json":
+		return true, nil
+	default:
+		return false, usageErrorf("unknown output format %q (expected text or json)", s)
+	}
+}
+
+// gpxConfig returns the GPX settings from the configuration files.
+func gpxConfig(cfg *config.Config) *converter.Config {
+	c := converter.DefaultConfig()
+	c.Creator = cfg.Creator
+	c.Author = cfg.Author
+	c.Copyright = cfg.Copyright
+	c.License = cfg.License
+	return c
+}
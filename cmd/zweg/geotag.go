@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chocoby/zweg/internal/cli"
+	"github.com/chocoby/zweg/internal/config"
+	"github.com/chocoby/zweg/internal/geotag"
+)
+
+var geotagCommand = &command{
+	name:    "geotag",
+	args:    "--log <track.json> <photo.jpg>...",
+	summary: "Write track positions into JPEG photos",
+	help:    "Write the track position at each photo's capture time into its EXIF GPS tags.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		logFile := fs.String("log", "", "Track log to take positions from (required)")
+		inputFormat := fs.String("input-format", cfg.InputFormat, "Format of the track log (defaults to detection from extension or content)")
+		cameraTZ := fs.String("camera-tz", "", "Time zone of the camera clock, as ±HH:MM or an IANA name (defaults to the photo's recorded offset, then local time)")
+		clockOffset := fs.Duration("clock-offset", 0, "How far the camera clock is ahead of the true time, e.g. 1m30s or -45s")
+		maxGap := fs.Duration("max-gap", geotag.DefaultMaxGap, "Longest recording gap, or distance beyond the track ends, to match photos in")
+		dryRun := fs.Bool("dry-run", false, "List matches without modifying photos")
+		zones := definePrivacyZoneFlag(fs, cfg, "Leave photos within a circle untagged, as `lat,lon,radius_m[,name]`; repeatable, adds to the configured zones")
+		lenient := defineLenientFlag(fs)
+
+		return func(args []string) error {
+			if *logFile == "" || len(args) == 0 {
+				fs.Usage()
+				return usageErrorf("a track log (--log) and at least one photo are required")
+			}
+
+			opts := geotag.Options{ClockOffset: *clockOffset, MaxGap: *maxGap, DryRun: *dryRun}
+			if *cameraTZ != "" {
+				loc, err := cli.ParseLocation(*cameraTZ)
+				if err != nil {
+					return usageErrorf("invalid camera time zone: %w", err)
+				}
+				opts.Location = loc
+			}
+
+			photos, err := expandGlobs(args)
+			if err != nil {
+				return err
+			}
+
+			c := cli.New(&cli.Config{Lang: lang, Stdout: os.Stdout, Stderr: os.Stderr, PrivacyZones: *zones, Lenient: *lenient})
+			return c.Geotag(&cli.GeotagOptions{
+				LogFile:     *logFile,
+				InputFormat: *inputFormat,
+				Photos:      photos,
+				Geotag:      opts,
+			})
+		}
+	},
+}
+
+// expandGlobs expands patterns the shell left alone (for example on
+// Windows); arguments that name existing files are kept as they are.
+func expandGlobs(args []string) ([]string, error) {
+	var out []string
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err == nil || !strings.ContainsAny(arg, "*?[") {
+			out = append(out, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", arg)
+		}
+		out = append(out, matches...)
+	}
+	return out, nil
+}
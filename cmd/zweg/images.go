@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/chocoby/zweg/internal/cli"
+	"github.com/chocoby/zweg/internal/config"
+	"github.com/chocoby/zweg/internal/render"
+)
+
+var renderCommand = &command{
+	name:    "render",
+	args:    "<input> [output.svg|output.png]",
+	summary: "Draw a track to an SVG or PNG image",
+	help:    "Draw a track to an SVG or PNG image using a local projection.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		defaults := render.DefaultMapOptions()
+
+		out := defineOutputFlags(fs, cfg, "Output directory (ignored if output file is specified)")
+		inputFormat := fs.String("input-format", cfg.InputFormat, "Input format (defaults to detection from extension or content)")
+		imageFormat := fs.String("format", "", "Image format: svg or png (defaults to the output file extension, or svg)")
+		width := fs.Int("width", defaults.Width, "Image width in pixels")
+		height := fs.Int("height", defaults.Height, "Image height in pixels")
+		lineColor := fs.String("color", defaults.Color, "Line colour as #rrggbb when --color-by is solid")
+		lineWidth := fs.Float64("line-width", defaults.LineWidth, "Line width in pixels")
+		colorBy := fs.String("color-by", string(render.ColorBySolid), "Line colouring: solid, speed, elevation or means")
+		noMarkers := fs.Bool("no-markers", false, "Omit the Start and Goal markers")
+		noScale := fs.Bool("no-scale", false, "Omit the scale bar")
+
+		return func(args []string) error {
+			if len(args) < 1 || len(args) > 2 {
+				fs.Usage()
+				return usageErrorf("1 or 2 arguments required (input file and optional output file)")
+			}
+			if err := checkSize(*width, *height); err != nil {
+				return err
+			}
+
+			mode, err := render.ParseColorBy(*colorBy)
+			if err != nil {
+				return usageError{err}
+			}
+			color, err := render.ParseColor(*lineColor)
+			if err != nil {
+				return usageError{err}
+			}
+			loc, err := out.location()
+			if err != nil {
+				return err
+			}
+
+			opts := defaults
+			opts.Width, opts.Height = *width, *height
+			opts.Color = color
+			opts.LineWidth = *lineWidth
+			opts.ColorBy = mode
+			opts.Markers = !*noMarkers
+			opts.ScaleBar = !*noScale
+
+			c, err := out.newCLI(nil, false, false, false, nil)
+			if err != nil {
+				return err
+			}
+			return c.Render(&cli.RenderOptions{
+				InputFile:   args[0],
+				InputFormat: *inputFormat,
+				OutputFile:  argOrEmpty(args, 1),
+				OutputDir:   out.dir,
+				Format:      *imageFormat,
+				Location:    loc,
+				Map:         opts,
+			})
+		}
+	},
+}
+
+var profileCommand = &command{
+	name:    "profile",
+	args:    "<input> [output.svg]",
+	summary: "Plot an elevation, speed or cadence profile as SVG",
+	help:    "Plot elevation against distance, or speed or cadence against time, as SVG.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		out := defineOutputFlags(fs, cfg, "Output directory (ignored if output file is specified)")
+		inputFormat := fs.String("input-format", cfg.InputFormat, "Input format (defaults to detection from extension or content)")
+		metric := fs.String("metric", string(render.MetricElevation), "Profile to plot: elevation, speed or cadence")
+		cadence := fs.Bool("cadence", false, "Overlay step cadence from ws on the elevation or speed profile")
+		width := fs.Int("width", 800, "Chart width in pixels")
+		height := fs.Int("height", 240, "Chart height in pixels")
+
+		return func(args []string) error {
+			if len(args) < 1 || len(args) > 2 {
+				fs.Usage()
+				return usageErrorf("1 or 2 arguments required (input file and optional output file)")
+			}
+			if err := checkSize(*width, *height); err != nil {
+				return err
+			}
+
+			m, err := render.ParseMetric(*metric)
+			if err != nil {
+				return usageError{err}
+			}
+			loc, err := out.location()
+			if err != nil {
+				return err
+			}
+
+			c, err := out.newCLI(nil, false, false, false, nil)
+			if err != nil {
+				return err
+			}
+			return c.Profile(&cli.ProfileOptions{
+				InputFile:   args[0],
+				InputFormat: *inputFormat,
+				OutputFile:  argOrEmpty(args, 1),
+				OutputDir:   out.dir,
+				Metric:      m,
+				Cadence:     *cadence && m != render.MetricCadence,
+				Width:       *width,
+				Height:      *height,
+				Location:    loc,
+			})
+		}
+	},
+}
+
+// checkSize validates --width and --height.
+func checkSize(width, height int) error {
+	if width <= 0 || height <= 0 || width > render.MaxSize || height > render.MaxSize {
+		return usageErrorf("invalid size %dx%d: width and height must be between 1 and %d", width, height, render.MaxSize)
+	}
+	return nil
+}
+
+// argOrEmpty returns args[i], or "" for an omitted optional argument.
+func argOrEmpty(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/chocoby/zweg/internal/cli"
+	"github.com/chocoby/zweg/internal/config"
+)
+
+var infoCommand = &command{
+	name:    "info",
+	aliases: []string{"stats"},
+	args:    "<input>",
+	summary: "Print statistics of a track",
+	help:    "Print the distance, duration, elevation and speed statistics of a track, the\nplaces where it stopped and, with --splits, --lap-distance or --lap-time, its\nlaps.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		inputFormat := fs.String("input-format", cfg.InputFormat, "Input format (defaults to detection from extension or content)")
+		asJSON := fs.Bool("json", false, "Print statistics as JSON")
+		lenient := defineLenientFlag(fs)
+		stops := defineStopFlags(fs)
+		laps := defineLapFlags(fs)
+
+		return func(args []string) error {
+			if len(args) != 1 {
+				fs.Usage()
+				return usageErrorf("exactly 1 argument required (input file)")
+			}
+			stopOpts, err := stops.options()
+			if err != nil {
+				return err
+			}
+			lapOpts, err := laps.options()
+			if err != nil {
+				return err
+			}
+			c := cli.New(&cli.Config{Lang: lang, Stdout: os.Stdout, Stderr: os.Stderr, Lenient: *lenient})
+			return c.Stats(args[0], cli.StatsOptions{InputFormat: *inputFormat, Stops: stopOpts, Laps: lapOpts, JSON: *asJSON})
+		}
+	},
+}
+
+var validateCommand = &command{
+	name:    "validate",
+	args:    "<input.json>",
+	summary: "Check a ZweiteGPS log for invalid values",
+	help: "Report out-of-range coordinates, unparsable numbers and timestamp problems in a\n" +
+		"ZweiteGPS log, or in each log of a zip archive. Exits with a non-zero status\n" +
+		"when a log has errors.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		asJSON := fs.Bool("json", false, "Print the report as JSON")
+
+		return func(args []string) error {
+			if len(args) != 1 {
+				fs.Usage()
+				return usageErrorf("exactly 1 argument required (input file)")
+			}
+			c := cli.New(&cli.Config{Lang: lang, Stdout: os.Stdout, Stderr: os.Stderr})
+			return c.Validate(args[0], *asJSON)
+		}
+	},
+}
+
+var formatsCommand = &command{
+	name:    "formats",
+	summary: "List the available input and output formats",
+	help:    "List the registered formats with their extensions and whether they can be read\nand written.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		return func(args []string) error {
+			if len(args) != 0 {
+				fs.Usage()
+				return usageErrorf("formats takes no arguments")
+			}
+			return cli.New(&cli.Config{Lang: lang, Stdout: os.Stdout, Stderr: os.Stderr}).ListFormats()
+		}
+	},
+}
@@ -1,17 +1,77 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	// Embedded so that IANA time zone names work where the system has no
+	// zoneinfo database, such as on Windows.
+	_ "time/tzdata"
 
 	"github.com/chocoby/zweg/internal/cli"
+	"github.com/chocoby/zweg/internal/config"
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/render"
 )
 
+// Exit statuses. A wrapper can skip an input that fails with exitNotFound
+// or exitInvalidInput, and retry one that fails with exitWrite.
 const (
-	exitFailure = 1
+	exitFailure      = 1
+	exitUsage        = 2
+	exitNotFound     = 3
+	exitInvalidInput = 4
+	exitUnsafePath   = 5
+	exitWrite        = 6
+	exitMismatch     = 7
 )
 
+// exitStatuses documents the exit statuses and maps errors to them.
+var exitStatuses = []struct {
+	code        int
+	errs        []error
+	description string
+}{
+	{0, nil, "Success."},
+	{exitFailure, nil, "Any other error."},
+	{exitUsage, nil, "Invalid command line, such as an unknown flag or a missing argument."},
+	{exitNotFound, []error{fileio.ErrInputNotFound}, "The input file does not exist."},
+	{exitInvalidInput, []error{fileio.ErrInvalidJSON, fileio.ErrEmptyLog, fileio.ErrBadArchive, converter.ErrNoPoints, converter.ErrBadAltitude, converter.ErrBadSpeed, converter.ErrBadDistance, render.ErrNoData},
+		"The input is not valid: malformed JSON, a log without points, a corrupt gzip or zip file, an altitude, speed or distance that is not a number, or nothing to plot in a profile."},
+	{exitUnsafePath, []error{cli.ErrUnsafeOutputPath}, "The output path would leave its directory."},
+	{exitWrite, []error{fileio.ErrWrite}, "The output could not be written, for example because the disk is full."},
+	{exitMismatch, []error{cli.ErrMismatch}, "zweg verify found an output that does not match its input or checksum."},
+}
+
+// usageError is an error in the command line. Errors from the flag
+// package already exit with exitUsage.
+type usageError struct{ error }
+
+func usageErrorf(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// exitCode returns the exit status for err.
+func exitCode(err error) int {
+	if errors.As(err, new(usageError)) {
+		return exitUsage
+	}
+	for _, s := range exitStatuses {
+		for _, target := range s.errs {
+			if errors.Is(err, target) {
+				return s.code
+			}
+		}
+	}
+	return exitFailure
+}
+
 var (
 	// Version information - set via ldflags during build
 	version = "dev"
@@ -19,60 +79,197 @@ var (
 	date    = "unknown"
 )
 
-func main() {
-	if err := run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(exitFailure)
-	}
-}
-
-func run() error {
-	trackName := flag.String("track-name", "", "Name for the GPS track (defaults to the recorded tl, or \"Track\" if absent)")
-	outputDir := flag.String("d", "", "Output directory (ignored if output file is specified)")
-	flag.StringVar(outputDir, "output-dir", "", "Output directory (ignored if output file is specified)")
-	timezoneOffsetStr := flag.String("timezone-offset", "+00:00", "Timezone offset for GPX timestamps (e.g., +09:00, -05:00)")
-	versionFlag := flag.Bool("version", false, "Show version information")
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options] <input.json> [output.gpx]\n\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "Convert ZweiteGPS JSON format to GPX format.\n\n")
-		fmt.Fprintf(os.Stderr, "Options:\n")
-		flag.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nArguments:\n")
-		fmt.Fprintf(os.Stderr, "  input.json    Input file in ZweiteGPS JSON format\n")
-		fmt.Fprintf(os.Stderr, "  output.gpx    Output file in GPX format (optional, defaults to YYYYMMDD-HHMMSS.gpx based on track start time)\n")
-	}
-
-	flag.Parse()
-
-	if *versionFlag {
-		fmt.Printf("zweg version %s\n", version)
-		fmt.Printf("  commit: %s\n", commit)
-		fmt.Printf("  built:  %s\n", date)
-		return nil
+// lang is the language chosen with --lang. It is package level so main can
+// report the final error in it too.
+var lang i18n.Lang
+
+// langFlag sets lang from a --lang flag.
+type langFlag struct{}
+
+func (langFlag) String() string { return string(lang) }
+
+func (langFlag) Set(s string) error {
+	l, err := i18n.Parse(s)
+	if err != nil {
+		return err
+	}
+	lang = l
+	return nil
+}
+
+const langUsage = "Language of messages and output: `en` or ja"
+
+// command is a zweg subcommand.
+type command struct {
+	name    string
+	aliases []string
+	// args is the argument synopsis shown after [options].
+	args string
+	// summary is the one-line description in the command list; help is
+	// the longer description in the command's own usage.
+	summary string
+	help    string
+	// define registers the command's flags on fs, with defaults taken
+	// from cfg, and returns the function that runs it on the remaining
+	// arguments. Keeping the two apart lets help list the flags without
+	// running anything.
+	define func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error
+}
+
+// commands lists the subcommands in the order help shows them. It is set
+// in init because completion and man describe the commands themselves.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		convertCommand,
+		infoCommand,
+		validateCommand,
+		verifyCommand,
+		formatsCommand,
+		renderCommand,
+		profileCommand,
+		geotagCommand,
+		watchCommand,
+		serveCommand,
+		configCommand,
+		completionCommand,
+		manCommand,
+		versionCommand,
+	}
+}
+
+func lookupCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+		for _, alias := range c.aliases {
+			if alias == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// flagSet defines the flags of c on a new FlagSet and returns it with the
+// run function. Every command accepts --lang.
+func (c *command) flagSet(cfg *config.Config) (*flag.FlagSet, func(args []string) error) {
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	run := c.define(fs, cfg)
+	fs.Var(langFlag{}, "lang", langUsage)
+	fs.Usage = func() { c.usage(os.Stderr, fs) }
+	return fs, run
+}
+
+// usage writes the help of c, including its flags.
+func (c *command) usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: %s %s [options]", progName(), c.name)
+	if c.args != "" {
+		fmt.Fprintf(w, " %s", c.args)
 	}
+	fmt.Fprintf(w, "\n\n%s\n", c.help)
+	if len(c.aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(c.aliases, ", "))
+	}
+	fmt.Fprintf(w, "\nOptions:\n")
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
 
-	nArgs := flag.NArg()
-	if nArgs < 1 || nArgs > 2 {
-		flag.Usage()
-		return fmt.Errorf("1 or 2 arguments required (input file and optional output file)")
+func (c *command) run(cfg *config.Config, args []string) error {
+	fs, run := c.flagSet(cfg)
+	_ = fs.Parse(args)
+	return run(fs.Args())
+}
+
+func progName() string {
+	return filepath.Base(os.Args[0])
+}
+
+// usage writes the top-level help.
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [options] [arguments]\n", progName())
+	fmt.Fprintf(w, "       %s [convert options] <input.json> [output.gpx]\n\n", progName())
+	fmt.Fprintf(w, "Convert and inspect ZweiteGPS logs. Without a command, zweg converts.\n\n")
+	fmt.Fprintf(w, "Commands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
 	}
+	fmt.Fprintf(w, "\nRun \"%s help <command>\" for the options of a command.\n", progName())
+}
 
-	inputFile := flag.Arg(0)
-	outputFile := ""
-	if nArgs == 2 {
-		outputFile = flag.Arg(1)
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprint(os.Stderr, lang.Sprintf("Error: %v\n", err))
+		os.Exit(exitCode(err))
 	}
+}
 
-	// Parse timezone offset for filename generation
-	timezoneOffset, err := cli.ParseTimezoneOffset(*timezoneOffsetStr)
+func run(args []string) error {
+	cfg, err := loadConfig()
 	if err != nil {
-		return fmt.Errorf("invalid timezone offset: %w", err)
+		return err
 	}
 
-	c := cli.New(&cli.Config{
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-	})
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return usageErrorf("no command or input file given")
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		return runHelp(cfg, args[1:])
+	case "-version", "--version":
+		return versionCommand.run(cfg, nil)
+	}
+
+	if c := lookupCommand(args[0]); c != nil {
+		return c.run(cfg, args[1:])
+	}
+
+	// Before subcommands existed zweg only converted, and scripts call
+	// "zweg [options] input.json [output]". Anything that is not a
+	// command goes to convert, unless it looks like a mistyped command.
+	if arg := args[0]; !strings.HasPrefix(arg, "-") && filepath.Ext(arg) == "" {
+		if _, err := os.Stat(arg); err != nil {
+			usage(os.Stderr)
+			return usageErrorf("unknown command %q", arg)
+		}
+	}
+	return convertCommand.run(cfg, args)
+}
+
+func runHelp(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return nil
+	}
+	c := lookupCommand(args[0])
+	if c == nil {
+		usage(os.Stderr)
+		return usageErrorf("unknown command %q", args[0])
+	}
+	fs, _ := c.flagSet(cfg)
+	c.usage(os.Stdout, fs)
+	return nil
+}
 
-	return c.Run(inputFile, outputFile, *outputDir, *trackName, timezoneOffset)
+var versionCommand = &command{
+	name:    "version",
+	summary: "Show version information",
+	help:    "Print the version, commit and build date of zweg.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		return func(args []string) error {
+			if len(args) != 0 {
+				fs.Usage()
+				return usageErrorf("version takes no arguments")
+			}
+			fmt.Printf("zweg version %s\n", version)
+			fmt.Printf("  commit: %s\n", commit)
+			fmt.Printf("  built:  %s\n", date)
+			return nil
+		}
+	},
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/chocoby/zweg/internal/config"
+	"github.com/chocoby/zweg/internal/server"
+)
+
+var serveCommand = &command{
+	name:    "serve",
+	summary: "Run the HTTP conversion server",
+	help:    "Serve POST /convert, /stats and /validate over HTTP.",
+	define: func(fs *flag.FlagSet, cfg *config.Config) func(args []string) error {
+		listen := fs.String("listen", ":8080", "Address to listen on")
+		maxBody := fs.Int64("max-body", server.DefaultMaxBodyBytes, "Maximum request body size in bytes")
+		timeout := fs.Duration("timeout", server.DefaultTimeout, "Maximum time to handle a single request")
+		zones := definePrivacyZoneFlag(fs, cfg, "Drop points within a circle from every request, as `lat,lon,radius_m[,name]`; repeatable, adds to the configured zones")
+
+		return func(args []string) error {
+			if len(args) != 0 {
+				fs.Usage()
+				return usageErrorf("serve takes no arguments")
+			}
+
+			gpx := gpxConfig(cfg)
+			gpx.Lang = lang
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			srv := server.New(&server.Config{
+				Addr:         *listen,
+				MaxBodyBytes: *maxBody,
+				Timeout:      *timeout,
+				GPX:          gpx,
+				PrivacyZones: *zones,
+			})
+			return srv.ListenAndServe(ctx, func(addr net.Addr) {
+				fmt.Fprintf(os.Stderr, "zweg %s listening on %s\n", version, addr)
+			})
+		}
+	},
+}
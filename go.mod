@@ -2,10 +2,14 @@ module github.com/chocoby/zweg
 
 go 1.25.3
 
-require github.com/twpayne/go-gpx v1.5.0
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/twpayne/go-gpx v1.5.0
+	golang.org/x/image v0.36.0
+)
 
 require (
 	github.com/twpayne/go-geom v1.6.1 // indirect
 	golang.org/x/net v0.39.0 // indirect
-	golang.org/x/text v0.24.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
 )
@@ -1,64 +1,162 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/chocoby/zweg/internal/converter"
 	"github.com/chocoby/zweg/internal/fileio"
-	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/format"
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/privacy"
+	"github.com/chocoby/zweg/internal/resample"
+	"github.com/chocoby/zweg/internal/smooth"
+	"github.com/chocoby/zweg/internal/stats"
+	"github.com/chocoby/zweg/internal/track"
+	"github.com/chocoby/zweg/internal/validate"
 )
 
 // CLI represents the command-line interface.
 type CLI struct {
-	reader    fileio.Reader
-	writer    fileio.Writer
-	converter converter.Converter
+	formats   *format.Registry
+	gpxConfig *converter.Config
+	lang      i18n.Lang
 	stdout    io.Writer
 	stderr    io.Writer
+
+	filenameTemplate string
+	privacyZones     []privacy.Zone
+	json             bool
+	lenient          bool
+	gzip             bool
+	bundle           *fileio.Bundle
+	checksum         bool
 }
 
 // Config holds CLI configuration.
 type Config struct {
-	Reader    fileio.Reader
-	Writer    fileio.Writer
-	Converter converter.Converter
-	Stdout    io.Writer
-	Stderr    io.Writer
+	// Formats is the registry used to resolve input and output formats.
+	// Defaults to format.Default.
+	Formats *format.Registry
+	// GPX configures GPX-based output. Defaults to converter.DefaultConfig().
+	GPX *converter.Config
+	// Lang is the language of messages, stats and default names. When GPX
+	// is nil it also applies to GPX output.
+	Lang   i18n.Lang
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// FilenameTemplate names generated output files; see
+	// DefaultFilenameTemplate and FilenameData. Empty means the default.
+	FilenameTemplate string
+	// PrivacyZones are removed from every track before it is written or
+	// drawn.
+	PrivacyZones []privacy.Zone
+	// JSON makes Convert write a Result as a line of JSON instead of the
+	// success message, also when the conversion fails.
+	JSON bool
+	// Lenient treats numeric input values that do not parse as not
+	// recorded instead of failing, with a warning on Stderr.
+	Lenient bool
+	// Gzip compresses every output and adds .gz to generated names. An
+	// output file ending in .gz is compressed either way.
+	Gzip bool
+	// Bundle, when set, receives every output under its generated name
+	// instead of a file; Options.OutputFile and OutputDir are ignored.
+	// The caller closes it after the last conversion.
+	Bundle *fileio.Bundle
+	// Checksum writes a .sha256 sidecar next to every output file.
+	Checksum bool
+}
+
+// Options describes a single conversion.
+type Options struct {
+	InputFile  string
+	OutputFile string // auto-generated from the track start time when empty
+	OutputDir  string // used only when OutputFile is empty
+	TrackName  string
+
+	// TimezoneOffset is the offset in seconds used for filename generation.
+	TimezoneOffset int
+	// Location, when set, is used instead of TimezoneOffset, so that IANA
+	// zones follow daylight saving time.
+	Location *time.Location
+
+	// InputFormat and OutputFormat name registered formats. When empty the
+	// input is detected from its extension or content, and the output from
+	// the output file extension, falling back to DefaultOutputFormat.
+	InputFormat  string
+	OutputFormat string
+	// DefaultOutputFormat is the output format when neither OutputFormat
+	// nor the output file extension selects one. Defaults to GPX.
+	DefaultOutputFormat string
+
+	// TimeShift corrects timestamps before anything else, so it affects
+	// both the written times and the generated filename.
+	TimeShift TimeShift
+
+	// Smooth, when set, reduces the jitter of the recorded positions and
+	// elevations before anything is interpolated from them.
+	Smooth *smooth.Options
+
+	// FillElevation interpolates the elevation of points that have none
+	// between the nearest points with one, before any resampling.
+	FillElevation bool
+
+	// Resample, when set, puts the track on a regular time grid before
+	// it is written.
+	Resample *resample.Options
 }
 
+// defaultOutputFormat is used when neither a flag, an extension nor
+// Options.DefaultOutputFormat selects one.
+const defaultOutputFormat = "gpx"
+
 // New creates a new CLI instance.
 func New(config *Config) *CLI {
 	if config == nil {
 		config = &Config{}
 	}
 
-	if config.Reader == nil {
-		config.Reader = fileio.NewJSONReader()
-	}
-
-	if config.Writer == nil {
-		config.Writer = fileio.NewGPXWriter("  ")
+	if config.Formats == nil {
+		config.Formats = format.Default
 	}
 
-	if config.Converter == nil {
-		config.Converter = converter.New(nil)
+	if config.GPX == nil {
+		config.GPX = converter.DefaultConfig()
+		config.GPX.Lang = config.Lang
 	}
 
 	return &CLI{
-		reader:    config.Reader,
-		writer:    config.Writer,
-		converter: config.Converter,
+		formats:   config.Formats,
+		gpxConfig: config.GPX,
+		lang:      config.Lang,
 		stdout:    config.Stdout,
 		stderr:    config.Stderr,
+
+		filenameTemplate: config.FilenameTemplate,
+		privacyZones:     config.PrivacyZones,
+		json:             config.JSON,
+		lenient:          config.Lenient,
+		gzip:             config.Gzip,
+		bundle:           config.Bundle,
+		checksum:         config.Checksum,
 	}
 }
 
+// ErrUnsafeOutputPath is returned for an output path, or a name made by the
+// filename template, that would leave the intended directory.
+var ErrUnsafeOutputPath = errors.New("unsafe output path")
+
 // validateOutputPath validates and sanitizes an output path to prevent path traversal attacks.
 // It returns the cleaned absolute path and an error if the path is unsafe.
 func validateOutputPath(path string) (string, error) {
@@ -78,25 +176,31 @@ func validateOutputPath(path string) (string, error) {
 	// Check for path traversal attempts by looking for .. in the cleaned path
 	// This catches relative paths that try to escape the current directory
 	if strings.Contains(filepath.ToSlash(cleaned), "..") {
-		return "", fmt.Errorf("path %q contains invalid relative path components", path)
+		return "", fmt.Errorf("%w: %q contains invalid relative path components", ErrUnsafeOutputPath, path)
 	}
 
 	return absPath, nil
 }
 
-// generateOutputFilename generates output filename based on GPS points timestamp.
-// Returns YYYYMMDD-HHMMSS.gpx format.
+// generateOutputFilename generates the output filename from the filename
+// template, by default YYYYMMDD-HHMMSS<ext> from the track start time.
 // If outputDir is specified, the file is placed in that directory.
 // Otherwise, it is placed in the same directory as the input file.
-// The timezoneOffset parameter is used to adjust the timestamp (in seconds).
-func (c *CLI) generateOutputFilename(inputFile string, outputDir string, points []models.Point, timezoneOffset int) (string, error) {
-	if len(points) == 0 {
-		return inputFile + ".gpx", nil
+// Times are formatted in loc. trackName defaults to the track's own
+// default name.
+func (c *CLI) generateOutputFilename(inputFile string, outputDir string, t *track.Track, loc *time.Location, trackName, ext string) (string, error) {
+	if len(t.Points) == 0 {
+		return inputFile + ext, nil
 	}
 
-	firstPoint := points[0]
-	timestamp := firstPoint.TimestampIn(time.FixedZone("", timezoneOffset))
-	baseName := timestamp.Format("20060102-150405") + ".gpx"
+	if trackName == "" {
+		trackName = t.DefaultNameIn(c.lang)
+	}
+	name, err := expandFilename(c.filenameTemplate, t, inputFile, loc, trackName)
+	if err != nil {
+		return "", err
+	}
+	baseName := name + ext
 
 	dir := outputDir
 	if dir == "" {
@@ -105,12 +209,88 @@ func (c *CLI) generateOutputFilename(inputFile string, outputDir string, points
 		// Validate output directory to prevent path traversal
 		validatedDir, err := validateOutputPath(dir)
 		if err != nil {
-			return "", fmt.Errorf("invalid output directory: %w", err)
+			return "", c.lang.Errorf("invalid output directory: %w", err)
 		}
 		dir = validatedDir
 	}
 
-	return filepath.Join(dir, baseName), nil
+	// expandFilename has already kept the name inside dir, so only the
+	// directory needed checking; names such as "Hike....gpx" are fine.
+	path := filepath.Join(dir, baseName)
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve absolute path for %q: %w", path, err)
+	}
+	return absPath, nil
+}
+
+// zone returns loc, or a fixed zone of offset seconds when loc is nil.
+func zone(offset int, loc *time.Location) *time.Location {
+	if loc != nil {
+		return loc
+	}
+	return time.FixedZone("", offset)
+}
+
+// maxMalformedListed caps the points named in a malformed value warning.
+const maxMalformedListed = 20
+
+// readFile reads and decodes an input track. In lenient mode, values that
+// do not parse are treated as not recorded and listed in a warning.
+func (c *CLI) readFile(path, name string) (*track.Track, *format.Format, error) {
+	return c.read(fileInput(path), name)
+}
+
+// read is readFile for any input.
+func (c *CLI) read(in input, name string) (*track.Track, *format.Format, error) {
+	rc, err := in.open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	if !c.lenient {
+		return c.formats.Read(rc, in.file, name, nil)
+	}
+	var malformed []track.Malformed
+	t, f, err := c.formats.Read(rc, in.file, name, &format.DecodeOptions{
+		Lenient:   true,
+		Malformed: func(m track.Malformed) { malformed = append(malformed, m) },
+	})
+	if err == nil && len(malformed) > 0 && c.stderr != nil {
+		_, _ = fmt.Fprint(c.stderr, c.lang.Sprintf("Warning: %s: treated %d malformed value(s) as not recorded at points %s\n",
+			in.path, len(malformed), listMalformed(malformed)))
+	}
+	return t, f, err
+}
+
+// listMalformed lists the points of malformed with their fields, e.g.
+// "3 (al, sp), 17 (ds)".
+func listMalformed(malformed []track.Malformed) string {
+	var points []string
+	for i := 0; i < len(malformed); {
+		index := malformed[i].Index
+		var fields []string
+		for ; i < len(malformed) && malformed[i].Index == index; i++ {
+			fields = append(fields, malformed[i].Field)
+		}
+		points = append(points, fmt.Sprintf("%d (%s)", index, strings.Join(fields, ", ")))
+	}
+	if len(points) > maxMalformedListed {
+		points = append(points[:maxMalformedListed], fmt.Sprintf("... %d more", len(points)-maxMalformedListed))
+	}
+	return strings.Join(points, ", ")
+}
+
+// protect removes the points inside the configured privacy zones.
+func (c *CLI) protect(t *track.Track) error {
+	if len(c.privacyZones) == 0 {
+		return nil
+	}
+	if privacy.Apply(t, c.privacyZones); len(t.Points) == 0 {
+		return c.lang.Errorf("all points lie inside privacy zones")
+	}
+	return nil
 }
 
 // Run executes the CLI command.
@@ -118,65 +298,443 @@ func (c *CLI) generateOutputFilename(inputFile string, outputDir string, points
 // outputDir is used only when outputFile is not specified.
 // timezoneOffset is the timezone offset in seconds for GPX timestamps and filename generation.
 func (c *CLI) Run(inputFile, outputFile, outputDir, trackName string, timezoneOffset int) error {
-	if inputFile == "" {
-		return fmt.Errorf("input file is required")
+	return c.Convert(&Options{
+		InputFile:      inputFile,
+		OutputFile:     outputFile,
+		OutputDir:      outputDir,
+		TrackName:      trackName,
+		TimezoneOffset: timezoneOffset,
+	})
+}
+
+// Convert reads opts.InputFile, converts it and writes the result. Each
+// log in a zip archive is converted on its own, and named as if it were a
+// file next to the archive.
+func (c *CLI) Convert(opts *Options) error {
+	if opts.InputFile == "" || !fileio.IsZip(opts.InputFile) {
+		return c.convertInput(opts, fileInput(opts.InputFile))
+	}
+
+	a, err := fileio.OpenArchive(opts.InputFile)
+	if err == nil && opts.OutputFile != "" && len(a.Entries) > 1 {
+		err = c.lang.Errorf("%s contains %d logs, which cannot share one output file", opts.InputFile, len(a.Entries))
+		_ = a.Close()
+	}
+	if err != nil {
+		if c.json {
+			return c.writeResult(&Result{Input: opts.InputFile, Warnings: []string{}}, err, 0)
+		}
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	var errs []error
+	for _, e := range a.Entries {
+		in := entryInput(opts.InputFile, e)
+		if err := c.convertInput(opts, in); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", in.path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// convertInput converts one log and reports the result.
+func (c *CLI) convertInput(opts *Options, in input) error {
+	start := time.Now()
+	res, err := c.convert(opts, in)
+	if c.json {
+		return c.writeResult(res, err, time.Since(start))
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.stdout != nil {
+		if _, err := fmt.Fprint(c.stdout, c.lang.Sprintf("Successfully converted %d points to %s: %s\n", res.Points, strings.ToUpper(res.Format), res.Output)); err != nil {
+			return c.lang.Errorf("failed to write output message: %w", err)
+		}
+	}
+	return nil
+}
+
+// conversion is a track ready to be written.
+type conversion struct {
+	in        input
+	t         *track.Track
+	trackName string
+	loc       *time.Location
+	// ext is the extension of generated output names.
+	ext    string
+	encode func(io.Writer) error
+}
+
+// convert does the work of Convert. The result is filled in as far as
+// the conversion got, also when it fails.
+func (c *CLI) convert(opts *Options, in input) (*Result, error) {
+	res := &Result{Input: in.path, Warnings: []string{}}
+	conv, err := c.prepare(opts, in, res)
+	if err != nil {
+		return res, err
+	}
+
+	if c.bundle != nil {
+		if res.Output, err = c.addToBundle(conv); err != nil {
+			return res, err
+		}
+	} else if res.Output, err = c.writeOutput(opts, conv); err != nil {
+		return res, err
+	}
+
+	summary := stats.Compute(conv.t)
+	if c.gpxConfig != nil && c.gpxConfig.Stops != nil {
+		summary.Stops = stats.Stops(conv.t, *c.gpxConfig.Stops)
+	}
+	if c.gpxConfig != nil && c.gpxConfig.Laps != nil {
+		summary.Laps = stats.Laps(conv.t, *c.gpxConfig.Laps)
+	}
+	res.Points = len(conv.t.Points)
+	res.Stats = &summary
+	return res, nil
+}
+
+// prepare reads and processes in as opts say, up to the point of
+// writing, and fills in the fields of res that are known by then.
+func (c *CLI) prepare(opts *Options, in input, res *Result) (*conversion, error) {
+	if in.path == "" {
+		return nil, c.lang.Errorf("input file is required")
+	}
+
+	t, inFormat, err := c.read(in, opts.InputFormat)
+	if err != nil {
+		return nil, c.lang.Errorf("failed to read input file: %w", err)
+	}
+	if c.json {
+		res.Warnings = inputWarnings(in, inFormat)
+	}
+
+	if !opts.TimeShift.IsZero() {
+		opts.TimeShift.Apply(t)
+	}
+
+	if opts.Smooth != nil {
+		t, err = smooth.Track(t, *opts.Smooth)
+		if err != nil {
+			return nil, c.lang.Errorf("failed to smooth track: %w", err)
+		}
+	}
+
+	if opts.FillElevation {
+		t.FillElevation()
+	}
+
+	if opts.Resample != nil {
+		t, err = resample.Track(t, *opts.Resample)
+		if err != nil {
+			return nil, c.lang.Errorf("failed to resample track: %w", err)
+		}
+	}
+
+	before := len(t.Points)
+	err = c.protect(t)
+	res.Dropped = before - len(t.Points)
+	if err != nil {
+		return nil, err
 	}
 
-	points, err := c.reader.Read(inputFile)
+	trackName := opts.TrackName
+	if trackName == "" {
+		trackName = t.DefaultNameIn(c.lang)
+	}
+
+	fallback := opts.DefaultOutputFormat
+	if fallback == "" {
+		fallback = defaultOutputFormat
+	}
+	outFormat, err := c.formats.Output(opts.OutputFormat, opts.OutputFile, fallback)
 	if err != nil {
-		return fmt.Errorf("failed to read input file: %w", err)
+		return nil, err
+	}
+	res.Format = outFormat.Name
+
+	conv := &conversion{
+		in:        in,
+		t:         t,
+		trackName: trackName,
+		loc:       zone(opts.TimezoneOffset, opts.Location),
+		ext:       outFormat.Ext(),
+	}
+	encOpts := &format.EncodeOptions{
+		TrackName: trackName,
+		GPX:       c.gpxConfig,
+		Indent:    "  ",
+		Location:  conv.loc,
 	}
+	conv.encode = func(w io.Writer) error {
+		return outFormat.Encode(context.Background(), w, t, encOpts)
+	}
+	if c.gzip {
+		conv.ext += ".gz"
+	}
+	if c.gzip || (opts.OutputFile != "" && strings.EqualFold(filepath.Ext(opts.OutputFile), ".gz")) {
+		conv.encode = fileio.Gzip(conv.encode)
+	}
+	return conv, nil
+}
 
+// writeOutput writes the output file of a conversion and returns its path.
+func (c *CLI) writeOutput(opts *Options, conv *conversion) (string, error) {
+	outputFile := opts.OutputFile
 	if outputFile == "" {
-		outputFile, err = c.generateOutputFilename(inputFile, outputDir, points, timezoneOffset)
+		var err error
+		outputFile, err = c.generateOutputFilename(conv.in.file, opts.OutputDir, conv.t, conv.loc, conv.trackName, conv.ext)
 		if err != nil {
-			return fmt.Errorf("failed to generate output filename: %w", err)
+			return "", c.lang.Errorf("failed to generate output filename: %w", err)
 		}
 	} else {
 		// Validate explicitly specified output file path
 		validatedOutput, err := validateOutputPath(outputFile)
 		if err != nil {
-			return fmt.Errorf("invalid output file path: %w", err)
+			return "", c.lang.Errorf("invalid output file path: %w", err)
 		}
 		outputFile = validatedOutput
 	}
 
-	if trackName == "" {
-		trackName = models.FirstTitle(points)
+	// Ensure output directory exists
+	outputFileDir := filepath.Dir(outputFile)
+	if err := fileio.MkdirAll(outputFileDir); err != nil {
+		return "", c.lang.Errorf("failed to create output directory: %w", err)
 	}
-	if trackName == "" {
-		if m, ok := models.FirstMeans(points); ok {
-			trackName = m.String()
+
+	if err := fileio.WriteFile(outputFile, conv.encode); err != nil {
+		return "", c.lang.Errorf("failed to write output file: %w", err)
+	}
+	if c.checksum {
+		if err := fileio.WriteChecksum(outputFile); err != nil {
+			return "", c.lang.Errorf("failed to write checksum: %w", err)
 		}
 	}
-	if trackName == "" {
-		trackName = "Track"
+	return outputFile, nil
+}
+
+// addToBundle adds the output of a conversion to the bundle under its
+// generated name and returns where it went.
+func (c *CLI) addToBundle(conv *conversion) (string, error) {
+	name, err := expandFilename(c.filenameTemplate, conv.t, conv.in.file, conv.loc, conv.trackName)
+	if err != nil {
+		return "", c.lang.Errorf("failed to generate output filename: %w", err)
 	}
+	name = filepath.ToSlash(name) + conv.ext
 
-	// Ensure output directory exists
-	outputFileDir := filepath.Dir(outputFile)
-	if err := os.MkdirAll(outputFileDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+	f := fileio.BundleFile{Input: conv.in.path, Output: name, Points: len(conv.t.Points)}
+	if err := c.bundle.Add(f, conv.t.Start().UTC(), conv.encode); err != nil {
+		return "", c.lang.Errorf("failed to write output file: %w", err)
 	}
+	return filepath.Join(c.bundle.Name(), filepath.FromSlash(name)), nil
+}
+
+// ErrMismatch is returned by Verify for an output that does not match
+// its checksum or a fresh conversion of its input.
+var ErrMismatch = errors.New("verification failed")
 
-	gpxData, err := c.converter.Convert(points, trackName)
+// Verify converts opts.InputFile again as opts say and checks that the
+// stored opts.OutputFile still has the same content, and that it matches
+// its checksum sidecar if there is one.
+func (c *CLI) Verify(opts *Options) error {
+	if opts.OutputFile == "" {
+		return c.lang.Errorf("output file is required")
+	}
+	stored, err := fileio.ReadFile(opts.OutputFile)
 	if err != nil {
-		return fmt.Errorf("failed to convert data: %w", err)
+		return c.lang.Errorf("failed to read output file: %w", err)
+	}
+
+	sum, err := fileio.ReadChecksum(opts.OutputFile)
+	switch {
+	case errors.Is(err, fileio.ErrInputNotFound):
+		// No sidecar to check.
+	case err != nil:
+		return c.lang.Errorf("failed to read checksum: %w", err)
+	case sum != fileio.SHA256(stored):
+		return fmt.Errorf("%w: %s", ErrMismatch, c.lang.Sprintf("%s does not match its checksum", opts.OutputFile))
 	}
 
-	if err := c.writer.Write(outputFile, gpxData); err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
+	conv, err := c.prepare(opts, fileInput(opts.InputFile), &Result{})
+	if err != nil {
+		return err
+	}
+	var fresh bytes.Buffer
+	if err := conv.encode(&fresh); err != nil {
+		return c.lang.Errorf("failed to convert: %w", err)
+	}
+	if !bytes.Equal(fresh.Bytes(), stored) {
+		return fmt.Errorf("%w: %s", ErrMismatch, c.lang.Sprintf("%s differs from a conversion of %s at byte %d",
+			opts.OutputFile, opts.InputFile, firstDifference(fresh.Bytes(), stored)))
 	}
 
 	if c.stdout != nil {
-		if _, err := fmt.Fprintf(c.stdout, "Successfully converted %d points to GPX: %s\n", len(points), outputFile); err != nil {
-			return fmt.Errorf("failed to write output message: %w", err)
+		if _, err := fmt.Fprint(c.stdout, c.lang.Sprintf("OK: %s matches %s\n", opts.OutputFile, opts.InputFile)); err != nil {
+			return c.lang.Errorf("failed to write output message: %w", err)
 		}
 	}
+	return nil
+}
+
+// firstDifference returns the offset of the first byte at which a and b
+// differ.
+func firstDifference(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
 
+// StatsOptions controls Stats.
+type StatsOptions struct {
+	InputFormat string
+	Stops       stats.StopOptions
+	// Laps, when set, lists the laps of the track too.
+	Laps *stats.LapOptions
+	// JSON writes the statistics as a JSON object instead of text.
+	JSON bool
+}
+
+// Stats reads inputFile and writes its statistics, including its stops, to
+// stdout.
+func (c *CLI) Stats(inputFile string, opts StatsOptions) error {
+	t, _, err := c.readFile(inputFile, opts.InputFormat)
+	if err != nil {
+		return c.lang.Errorf("failed to read input file: %w", err)
+	}
+	if c.stdout == nil {
+		return nil
+	}
+
+	summary := stats.Compute(t)
+	summary.Stops = stats.Stops(t, opts.Stops)
+	if opts.Laps != nil {
+		summary.Laps = stats.Laps(t, *opts.Laps)
+	}
+	if opts.JSON {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(summary)
+	} else {
+		err = stats.WriteText(c.stdout, summary, c.lang)
+	}
+	if err != nil {
+		return c.lang.Errorf("failed to write stats: %w", err)
+	}
 	return nil
 }
 
+// validation is the JSON report of one validated log. Input names the
+// log inside a zip archive and is empty for a plain file.
+type validation struct {
+	Input string `json:"input,omitempty"`
+	validate.Report
+}
+
+// Validate checks a ZweiteGPS log and writes the issues found to stdout.
+// Each log in a zip archive is checked and reported on its own. It returns
+// an error when a log has error-level issues.
+func (c *CLI) Validate(inputFile string, asJSON bool) error {
+	if !fileio.IsZip(inputFile) {
+		return c.validate(fileInput(inputFile), false, asJSON)
+	}
+
+	a, err := fileio.OpenArchive(inputFile)
+	if err != nil {
+		return c.lang.Errorf("failed to read input file: %w", err)
+	}
+	defer func() { _ = a.Close() }()
+
+	var errs []error
+	for _, e := range a.Entries {
+		if err := c.validate(entryInput(inputFile, e), true, asJSON); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate checks one log. inArchive names the log in the report, which
+// a plain file does not need.
+func (c *CLI) validate(in input, inArchive, asJSON bool) error {
+	points, err := readPoints(in)
+	if err != nil {
+		err = c.lang.Errorf("failed to read input file: %w", err)
+		if inArchive {
+			err = fmt.Errorf("%s: %w", in.path, err)
+		}
+		return err
+	}
+
+	v := validation{Report: validate.Points(points)}
+	if inArchive {
+		v.Input = in.path
+	}
+	if c.stdout != nil {
+		if asJSON {
+			enc := json.NewEncoder(c.stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(v)
+		} else {
+			if inArchive {
+				_, err = fmt.Fprintf(c.stdout, "%s:\n", in.path)
+			}
+			if err == nil {
+				err = writeReport(c.stdout, v.Report)
+			}
+		}
+		if err != nil {
+			return c.lang.Errorf("failed to write validation report: %w", err)
+		}
+	}
+
+	if !v.Valid {
+		return c.lang.Errorf("%s: %d error(s), %d warning(s)", in.path, v.Errors, v.Warnings)
+	}
+	return nil
+}
+
+func writeReport(w io.Writer, r validate.Report) error {
+	for _, issue := range r.Issues {
+		if _, err := fmt.Fprintln(w, issue); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d points, %d error(s), %d warning(s)\n", r.Points, r.Errors, r.Warnings)
+	return err
+}
+
+// ListFormats writes a table of the registered formats to stdout.
+func (c *CLI) ListFormats() error {
+	if c.stdout == nil {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAME\tREAD\tWRITE\tEXTENSIONS\tDESCRIPTION")
+	for _, f := range c.formats.Formats() {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			f.Name, yesNo(f.CanRead()), yesNo(f.CanWrite()), strings.Join(f.Extensions, ","), f.Description)
+	}
+	if err := tw.Flush(); err != nil {
+		return c.lang.Errorf("failed to write format list: %w", err)
+	}
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "-"
+}
+
 // ParseTimezoneOffset parses a timezone offset string and returns the offset in seconds.
 // Supported formats: ±HH:MM or ±HHMM (e.g., +09:00, -05:00, +0900, -0500)
 // Valid range: -12:00 to +14:00
@@ -257,3 +815,20 @@ func ParseTimezoneOffset(offset string) (int, error) {
 
 	return totalSeconds, nil
 }
+
+// ParseLocation parses a time zone given either as an offset accepted by
+// ParseTimezoneOffset or as an IANA name such as "Asia/Tokyo".
+func ParseLocation(s string) (*time.Location, error) {
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		secs, err := ParseTimezoneOffset(s)
+		if err != nil {
+			return nil, err
+		}
+		return time.FixedZone(s, secs), nil
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil || s == "" {
+		return nil, fmt.Errorf("unknown time zone %q (expected ±HH:MM or an IANA name like Asia/Tokyo)", s)
+	}
+	return loc, nil
+}
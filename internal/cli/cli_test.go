@@ -1,11 +1,28 @@
 package cli
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/privacy"
+	"github.com/chocoby/zweg/internal/resample"
+	"github.com/chocoby/zweg/internal/smooth"
+	"github.com/chocoby/zweg/internal/stats"
+	"github.com/chocoby/zweg/internal/track"
 )
 
 // singlePointJSON returns a one-point ZweiteGPS payload with the given Unix timestamp.
@@ -255,6 +272,45 @@ func TestCLI_Run_NonExistentFile(t *testing.T) {
 	}
 }
 
+func TestCLI_Convert_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		return path
+	}
+	valid := write("valid.json", singlePointJSON(1729411200))
+	notDir := write("file", "")
+
+	tests := []struct {
+		name     string
+		config   *Config
+		opts     Options
+		wantKind error
+	}{
+		{"missing input", nil, Options{InputFile: filepath.Join(tmpDir, "missing.json")}, fileio.ErrInputNotFound},
+		{"invalid JSON", nil, Options{InputFile: write("invalid.json", "{")}, fileio.ErrInvalidJSON},
+		{"empty log", nil, Options{InputFile: write("empty.json", "[]")}, fileio.ErrEmptyLog},
+		{"invalid altitude", nil, Options{InputFile: write("altitude.json", `[{"tm":1,"lo":0,"la":0,"al":"x"}]`)}, converter.ErrBadAltitude},
+		{"invalid speed", nil, Options{InputFile: write("speed.json", `[{"tm":1,"lo":0,"la":0,"sp":"x"}]`)}, converter.ErrBadSpeed},
+		{"output directory traversal", nil, Options{InputFile: valid, OutputDir: "../../etc"}, ErrUnsafeOutputPath},
+		{"filename template traversal", &Config{FilenameTemplate: "../{{.Input}}"}, Options{InputFile: valid, OutputDir: tmpDir}, ErrUnsafeOutputPath},
+		{"unwritable output directory", nil, Options{InputFile: valid, OutputDir: filepath.Join(notDir, "out")}, fileio.ErrWrite},
+		{"unwritable output file", nil, Options{InputFile: valid, OutputFile: filepath.Join(notDir, "out.gpx")}, fileio.ErrWrite},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(tt.config).Convert(&tt.opts)
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("Convert() error = %v, want %v", err, tt.wantKind)
+			}
+		})
+	}
+}
+
 func TestCLI_Run_WithOutputDir(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -537,6 +593,35 @@ func TestCLI_Run_WithTimezoneOffset(t *testing.T) {
 	}
 }
 
+func TestCLI_Convert_Location(t *testing.T) {
+	tests := []struct {
+		zone     string
+		tm       int64
+		wantFile string
+	}{
+		{"America/New_York", 1609459200, "20201231-190000.gpx"}, // EST, UTC-5
+		{"America/New_York", 1625097600, "20210630-200000.gpx"}, // EDT, UTC-4
+	}
+	for _, tt := range tests {
+		loc, err := time.LoadLocation(tt.zone)
+		if err != nil {
+			t.Skipf("time zone data unavailable: %v", err)
+		}
+
+		tmpDir := t.TempDir()
+		input := filepath.Join(tmpDir, "in.json")
+		if err := os.WriteFile(input, []byte(singlePointJSON(tt.tm)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := New(nil).Convert(&Options{InputFile: input, Location: loc, TimezoneOffset: 9 * 3600}); err != nil {
+			t.Fatalf("Convert: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, tt.wantFile)); err != nil {
+			t.Errorf("%s at %d: want %s: %v", tt.zone, tt.tm, tt.wantFile, err)
+		}
+	}
+}
+
 func TestValidateOutputPath(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -739,3 +824,589 @@ func TestParseTimezoneOffset_ErrorMessages(t *testing.T) {
 		})
 	}
 }
+
+func TestCLI_Convert_OutputFormat(t *testing.T) {
+	tests := []struct {
+		name          string
+		outputFile    string
+		outputFormat  string
+		defaultFormat string
+		wantFile      string
+		wantContent   string
+	}{
+		{
+			name:         "explicit format with generated filename",
+			outputFormat: "geojson",
+			wantFile:     "20210101-000000.geojson",
+			wantContent:  `"FeatureCollection"`,
+		},
+		{
+			name:        "format from output extension",
+			outputFile:  "out.geojson",
+			wantFile:    "out.geojson",
+			wantContent: `"LineString"`,
+		},
+		{
+			name:        "unknown extension defaults to GPX",
+			outputFile:  "out.xml",
+			wantFile:    "out.xml",
+			wantContent: "<gpx",
+		},
+		{
+			name:          "default format with generated filename",
+			defaultFormat: "geojson",
+			wantFile:      "20210101-000000.geojson",
+			wantContent:   `"FeatureCollection"`,
+		},
+		{
+			name:          "output extension over default format",
+			outputFile:    "out.geojson",
+			defaultFormat: "gpx",
+			wantFile:      "out.geojson",
+			wantContent:   `"LineString"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			inputPath := filepath.Join(tmpDir, "test.json")
+			if err := os.WriteFile(inputPath, []byte(singlePointJSON(1609459200)), 0644); err != nil {
+				t.Fatalf("write input: %v", err)
+			}
+
+			outputFile := ""
+			if tt.outputFile != "" {
+				outputFile = filepath.Join(tmpDir, tt.outputFile)
+			}
+
+			err := New(nil).Convert(&Options{
+				InputFile:           inputPath,
+				OutputFile:          outputFile,
+				OutputFormat:        tt.outputFormat,
+				DefaultOutputFormat: tt.defaultFormat,
+			})
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(tmpDir, tt.wantFile))
+			if err != nil {
+				t.Fatalf("read output: %v", err)
+			}
+			if !strings.Contains(string(data), tt.wantContent) {
+				t.Errorf("output missing %q\n--- output ---\n%s", tt.wantContent, data)
+			}
+		})
+	}
+}
+
+func TestCLI_Convert_DetectsInputByContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "export.txt")
+	if err := os.WriteFile(inputPath, []byte(singlePointJSON(1609459200)), 0644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	if err := New(nil).Convert(&Options{InputFile: inputPath}); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "20210101-000000.gpx")); err != nil {
+		t.Errorf("expected GPX output: %v", err)
+	}
+}
+
+func TestCLI_Convert_Resample(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "out.gpx")
+	var out strings.Builder
+	err := New(&Config{Stdout: &out}).Convert(&Options{
+		InputFile:  filepath.Join("testdata", "input", "multi_point.json"),
+		OutputFile: outputFile,
+		Resample:   &resample.Options{Interval: 30 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if got := strings.Count(string(data), "<trkpt"); got != 5 {
+		t.Errorf("trkpt count = %d, want 5 (3 points over 2 minutes at 30s)", got)
+	}
+	if !strings.Contains(string(data), "<time>2021-01-01T00:00:30Z</time>") {
+		t.Error("output missing interpolated point at 00:00:30")
+	}
+	if !strings.Contains(out.String(), "converted 5 points") {
+		t.Errorf("message = %q, want resampled point count", out.String())
+	}
+}
+
+func TestCLI_Convert_Smooth(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "out.gpx")
+	err := New(&Config{Stdout: io.Discard}).Convert(&Options{
+		InputFile:  filepath.Join("testdata", "input", "multi_point.json"),
+		OutputFile: outputFile,
+		Smooth:     &smooth.Options{Method: smooth.Kalman},
+	})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if got := strings.Count(string(data), "<trkpt"); got != 3 {
+		t.Errorf("trkpt count = %d, want 3", got)
+	}
+	if !strings.Contains(string(data), "<time>2021-01-01T00:01:00Z</time>") {
+		t.Error("output missing the recorded time 00:01:00")
+	}
+	if strings.Contains(string(data), `lat="35.6815" lon="139.746"`) {
+		t.Error("middle point was not smoothed")
+	}
+}
+
+// writeZip writes a zip archive holding logs, keyed by entry name.
+func writeZip(t *testing.T, name string, logs map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for entry, log := range logs {
+		w, err := zw.Create(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(log)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCLI_Convert_Zip(t *testing.T) {
+	tmpDir := t.TempDir()
+	archive := filepath.Join(tmpDir, "export.zip")
+	writeZip(t, archive, map[string]string{
+		"2021/a.json": `[{"tm":1609459200,"lo":139.7,"la":35.6,"al":"10","sp":"1","ds":"0"}]`,
+		"2021/b.json": `[{"tm":1609462800,"lo":139.7,"la":35.6,"al":"10","sp":"1","ds":"0"}]`,
+	})
+
+	var out strings.Builder
+	c := New(&Config{Stdout: &out, FilenameTemplate: "{{.Input}}-{{.Start.Format \"1504\"}}"})
+	if err := c.Convert(&Options{InputFile: archive}); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	for _, name := range []string{"a-0000.gpx", "b-0100.gpx"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("expected %s next to the archive: %v", name, err)
+		}
+	}
+	if got := strings.Count(out.String(), "Successfully converted"); got != 2 {
+		t.Errorf("messages = %q, want one per log", out.String())
+	}
+
+	err := c.Convert(&Options{InputFile: archive, OutputFile: filepath.Join(tmpDir, "one.gpx")})
+	if err == nil || !strings.Contains(err.Error(), "contains 2 logs") {
+		t.Errorf("Convert(output file) error = %v, want an error for several logs", err)
+	}
+}
+
+func TestCLI_Convert_Gzip(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := filepath.Join("testdata", "input", "multi_point.json")
+	if err := New(&Config{Gzip: true}).Convert(&Options{InputFile: input, OutputDir: tmpDir}); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, "20210101-000000.gpx.gz"))
+	if err != nil {
+		t.Fatalf("expected compressed output: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("output is not gzip: %v", err)
+	}
+	if gpx, err := io.ReadAll(zr); err != nil || !strings.Contains(string(gpx), "<trkpt") {
+		t.Errorf("decompressed output = %.40q, %v, want GPX", gpx, err)
+	}
+
+	// An explicit .gz output file is compressed without the option.
+	outputFile := filepath.Join(tmpDir, "explicit.gpx.gz")
+	if err := New(nil).Convert(&Options{InputFile: input, OutputFile: outputFile}); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if data, err := os.ReadFile(outputFile); err != nil || !bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		t.Errorf("explicit .gz output is not gzip (err %v)", err)
+	}
+}
+
+func TestCLI_Convert_Bundle(t *testing.T) {
+	tmpDir := t.TempDir()
+	bundle := filepath.Join(tmpDir, "week.zip")
+	b, err := fileio.CreateBundle(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	c := New(&Config{Stdout: &out, Bundle: b, FilenameTemplate: "{{.Input}}"})
+	for _, input := range []string{"multi_point.json", "single_point.json"} {
+		if err := c.Convert(&Options{InputFile: filepath.Join("testdata", "input", input), OutputDir: tmpDir}); err != nil {
+			t.Fatalf("Convert(%s): %v", input, err)
+		}
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("output directory = %v, want only the bundle", entries)
+	}
+	zr, err := zip.OpenReader(bundle)
+	if err != nil {
+		t.Fatalf("open bundle: %v", err)
+	}
+	defer func() { _ = zr.Close() }()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if len(names) != 3 || names[2] != fileio.ManifestName {
+		t.Errorf("bundle files = %v, want two outputs and the manifest", names)
+	}
+	if want := filepath.Join(bundle, names[0]); !strings.Contains(out.String(), want) {
+		t.Errorf("message = %q, want output %s", out.String(), want)
+	}
+}
+
+func TestCLI_Verify(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := filepath.Join("testdata", "input", "multi_point.json")
+	outputFile := filepath.Join(tmpDir, "out.gpx")
+	if err := New(&Config{Checksum: true}).Convert(&Options{InputFile: input, OutputFile: outputFile, TrackName: "Run"}); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if _, err := os.Stat(outputFile + fileio.ChecksumExt); err != nil {
+		t.Fatalf("expected checksum sidecar: %v", err)
+	}
+
+	var out strings.Builder
+	if err := New(&Config{Stdout: &out}).Verify(&Options{InputFile: input, OutputFile: outputFile, TrackName: "Run"}); err != nil {
+		t.Fatalf("Verify(same options): %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "OK: ") {
+		t.Errorf("message = %q, want OK", out.String())
+	}
+
+	err := New(nil).Verify(&Options{InputFile: input, OutputFile: outputFile, TrackName: "Walk"})
+	if !errors.Is(err, ErrMismatch) || !strings.Contains(err.Error(), "differs from a conversion") {
+		t.Errorf("Verify(other track name) error = %v, want a difference", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-2] ^= 1
+	if err := os.WriteFile(outputFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = New(nil).Verify(&Options{InputFile: input, OutputFile: outputFile, TrackName: "Run"})
+	if !errors.Is(err, ErrMismatch) || !strings.Contains(err.Error(), "does not match its checksum") {
+		t.Errorf("Verify(corrupted) error = %v, want a checksum mismatch", err)
+	}
+}
+
+func TestCLI_Convert_Lenient(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "corrupt.json")
+	log := `[{"tm":1609459200,"lo":139.7,"la":35.6,"al":"10","sp":"1","ds":"0"},` +
+		`{"tm":1609459260,"lo":139.71,"la":35.61,"al":"N/A","sp":"?","ds":"5"},` +
+		`{"tm":1609459320,"lo":139.72,"la":35.62,"al":"12","sp":"2","ds":"x"}]`
+	if err := os.WriteFile(inputFile, []byte(log), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	outputFile := filepath.Join(tmpDir, "out.gpx")
+	if err := New(nil).Convert(&Options{InputFile: inputFile, OutputFile: outputFile}); !errors.Is(err, converter.ErrBadAltitude) {
+		t.Fatalf("strict Convert() error = %v, want ErrBadAltitude", err)
+	}
+
+	var stderr strings.Builder
+	if err := New(&Config{Stderr: &stderr, Lenient: true}).Convert(&Options{InputFile: inputFile, OutputFile: outputFile}); err != nil {
+		t.Fatalf("lenient Convert: %v", err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if got := strings.Count(string(data), "<trkpt"); got != 3 {
+		t.Errorf("trkpt count = %d, want 3", got)
+	}
+	if got := strings.Count(string(data), "<ele>"); got != 4 {
+		t.Errorf("<ele> count = %d, want 4 (two trkpts and two waypoints)", got)
+	}
+	want := "treated 3 malformed value(s) as not recorded at points 1 (al, sp), 2 (ds)"
+	if !strings.Contains(stderr.String(), want) {
+		t.Errorf("warning = %q, want %q", stderr.String(), want)
+	}
+}
+
+func TestCLI_Convert_FillElevation(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "gap.json")
+	log := `[{"tm":1609459200,"lo":139.7,"la":35.6,"al":"10","sp":"","ds":""},` +
+		`{"tm":1609459260,"lo":139.7,"la":35.601,"al":"","sp":"","ds":""},` +
+		`{"tm":1609459320,"lo":139.7,"la":35.602,"al":"20","sp":"","ds":""}]`
+	if err := os.WriteFile(inputFile, []byte(log), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	outputFile := filepath.Join(tmpDir, "out.gpx")
+	for _, tt := range []struct {
+		fill bool
+		ele  int
+	}{
+		{false, 4}, // two trkpts and two waypoints
+		{true, 5},
+	} {
+		if err := New(nil).Convert(&Options{InputFile: inputFile, OutputFile: outputFile, FillElevation: tt.fill}); err != nil {
+			t.Fatalf("Convert(FillElevation=%v): %v", tt.fill, err)
+		}
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("read output: %v", err)
+		}
+		if got := strings.Count(string(data), "<ele>"); got != tt.ele {
+			t.Errorf("FillElevation=%v: <ele> count = %d, want %d", tt.fill, got, tt.ele)
+		}
+		if tt.fill && !strings.Contains(string(data), "<ele>15</ele>") {
+			t.Error("output missing the interpolated elevation 15")
+		}
+		if strings.Contains(string(data), "<ele>0</ele>") {
+			t.Errorf("FillElevation=%v: output has a zero elevation", tt.fill)
+		}
+	}
+}
+
+func TestListMalformed(t *testing.T) {
+	var malformed []track.Malformed
+	for i := range maxMalformedListed + 2 {
+		malformed = append(malformed, track.Malformed{Index: i, Field: "al"})
+	}
+	got := listMalformed(malformed)
+	if !strings.HasPrefix(got, "0 (al), 1 (al), ") || !strings.HasSuffix(got, ", 19 (al), ... 2 more") {
+		t.Errorf("listMalformed() = %q", got)
+	}
+}
+
+func TestCLI_Convert_JSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := filepath.Join(tmpDir, "in.json")
+	log := `[
+		{"tm":1609459200,"lo":139.7454,"la":35.6812,"sp":"x","al":"0","ds":"0"},
+		{"tm":1609459260,"lo":139.7470,"la":35.6820,"sp":"1","al":"0","ds":"0"}
+	]`
+	if err := os.WriteFile(input, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	c := New(&Config{
+		Stdout:       &out,
+		JSON:         true,
+		Lenient:      true,
+		PrivacyZones: []privacy.Zone{{Lat: 35.6812, Lon: 139.7454, Radius: 10}},
+	})
+	if err := c.Convert(&Options{InputFile: input, OutputDir: tmpDir}); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	missing := filepath.Join(tmpDir, "missing.json")
+	if err := c.Convert(&Options{InputFile: missing}); err == nil {
+		t.Fatal("Convert(missing) error = nil")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want one JSON line per input, got\n%s", out.String())
+	}
+	var ok, failed struct {
+		Result
+		Stats    map[string]any `json:"stats"`
+		Duration float64        `json:"duration_s"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &ok); err != nil {
+		t.Fatalf("decode %s: %v", lines[0], err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &failed); err != nil {
+		t.Fatalf("decode %s: %v", lines[1], err)
+	}
+
+	if ok.Input != input || ok.Output != filepath.Join(tmpDir, "20210101-000100.gpx") || ok.Format != "gpx" {
+		t.Errorf("result = %+v", ok.Result)
+	}
+	if ok.Points != 1 || ok.Dropped != 1 || ok.Error != "" {
+		t.Errorf("points = %d, dropped = %d, error = %q; want 1, 1 and none", ok.Points, ok.Dropped, ok.Error)
+	}
+	if len(ok.Warnings) != 1 || !strings.Contains(ok.Warnings[0], `speed "x" is not a number`) {
+		t.Errorf("warnings = %q", ok.Warnings)
+	}
+	if ok.Stats["points"] != 1.0 || ok.Duration < 0 {
+		t.Errorf("stats = %v, duration = %v", ok.Stats, ok.Duration)
+	}
+
+	if failed.Input != missing || !strings.Contains(failed.Error, "failed to read input file") || failed.Output != "" {
+		t.Errorf("failed result = %+v", failed.Result)
+	}
+}
+
+func TestCLI_ListFormats(t *testing.T) {
+	var out strings.Builder
+	if err := New(&Config{Stdout: &out}).ListFormats(); err != nil {
+		t.Fatalf("ListFormats: %v", err)
+	}
+
+	for _, want := range []string{"NAME", "zweite", "gpx", "geojson", ".json"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("format list missing %q\n%s", want, out.String())
+		}
+	}
+}
+
+func TestCLI_Stats(t *testing.T) {
+	inputPath := filepath.Join("testdata", "input", "multi_point.json")
+
+	var text strings.Builder
+	if err := New(&Config{Stdout: &text}).Stats(inputPath, StatsOptions{}); err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if !strings.Contains(text.String(), "Points:          3") {
+		t.Errorf("text stats missing point count\n%s", text.String())
+	}
+
+	var js strings.Builder
+	if err := New(&Config{Stdout: &js}).Stats(inputPath, StatsOptions{JSON: true}); err != nil {
+		t.Fatalf("Stats JSON: %v", err)
+	}
+	if !strings.Contains(js.String(), `"duration_s": 120`) {
+		t.Errorf("JSON stats missing duration\n%s", js.String())
+	}
+	if strings.Contains(js.String(), `"stops"`) {
+		t.Errorf("JSON stats list stops of a track that kept moving\n%s", js.String())
+	}
+
+	// The first two points are 64 m and a minute apart.
+	text.Reset()
+	stops := stats.StopOptions{Radius: 100, MinDuration: time.Minute}
+	if err := New(&Config{Stdout: &text}).Stats(inputPath, StatsOptions{Stops: stops}); err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if !strings.Contains(text.String(), "Stop:            2021-01-01T00:00:00Z – 2021-01-01T00:01:00Z (1m0s)") {
+		t.Errorf("text stats missing stop\n%s", text.String())
+	}
+
+	js.Reset()
+	laps := &stats.LapOptions{Distance: 100}
+	if err := New(&Config{Stdout: &js}).Stats(inputPath, StatsOptions{Laps: laps, JSON: true}); err != nil {
+		t.Fatalf("Stats JSON: %v", err)
+	}
+	var summary struct {
+		Laps []struct {
+			Distance float64 `json:"distance_m"`
+		} `json:"laps"`
+	}
+	if err := json.Unmarshal([]byte(js.String()), &summary); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(summary.Laps) != 2 || math.Abs(summary.Laps[0].Distance-100) > 1e-6 {
+		t.Errorf("laps = %+v, want 100 m and the remaining 70 m", summary.Laps)
+	}
+}
+
+func TestCLI_Lang(t *testing.T) {
+	inputPath := filepath.Join("testdata", "input", "multi_point.json")
+
+	var text strings.Builder
+	c := New(&Config{Lang: i18n.Japanese, Stdout: &text})
+	if err := c.Stats(inputPath, StatsOptions{}); err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if !strings.Contains(text.String(), "ポイント数:      3") {
+		t.Errorf("Japanese stats missing point count\n%s", text.String())
+	}
+
+	err := c.Convert(&Options{InputFile: filepath.Join(t.TempDir(), "missing.json")})
+	if err == nil || !strings.HasPrefix(err.Error(), "入力ファイルを読み込めませんでした: ") {
+		t.Errorf("Convert() error = %v, want Japanese message", err)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Convert() error = %v, want it to wrap os.ErrNotExist", err)
+	}
+}
+
+func TestCLI_Validate(t *testing.T) {
+	tmpDir := t.TempDir()
+	good := filepath.Join(tmpDir, "good.json")
+	bad := filepath.Join(tmpDir, "bad.json")
+	if err := os.WriteFile(good, []byte(singlePointJSON(1609459200)), 0644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	if err := os.WriteFile(bad, []byte(`[{"tm":1609459200,"lo":139,"la":35,"al":"high"}]`), 0644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	var out strings.Builder
+	if err := New(&Config{Stdout: &out}).Validate(good, false); err != nil {
+		t.Errorf("Validate(good) unexpected error = %v", err)
+	}
+
+	out.Reset()
+	err := New(&Config{Stdout: &out}).Validate(bad, false)
+	if err == nil {
+		t.Fatal("Validate(bad) error = nil, want error")
+	}
+	if !strings.Contains(out.String(), `point 0: error: al: altitude "high" is not a number`) {
+		t.Errorf("report missing altitude issue\n%s", out.String())
+	}
+}
+
+func TestCLI_Validate_Zip(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "export.zip")
+	writeZip(t, archive, map[string]string{
+		"a.json": singlePointJSON(1609459200),
+		"b.json": `[{"tm":1609459200,"lo":139,"la":35,"al":"high"}]`,
+		"c.json": `not json`,
+	})
+
+	var out strings.Builder
+	err := New(&Config{Stdout: &out}).Validate(archive, false)
+	if err == nil {
+		t.Fatal("Validate(zip) error = nil, want error")
+	}
+	for _, want := range []string{"b.json: 1 error(s)", "c.json: failed to read input file"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate(zip) error = %v, want it to contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "a.json") {
+		t.Errorf("Validate(zip) error = %v, want the valid log left out", err)
+	}
+	for _, want := range []string{filepath.Join(archive, "a.json") + ":\n1 points", `point 0: error: al: altitude "high" is not a number`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report missing %q\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	_ = New(&Config{Stdout: &out}).Validate(archive, true)
+	if got := strings.Count(out.String(), `"input":`); got != 2 {
+		t.Errorf("JSON reports = %d, want one per readable log\n%s", got, out.String())
+	}
+}
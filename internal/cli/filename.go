@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// DefaultFilenameTemplate names generated files after the track start
+// time, e.g. 20240501-093000.
+const DefaultFilenameTemplate = `{{.Start.Format "20060102-150405"}}`
+
+// FilenameData is the data a filename template is executed with. String
+// fields have path separators replaced, so only the template itself can
+// create subdirectories.
+type FilenameData struct {
+	// Start and End are the first and last point times in the filename
+	// time zone.
+	Start time.Time
+	End   time.Time
+	// Name is the resolved track name.
+	Name string
+	// Means is the English name of the first means of transportation.
+	Means string
+	// Input is the input file name without directory and extension.
+	Input string
+}
+
+// ParseFilenameTemplate parses a text/template for generated filenames.
+// The extension is appended to its output.
+func ParseFilenameTemplate(s string) (*template.Template, error) {
+	tmpl, err := template.New("filename").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filename template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// expandFilename executes the template s for t.
+func expandFilename(s string, t *track.Track, inputFile string, loc *time.Location, name string) (string, error) {
+	if s == "" {
+		s = DefaultFilenameTemplate
+	}
+	tmpl, err := ParseFilenameTemplate(s)
+	if err != nil {
+		return "", err
+	}
+
+	data := FilenameData{
+		Start: t.Start().In(loc),
+		End:   t.Points[len(t.Points)-1].Time.In(loc),
+		Name:  sanitizeFilename(name),
+		Input: sanitizeFilename(inputName(inputFile)),
+	}
+	if m, ok := t.FirstMeans(); ok {
+		data.Means = m.String()
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid filename template: %w", err)
+	}
+	out := filepath.FromSlash(strings.TrimSpace(b.String()))
+	if out == "" {
+		return "", fmt.Errorf("filename template %q produced an empty name", s)
+	}
+	if !filepath.IsLocal(out) {
+		return "", fmt.Errorf("%w: filename template %q produced %q, which leaves the output directory", ErrUnsafeOutputPath, s, out)
+	}
+	return out, nil
+}
+
+// inputName returns the file name of inputFile without directory and
+// extension, also removing a .gz before the extension.
+func inputName(inputFile string) string {
+	base := fileio.TrimCompressionExt(filepath.Base(inputFile))
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// sanitizeFilename replaces characters that are not allowed, or not
+// wanted, in a single path element.
+func sanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chocoby/zweg/internal/privacy"
+)
+
+func TestCLI_Convert_FilenameTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantFile string
+		wantErr  bool
+	}{
+		{"default", "", "20210101-000000.gpx", false},
+		{"name and input", `{{.Start.Format "2006-01-02"}}-{{.Name}}-{{.Input}}`, "2021-01-01-Tokyo Run-multi_point.gpx", false},
+		{"dots in name", `{{.Name}}...`, "Tokyo Run....gpx", false},
+		{"subdirectory", `{{.Start.Format "2006"}}/{{.Start.Format "0102"}}`, filepath.Join("2021", "0101.gpx"), false},
+		{"unknown field", `{{.Title}}`, "", true},
+		{"escapes directory", `../{{.Input}}`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			c := New(&Config{FilenameTemplate: tt.template})
+			err := c.Convert(&Options{
+				InputFile: filepath.Join("testdata", "input", "multi_point.json"),
+				OutputDir: tmpDir,
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Convert() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(tmpDir, tt.wantFile)); err != nil {
+				t.Errorf("output %s not written: %v", tt.wantFile, err)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	if got := sanitizeFilename(`Home/Work: "A|B"`); got != "Home_Work_ _A_B_" {
+		t.Errorf("sanitizeFilename() = %q", got)
+	}
+}
+
+func TestCLI_Convert_PrivacyZones(t *testing.T) {
+	input := filepath.Join("testdata", "input", "multi_point.json")
+	home := privacy.Zone{Name: "Home", Lat: 35.6812, Lon: 139.7454, Radius: 20}
+
+	tmpDir := t.TempDir()
+	output := filepath.Join(tmpDir, "out.gpx")
+	if err := New(&Config{PrivacyZones: []privacy.Zone{home}}).Convert(&Options{InputFile: input, OutputFile: output}); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if strings.Contains(string(data), `lat="35.6812"`) {
+		t.Errorf("output still contains the point inside the privacy zone")
+	}
+	if got := strings.Count(string(data), "<trkpt"); got != 2 {
+		t.Errorf("got %d track points, want 2", got)
+	}
+
+	home.Radius = 1000
+	err = New(&Config{PrivacyZones: []privacy.Zone{home}}).Convert(&Options{InputFile: input, OutputFile: output})
+	if err == nil || !strings.Contains(err.Error(), "privacy zones") {
+		t.Errorf("Convert() error = %v, want all points removed", err)
+	}
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chocoby/zweg/internal/geotag"
+)
+
+// GeotagOptions describes a geotagging run.
+type GeotagOptions struct {
+	LogFile     string
+	InputFormat string
+	Photos      []string
+	Geotag      geotag.Options
+}
+
+// Geotag matches photos to the track in opts.LogFile and writes their
+// positions, printing one line per photo. It returns an error when any
+// photo could not be matched or written.
+func (c *CLI) Geotag(opts *GeotagOptions) error {
+	if opts.LogFile == "" {
+		return c.lang.Errorf("track log is required")
+	}
+	if len(opts.Photos) == 0 {
+		return c.lang.Errorf("no photos given")
+	}
+
+	t, _, err := c.readFile(opts.LogFile, opts.InputFormat)
+	if err != nil {
+		return c.lang.Errorf("failed to read track log: %w", err)
+	}
+	if err := c.protect(t); err != nil {
+		return err
+	}
+
+	geotagOpts := opts.Geotag
+	geotagOpts.PrivacyZones = append(geotagOpts.PrivacyZones, c.privacyZones...)
+	matches := geotag.Photos(t, opts.Photos, geotagOpts)
+
+	out := c.stdout
+	if out == nil {
+		out = io.Discard
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PHOTO\tTIME (UTC)\tLATITUDE\tLONGITUDE\tELEVATION\tNEAREST POINT")
+
+	failed := 0
+	for _, m := range matches {
+		if m.Err != nil {
+			failed++
+			when := "-"
+			if !m.Time.IsZero() {
+				when = m.Time.UTC().Format(time.DateTime)
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t-\t-\t-\t%v\n", m.Photo, when, m.Err)
+			continue
+		}
+		elevation := "-"
+		if m.Point.HasEle {
+			elevation = fmt.Sprintf("%.1f m", m.Point.Ele)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%.6f\t%.6f\t%s\t%.1f m, %s\n",
+			m.Photo, m.Time.UTC().Format(time.DateTime), m.Point.Lat, m.Point.Lon, elevation,
+			m.Distance, m.TimeDelta.Abs().Round(time.Second))
+	}
+	if err := tw.Flush(); err != nil {
+		return c.lang.Errorf("failed to write geotag report: %w", err)
+	}
+
+	summary := "Tagged %d of %d photo(s)\n"
+	if opts.Geotag.DryRun {
+		summary = "Matched %d of %d photo(s) (dry run, no files changed)\n"
+	}
+	_, _ = fmt.Fprint(out, c.lang.Sprintf(summary, len(matches)-failed, len(matches)))
+
+	if failed > 0 {
+		return c.lang.Errorf("%d of %d photo(s) could not be tagged", failed, len(matches))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/geotag"
+	"github.com/chocoby/zweg/internal/privacy"
+)
+
+func TestCLI_Geotag_ReportsFailures(t *testing.T) {
+	photo := filepath.Join(t.TempDir(), "scan.jpg")
+	if err := os.WriteFile(photo, []byte("not a jpeg"), 0644); err != nil {
+		t.Fatalf("write photo: %v", err)
+	}
+
+	var out strings.Builder
+	err := New(&Config{Stdout: &out}).Geotag(&GeotagOptions{
+		LogFile: filepath.Join("testdata", "input", "multi_point.json"),
+		Photos:  []string{photo},
+		Geotag:  geotag.Options{DryRun: true},
+	})
+	if err == nil || !strings.Contains(err.Error(), "1 of 1 photo(s)") {
+		t.Errorf("Geotag() error = %v, want 1 of 1 photo(s) failed", err)
+	}
+	for _, want := range []string{"PHOTO", "scan.jpg", "not a JPEG file", "Matched 0 of 1 photo(s) (dry run"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if err := New(nil).Geotag(&GeotagOptions{LogFile: "missing.json", Photos: []string{photo}}); err == nil {
+		t.Error("Geotag(missing log) error = nil, want error")
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		in      string
+		offset  int
+		wantErr bool
+	}{
+		{"+09:00", 9 * 3600, false},
+		{"-0500", -5 * 3600, false},
+		{"UTC", 0, false},
+		{"Asia/Tokyo", 9 * 3600, false},
+		{"Mars/Olympus", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		loc, err := ParseLocation(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseLocation(%q) error = nil, want error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseLocation(%q) error = %v", tt.in, err)
+			continue
+		}
+		if _, off := time.Date(2024, 1, 1, 0, 0, 0, 0, loc).Zone(); off != tt.offset {
+			t.Errorf("ParseLocation(%q) offset = %d, want %d", tt.in, off, tt.offset)
+		}
+	}
+}
+
+func TestCLI_Geotag_PrivacyZones(t *testing.T) {
+	photo := filepath.Join(t.TempDir(), "scan.jpg")
+	if err := os.WriteFile(photo, []byte("not a jpeg"), 0644); err != nil {
+		t.Fatalf("write photo: %v", err)
+	}
+
+	// A zone covering the whole log leaves nothing to take positions from.
+	c := New(&Config{PrivacyZones: []privacy.Zone{{Lat: 35.6812, Lon: 139.7671, Radius: 100000}}})
+	err := c.Geotag(&GeotagOptions{
+		LogFile: filepath.Join("testdata", "input", "multi_point.json"),
+		Photos:  []string{photo},
+		Geotag:  geotag.Options{DryRun: true},
+	})
+	if err == nil || !strings.Contains(err.Error(), "privacy zones") {
+		t.Errorf("Geotag() error = %v, want all points inside privacy zones", err)
+	}
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"io"
+	"path"
+	"path/filepath"
+
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/models"
+)
+
+// input is a log to convert: a file, or one log of a zip archive.
+type input struct {
+	// path names the log in messages and results.
+	path string
+	// file stands in for the input file when resolving the format and
+	// naming the output. For an archive entry it is a file of the same
+	// name next to the archive.
+	file string
+	open func() (io.ReadCloser, error)
+}
+
+func fileInput(name string) input {
+	return input{
+		path: name,
+		file: name,
+		open: func() (io.ReadCloser, error) { return fileio.Open(name) },
+	}
+}
+
+func entryInput(archive string, e *fileio.ArchiveEntry) input {
+	return input{
+		path: filepath.Join(archive, filepath.FromSlash(e.Name)),
+		file: filepath.Join(filepath.Dir(archive), path.Base(e.Name)),
+		open: e.Open,
+	}
+}
+
+// readPoints decodes in as a ZweiteGPS log.
+func readPoints(in input) ([]models.Point, error) {
+	rc, err := in.open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	r, err := fileio.Decompress(rc)
+	if err != nil {
+		return nil, err
+	}
+	return fileio.NewJSONReader().Decode(r)
+}
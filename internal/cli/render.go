@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/render"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// imageFormats maps image output names to their encoders.
+var imageFormats = map[string]func(io.Writer, *track.Track, render.MapOptions) error{
+	"svg": render.MapSVG,
+	"png": render.MapPNG,
+}
+
+// RenderOptions describes a single track rendering.
+type RenderOptions struct {
+	InputFile   string
+	InputFormat string
+	OutputFile  string // auto-generated from the track start time when empty
+	OutputDir   string // used only when OutputFile is empty
+
+	// Format is "svg" or "png". When empty it is taken from the output
+	// file extension, falling back to SVG.
+	Format string
+
+	// TimezoneOffset is the offset in seconds used for filename generation.
+	TimezoneOffset int
+	// Location, when set, is used instead of TimezoneOffset.
+	Location *time.Location
+
+	Map render.MapOptions
+}
+
+// imageFormat resolves the image format from name or the path extension.
+func imageFormat(name, path string) (string, error) {
+	if name == "" {
+		name = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if _, ok := imageFormats[name]; !ok {
+			name = "svg"
+		}
+	}
+	name = strings.ToLower(name)
+	if _, ok := imageFormats[name]; !ok {
+		return "", fmt.Errorf("unknown image format %q (expected svg or png)", name)
+	}
+	return name, nil
+}
+
+// Render reads opts.InputFile and draws the track as an image.
+func (c *CLI) Render(opts *RenderOptions) error {
+	if opts.InputFile == "" {
+		return c.lang.Errorf("input file is required")
+	}
+
+	name, err := imageFormat(opts.Format, opts.OutputFile)
+	if err != nil {
+		return err
+	}
+
+	t, _, err := c.readFile(opts.InputFile, opts.InputFormat)
+	if err != nil {
+		return c.lang.Errorf("failed to read input file: %w", err)
+	}
+	if err := c.protect(t); err != nil {
+		return err
+	}
+
+	outputFile, err := c.imageOutputFile(opts.InputFile, opts.OutputFile, opts.OutputDir, t, zone(opts.TimezoneOffset, opts.Location), "."+name)
+	if err != nil {
+		return err
+	}
+
+	mapOpts := opts.Map
+	if mapOpts.Lang == "" {
+		mapOpts.Lang = c.lang
+	}
+	draw := imageFormats[name]
+	if err := fileio.WriteFile(outputFile, func(w io.Writer) error {
+		return draw(w, t, mapOpts)
+	}); err != nil {
+		return c.lang.Errorf("failed to write output file: %w", err)
+	}
+
+	if c.stdout != nil {
+		if _, err := fmt.Fprint(c.stdout, c.lang.Sprintf("Successfully rendered %d points to %s: %s\n", len(t.Points), strings.ToUpper(name), outputFile)); err != nil {
+			return c.lang.Errorf("failed to write output message: %w", err)
+		}
+	}
+	return nil
+}
+
+// ProfileOptions describes a single profile chart.
+type ProfileOptions struct {
+	InputFile   string
+	InputFormat string
+	OutputFile  string // auto-generated from the track start time when empty
+	OutputDir   string // used only when OutputFile is empty
+
+	Metric render.Metric
+	// Cadence overlays step cadence on the elevation or speed chart.
+	Cadence bool
+	Width   int
+	Height  int
+
+	// TimezoneOffset is the offset in seconds used for filename generation.
+	TimezoneOffset int
+	// Location, when set, is used instead of TimezoneOffset.
+	Location *time.Location
+}
+
+// Profile reads opts.InputFile and writes a profile chart as SVG. The
+// generated filename carries the metric, e.g. 20240101-093015-elevation.svg.
+func (c *CLI) Profile(opts *ProfileOptions) error {
+	if opts.InputFile == "" {
+		return c.lang.Errorf("input file is required")
+	}
+
+	t, _, err := c.readFile(opts.InputFile, opts.InputFormat)
+	if err != nil {
+		return c.lang.Errorf("failed to read input file: %w", err)
+	}
+	if err := c.protect(t); err != nil {
+		return err
+	}
+
+	chart, err := render.ProfileChart(t, opts.Metric, opts.Width, opts.Height, opts.Cadence)
+	if err != nil {
+		return c.lang.Errorf("failed to build %s profile: %w", opts.Metric, err)
+	}
+	chart.Translate(c.lang)
+
+	outputFile, err := c.imageOutputFile(opts.InputFile, opts.OutputFile, opts.OutputDir, t, zone(opts.TimezoneOffset, opts.Location), "-"+string(opts.Metric)+".svg")
+	if err != nil {
+		return err
+	}
+
+	if err := fileio.WriteFile(outputFile, chart.WriteSVG); err != nil {
+		return c.lang.Errorf("failed to write output file: %w", err)
+	}
+
+	if c.stdout != nil {
+		if _, err := fmt.Fprint(c.stdout, c.lang.Sprintf("Successfully plotted %s profile of %d points: %s\n", opts.Metric, len(t.Points), outputFile)); err != nil {
+			return c.lang.Errorf("failed to write output message: %w", err)
+		}
+	}
+	return nil
+}
+
+// imageOutputFile resolves and validates the output path of an image,
+// generating it from the track start time and suffix when outputFile is
+// empty, and creates its directory.
+func (c *CLI) imageOutputFile(inputFile, outputFile, outputDir string, t *track.Track, loc *time.Location, suffix string) (string, error) {
+	var err error
+	if outputFile == "" {
+		outputFile, err = c.generateOutputFilename(inputFile, outputDir, t, loc, "", suffix)
+		if err != nil {
+			return "", c.lang.Errorf("failed to generate output filename: %w", err)
+		}
+	} else {
+		outputFile, err = validateOutputPath(outputFile)
+		if err != nil {
+			return "", c.lang.Errorf("invalid output file path: %w", err)
+		}
+	}
+
+	if err := fileio.MkdirAll(filepath.Dir(outputFile)); err != nil {
+		return "", c.lang.Errorf("failed to create output directory: %w", err)
+	}
+	return outputFile, nil
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chocoby/zweg/internal/render"
+)
+
+func TestCLI_Render(t *testing.T) {
+	inputPath := filepath.Join("testdata", "input", "multi_point.json")
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name   string
+		opts   RenderOptions
+		output string
+		magic  string
+	}{
+		{
+			name:   "svg by default",
+			opts:   RenderOptions{OutputDir: tmpDir},
+			output: "20210101-000000.svg",
+			magic:  "<svg",
+		},
+		{
+			name:   "png from extension",
+			opts:   RenderOptions{OutputFile: filepath.Join(tmpDir, "map.png")},
+			output: "map.png",
+			magic:  "\x89PNG",
+		},
+		{
+			name:   "png by flag",
+			opts:   RenderOptions{OutputDir: tmpDir, Format: "PNG", TimezoneOffset: 9 * 3600},
+			output: "20210101-090000.png",
+			magic:  "\x89PNG",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.InputFile = inputPath
+			opts.Map = render.DefaultMapOptions()
+
+			var out strings.Builder
+			if err := New(&Config{Stdout: &out}).Render(&opts); err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(tmpDir, tt.output))
+			if err != nil {
+				t.Fatalf("read output: %v", err)
+			}
+			if !strings.HasPrefix(string(data), tt.magic) {
+				t.Errorf("output starts with %q, want %q", data[:min(len(data), 8)], tt.magic)
+			}
+			if !strings.Contains(out.String(), tt.output) {
+				t.Errorf("message %q does not name %s", out.String(), tt.output)
+			}
+		})
+	}
+
+	err := New(&Config{}).Render(&RenderOptions{InputFile: inputPath, Format: "jpeg", Map: render.DefaultMapOptions()})
+	if err == nil {
+		t.Error("Render(jpeg) error = nil, want error")
+	}
+}
+
+func TestCLI_Profile(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := filepath.Join(tmpDir, "walk.json")
+	log := `[
+  {"tm":1609459200,"lo":139.000,"la":35.000,"al":"10","ws":0},
+  {"tm":1609459260,"lo":139.000,"la":35.001,"al":"14","ws":110},
+  {"tm":1609459320,"lo":139.001,"la":35.001,"al":"12","ws":230}
+]`
+	if err := os.WriteFile(input, []byte(log), 0644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	for _, metric := range []render.Metric{render.MetricElevation, render.MetricSpeed, render.MetricCadence} {
+		var out strings.Builder
+		err := New(&Config{Stdout: &out}).Profile(&ProfileOptions{
+			InputFile: input,
+			Metric:    metric,
+			Cadence:   metric != render.MetricCadence,
+			Width:     800,
+			Height:    240,
+		})
+		if err != nil {
+			t.Fatalf("Profile(%s): %v", metric, err)
+		}
+
+		output := filepath.Join(tmpDir, "20210101-000000-"+string(metric)+".svg")
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("read output: %v", err)
+		}
+		if !strings.Contains(string(data), `width="800" height="240"`) {
+			t.Errorf("%s profile has wrong size:\n%s", metric, data)
+		}
+		if !strings.Contains(string(data), "Cadence (steps/min)") {
+			t.Errorf("%s profile missing cadence", metric)
+		}
+	}
+
+	err := New(&Config{}).Profile(&ProfileOptions{
+		InputFile: filepath.Join("testdata", "input", "multi_point.json"),
+		OutputDir: tmpDir,
+		Metric:    render.MetricCadence,
+		Width:     800,
+		Height:    240,
+	})
+	if err == nil {
+		t.Error("Profile(cadence) of a log without steps error = nil, want error")
+	}
+
+	noEle := filepath.Join(tmpDir, "flat.json")
+	if err := os.WriteFile(noEle, []byte(`[{"tm":1609459200,"lo":139,"la":35},{"tm":1609459260,"lo":139,"la":35.001}]`), 0644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	output := filepath.Join(tmpDir, "flat.svg")
+	err = New(&Config{}).Profile(&ProfileOptions{InputFile: noEle, OutputFile: output, Metric: render.MetricElevation, Width: 800, Height: 240})
+	if !errors.Is(err, render.ErrNoData) {
+		t.Errorf("Profile(elevation) of a log without altitudes error = %v, want ErrNoData", err)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("Profile without data left %s behind", output)
+	}
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/chocoby/zweg/internal/format"
+	"github.com/chocoby/zweg/internal/stats"
+	"github.com/chocoby/zweg/internal/validate"
+)
+
+// Result describes one conversion. With Config.JSON, Convert writes it to
+// stdout as a single line of JSON, so that scripts need not parse the
+// message meant for people.
+type Result struct {
+	Input  string `json:"input"`
+	Output string `json:"output,omitempty"`
+	// Format is the name of the output format.
+	Format string `json:"format,omitempty"`
+	// Points is the number of points written, and Dropped the number
+	// removed by privacy zones.
+	Points  int `json:"points"`
+	Dropped int `json:"dropped"`
+	// Warnings lists suspicious input values that were converted anyway.
+	Warnings []string       `json:"warnings"`
+	Stats    *stats.Summary `json:"stats,omitempty"`
+	// Duration is how long the conversion took.
+	Duration time.Duration `json:"-"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes Duration as seconds, like the durations in stats.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return json.Marshal(struct {
+		plain
+		DurationSeconds float64 `json:"duration_s"`
+	}{
+		plain:           plain(r),
+		DurationSeconds: r.Duration.Seconds(),
+	})
+}
+
+// writeResult writes res as JSON and returns convErr, the error of the
+// conversion, so that a failed conversion still fails the command.
+func (c *CLI) writeResult(res *Result, convErr error, elapsed time.Duration) error {
+	res.Duration = elapsed
+	if convErr != nil {
+		res.Error = convErr.Error()
+	}
+	if c.stdout != nil {
+		if err := json.NewEncoder(c.stdout).Encode(res); err != nil {
+			return c.lang.Errorf("failed to write output message: %w", err)
+		}
+	}
+	return convErr
+}
+
+// inputWarnings lists the validation issues of a ZweiteGPS log. The
+// decoder has already turned its strings into numbers, so the log is read
+// again as recorded. Other formats have no checks.
+func inputWarnings(in input, f *format.Format) []string {
+	warnings := []string{}
+	if f.Name != "zweite" {
+		return warnings
+	}
+	points, err := readPoints(in)
+	if err != nil {
+		return warnings
+	}
+	for _, issue := range validate.Points(points).Issues {
+		warnings = append(warnings, issue.String())
+	}
+	return warnings
+}
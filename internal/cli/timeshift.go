@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// TimeShift corrects the timestamps of a recording, either by a fixed
+// duration or by moving the first point to a known time. The zero value
+// leaves timestamps alone.
+type TimeShift struct {
+	By         time.Duration
+	AlignStart time.Time
+}
+
+// IsZero reports whether the shift changes nothing.
+func (s TimeShift) IsZero() bool {
+	return s.By == 0 && s.AlignStart.IsZero()
+}
+
+// Apply shifts every point of t.
+func (s TimeShift) Apply(t *track.Track) {
+	d := s.By
+	if !s.AlignStart.IsZero() && len(t.Points) > 0 {
+		d = s.AlignStart.Sub(t.Start())
+	}
+	t.Shift(d)
+}
+
+// timeShiftLayouts are the accepted timestamp forms, most specific first.
+var timeShiftLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// ParseTimeShift parses a duration such as "-9h" or "+1m30s", or a
+// timestamp to align the first point to. Timestamps without an offset are
+// read in loc.
+func ParseTimeShift(s string, loc *time.Location) (TimeShift, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return TimeShift{By: d}, nil
+	}
+	for _, layout := range timeShiftLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return TimeShift{AlignStart: t}, nil
+		}
+	}
+	return TimeShift{}, fmt.Errorf("invalid time shift %q (expected a duration like -9h or a timestamp like 2024-05-01T09:30:00+09:00)", s)
+}
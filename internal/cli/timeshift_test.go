@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimeShift(t *testing.T) {
+	jst := time.FixedZone("", 9*3600)
+	tests := []struct {
+		in      string
+		want    TimeShift
+		wantErr bool
+	}{
+		{"-9h", TimeShift{By: -9 * time.Hour}, false},
+		{"+1m30s", TimeShift{By: 90 * time.Second}, false},
+		{"2024-05-01T09:30:00Z", TimeShift{AlignStart: time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)}, false},
+		{"2024-05-01 09:30:00", TimeShift{AlignStart: time.Date(2024, 5, 1, 0, 30, 0, 0, time.UTC)}, false},
+		{"yesterday", TimeShift{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeShift(tt.in, jst)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseTimeShift(%q) error = nil, want error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTimeShift(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got.By != tt.want.By || !got.AlignStart.Equal(tt.want.AlignStart) {
+			t.Errorf("ParseTimeShift(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCLI_Convert_TimeShift(t *testing.T) {
+	tests := []struct {
+		name     string
+		shift    TimeShift
+		wantFile string
+		wantTime string
+	}{
+		{
+			name:     "by duration",
+			shift:    TimeShift{By: -9 * time.Hour},
+			wantFile: "20201231-150000.gpx",
+			wantTime: "<time>2020-12-31T15:01:00Z</time>",
+		},
+		{
+			name:     "align first point",
+			shift:    TimeShift{AlignStart: time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)},
+			wantFile: "20240501-093000.gpx",
+			wantTime: "<time>2024-05-01T09:32:00Z</time>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			err := New(nil).Convert(&Options{
+				InputFile: filepath.Join("testdata", "input", "multi_point.json"),
+				OutputDir: tmpDir,
+				TimeShift: tt.shift,
+			})
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(tmpDir, tt.wantFile))
+			if err != nil {
+				t.Fatalf("read output: %v", err)
+			}
+			if !strings.Contains(string(data), tt.wantTime) {
+				t.Errorf("output missing %s", tt.wantTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package clidoc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Bash writes a bash completion script for p.
+func Bash(w io.Writer, p *Program) error {
+	bw := bufio.NewWriter(w)
+	fn := "_" + ident(p.Name)
+	sets := newValueSets(p, fn+"_values")
+
+	fmt.Fprintf(bw, "# bash completion for %s\n", p.Name)
+	fmt.Fprintf(bw, "# Generated by \"%s completion bash\"; do not edit.\n\n", p.Name)
+	for i, values := range sets.lists {
+		fmt.Fprintf(bw, "%s=%s\n", sets.name(i), shellQuote(strings.Join(values, " ")))
+	}
+	fmt.Fprintf(bw, "\n%s_files() {\n", fn)
+	fmt.Fprintf(bw, "    local IFS=$'\\n'\n")
+	fmt.Fprintf(bw, "    compopt -o filenames 2>/dev/null\n")
+	fmt.Fprintf(bw, "    COMPREPLY+=($(compgen -f -- \"$cur\"))\n")
+	fmt.Fprintf(bw, "}\n\n")
+
+	fmt.Fprintf(bw, "%s() {\n", fn)
+	fmt.Fprintf(bw, "    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}\n")
+	fmt.Fprintf(bw, "    # The default COMP_WORDBREAKS splits --flag=value at the \"=\".\n")
+	fmt.Fprintf(bw, "    if [[ $cur == = ]]; then\n        cur=\n")
+	fmt.Fprintf(bw, "    elif [[ $prev == = ]]; then\n        prev=${COMP_WORDS[COMP_CWORD-2]}\n    fi\n")
+	fmt.Fprintf(bw, "    COMPREPLY=()\n\n")
+
+	names := strings.Join(p.names(), " ")
+	var def *Command
+	for i := range p.Commands {
+		if p.Commands[i].Name == p.DefaultCommand {
+			def = &p.Commands[i]
+		}
+	}
+	fmt.Fprintf(bw, "    if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then\n")
+	fmt.Fprintf(bw, "        COMPREPLY=($(compgen -W \"help %s\" -- \"$cur\"))\n", names)
+	if def != nil && def.Files {
+		fmt.Fprintf(bw, "        %s_files\n", fn)
+	}
+	fmt.Fprintf(bw, "        return\n    fi\n\n")
+
+	fmt.Fprintf(bw, "    local cmd=%s\n", p.DefaultCommand)
+	fmt.Fprintf(bw, "    if [[ $COMP_CWORD -gt 1 ]]; then\n")
+	fmt.Fprintf(bw, "        case ${COMP_WORDS[1]} in\n")
+	for _, c := range p.Commands {
+		fmt.Fprintf(bw, "        %s) cmd=%s ;;\n", strings.Join(append([]string{c.Name}, c.Aliases...), "|"), c.Name)
+	}
+	fmt.Fprintf(bw, "        help)\n")
+	fmt.Fprintf(bw, "            [[ $COMP_CWORD -eq 2 ]] && COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", names)
+	fmt.Fprintf(bw, "            return ;;\n")
+	fmt.Fprintf(bw, "        esac\n    fi\n\n")
+
+	fmt.Fprintf(bw, "    case $cmd in\n")
+	for _, c := range p.Commands {
+		fmt.Fprintf(bw, "    %s)\n", c.Name)
+		bashCommand(bw, fn, &c, sets)
+		fmt.Fprintf(bw, "        ;;\n")
+	}
+	fmt.Fprintf(bw, "    esac\n}\n\n")
+	fmt.Fprintf(bw, "complete -F %s %s\n", fn, p.Name)
+	return bw.Flush()
+}
+
+func bashCommand(w io.Writer, fn string, c *Command, sets *valueSets) {
+	var options, free []string
+	fmt.Fprintf(w, "        case $prev in\n")
+	for _, f := range c.Flags {
+		options = append(options, f.Option())
+		if f.Bool() {
+			continue
+		}
+		pattern := "-" + f.Name + "|--" + f.Name
+		switch {
+		case f.Values != nil:
+			fmt.Fprintf(w, "        %s)\n            COMPREPLY=($(compgen -W \"$%s\" -- \"$cur\"))\n            return ;;\n", pattern, sets.lookup(f.Values))
+		case f.Files:
+			fmt.Fprintf(w, "        %s)\n            %s_files\n            return ;;\n", pattern, fn)
+		default:
+			free = append(free, pattern)
+		}
+	}
+	if len(free) > 0 {
+		fmt.Fprintf(w, "        %s)\n            return ;;\n", strings.Join(free, "|"))
+	}
+	fmt.Fprintf(w, "        esac\n")
+
+	fmt.Fprintf(w, "        if [[ $cur == -* ]]; then\n")
+	fmt.Fprintf(w, "            COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(options, " "))
+	fmt.Fprintf(w, "            return\n        fi\n")
+	if len(c.Args) > 0 {
+		fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(c.Args, " "))
+	}
+	if c.Files {
+		fmt.Fprintf(w, "        %s_files\n", fn)
+	}
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ident turns a program name into a shell identifier.
+func ident(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// valueSets gives each distinct list of flag values a variable, so that
+// long lists such as time zone names appear in a script only once.
+type valueSets struct {
+	prefix string
+	lists  [][]string
+	index  map[string]int
+}
+
+func newValueSets(p *Program, prefix string) *valueSets {
+	s := &valueSets{prefix: prefix, index: make(map[string]int)}
+	for _, c := range p.Commands {
+		for _, f := range c.Flags {
+			if f.Values == nil {
+				continue
+			}
+			key := strings.Join(f.Values, "\n")
+			if _, ok := s.index[key]; !ok {
+				s.index[key] = len(s.lists)
+				s.lists = append(s.lists, f.Values)
+			}
+		}
+	}
+	return s
+}
+
+func (s *valueSets) name(i int) string {
+	return fmt.Sprintf("%s%d", s.prefix, i+1)
+}
+
+func (s *valueSets) lookup(values []string) string {
+	return s.name(s.index[strings.Join(values, "\n")])
+}
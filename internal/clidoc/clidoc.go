@@ -0,0 +1,92 @@
+// Package clidoc generates shell completion scripts and a man page from a
+// description of a command-line program, so that both follow the flag
+// definitions instead of being maintained by hand.
+package clidoc
+
+import "strings"
+
+// Program describes a program with subcommands.
+type Program struct {
+	Name    string
+	Version string
+	// Summary is the one-line description in the NAME section of the man
+	// page; Description is the text of its DESCRIPTION section.
+	Summary     string
+	Description string
+	Commands    []Command
+	// DefaultCommand runs when the first argument is not a command name.
+	DefaultCommand string
+	// ExitStatus fills the EXIT STATUS section of the man page, and Files
+	// its FILES section.
+	ExitStatus []ExitStatus
+	Files      []File
+}
+
+// Command describes a subcommand.
+type Command struct {
+	Name    string
+	Aliases []string
+	// Synopsis is the argument synopsis shown after [options].
+	Synopsis string
+	Summary  string
+	Help     string
+	Flags    []Flag
+	// Args lists the words the command accepts as arguments, and Files
+	// reports whether it takes file names.
+	Args  []string
+	Files bool
+}
+
+// Flag describes a command-line flag.
+type Flag struct {
+	Name string
+	// Arg names the flag's value, e.g. "duration"; it is empty for boolean
+	// flags, which take no value.
+	Arg     string
+	Usage   string
+	Default string
+	// Values lists the accepted values when they are a known set, and Files
+	// reports whether the value is a file name.
+	Values []string
+	Files  bool
+}
+
+// ExitStatus is an entry of the EXIT STATUS section of the man page.
+type ExitStatus struct {
+	Code        int
+	Description string
+}
+
+// File is an entry of the FILES section of the man page.
+type File struct {
+	Path        string
+	Description string
+}
+
+// Bool reports whether the flag takes no value.
+func (f *Flag) Bool() bool { return f.Arg == "" }
+
+// Option returns the flag as it is written on the command line: a
+// single-letter flag with one dash, others with two.
+func (f *Flag) Option() string {
+	if len(f.Name) == 1 {
+		return "-" + f.Name
+	}
+	return "--" + f.Name
+}
+
+// names returns the command names and aliases of p.
+func (p *Program) names() []string {
+	var names []string
+	for _, c := range p.Commands {
+		names = append(names, c.Name)
+		names = append(names, c.Aliases...)
+	}
+	return names
+}
+
+// firstLine returns s up to its first newline.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
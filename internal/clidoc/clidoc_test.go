@@ -0,0 +1,183 @@
+package clidoc
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testProgram() *Program {
+	return &Program{
+		Name:           "tool",
+		Version:        "1.2.3",
+		Summary:        "do things",
+		Description:    "Tool does things.\n\n.Dotted lines stay text.",
+		DefaultCommand: "run",
+		Commands: []Command{
+			{
+				Name:     "run",
+				Synopsis: "<input>",
+				Summary:  "Run a file",
+				Help:     "Run the input file.",
+				Files:    true,
+				Flags: []Flag{
+					{Name: "d", Arg: "dir", Usage: "Output directory", Files: true},
+					{Name: "format", Arg: "string", Usage: "Output format", Default: `"a"`, Values: []string{"a", "b-c"}},
+					{Name: "zone", Arg: "string", Usage: "Time zone", Values: []string{"Asia/Tokyo", "Europe/Paris"}},
+					{Name: "name", Arg: "string", Usage: "Name: any [text]"},
+					{Name: "verbose", Usage: "Print what's going on"},
+				},
+			},
+			{
+				Name:     "show",
+				Aliases:  []string{"list"},
+				Synopsis: "one|two",
+				Summary:  "Show something",
+				Help:     "Show it.",
+				Args:     []string{"one", "two"},
+				Flags: []Flag{
+					{Name: "zone", Arg: "string", Usage: "Time zone", Values: []string{"Asia/Tokyo", "Europe/Paris"}},
+				},
+			},
+		},
+	}
+}
+
+// complete runs the bash script in dir for the command line words, where
+// the last word is being completed.
+func complete(t *testing.T, script, dir string, words ...string) string {
+	t.Helper()
+	var quoted []string
+	for _, w := range words {
+		quoted = append(quoted, shellQuote(w))
+	}
+	cmd := exec.Command("bash", "--norc", "--noprofile", "-c",
+		`source "$1"; COMP_WORDS=(`+strings.Join(quoted, " ")+`); COMP_CWORD=$((${#COMP_WORDS[@]} - 1)); _tool; echo "${COMPREPLY[*]}"`,
+		"bash", script)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash: %v\n%s", err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestBash(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+
+	var b strings.Builder
+	if err := Bash(&b, testProgram()); err != nil {
+		t.Fatalf("Bash: %v", err)
+	}
+	script := filepath.Join(t.TempDir(), "tool.bash")
+	if err := os.WriteFile(script, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "input.json"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"tool", ""}, "help run show list input.json"},
+		{[]string{"tool", "s"}, "show"},
+		{[]string{"tool", "--f"}, "--format"},
+		{[]string{"tool", "run", "--"}, "--format --zone --name --verbose"},
+		{[]string{"tool", "run", "--format", ""}, "a b-c"},
+		{[]string{"tool", "--zone", "As"}, "Asia/Tokyo"},
+		{[]string{"tool", "run", "--zone", "=", "Eu"}, "Europe/Paris"},
+		{[]string{"tool", "run", "--name", ""}, ""},
+		{[]string{"tool", "run", "-d", "in"}, "input.json"},
+		{[]string{"tool", "run", "--verbose", "in"}, "input.json"},
+		{[]string{"tool", "list", ""}, "one two"},
+		{[]string{"tool", "help", "r"}, "run"},
+	}
+	for _, tt := range tests {
+		if got := complete(t, script, dir, tt.words...); got != tt.want {
+			t.Errorf("complete %q = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestZsh(t *testing.T) {
+	var b strings.Builder
+	if err := Zsh(&b, testProgram()); err != nil {
+		t.Fatalf("Zsh: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"#compdef tool\n",
+		"_tool_values2=('Asia/Tokyo' 'Europe/Paris')\n",
+		"'list:Show something'",
+		"show|list)",
+		"'-d+[Output directory]:dir:_files'",
+		"'--zone=[Time zone]:string:compadd -a _tool_values2'",
+		`'--name=[Name\: any \[text\]]:string: '`,
+		`'--verbose[Print what'\''s going on]'`,
+		"'1:argument:(one two)'",
+		"'*:file:_files'",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "Asia/Tokyo") != 1 {
+		t.Errorf("value list repeated:\n%s", out)
+	}
+}
+
+func TestFish(t *testing.T) {
+	var b strings.Builder
+	if err := Fish(&b, testProgram()); err != nil {
+		t.Fatalf("Fish: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"complete -c tool -f\n",
+		"complete -c tool -n __tool_first -a list -d 'Show something'\n",
+		"            case show list\n                set cmd show\n",
+		"complete -c tool -n '__tool_using run' -s d -r -F -d 'Output directory'\n",
+		"complete -c tool -n '__tool_using run' -l format -x -a '$__tool_values1' -d 'Output format'\n",
+		`complete -c tool -n '__tool_using run' -l verbose -d 'Print what\'s going on'` + "\n",
+		"complete -c tool -n '__tool_using show' -a 'one two'\n",
+		"complete -c tool -n '__tool_using run' -F\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestMan(t *testing.T) {
+	p := testProgram()
+	p.ExitStatus = []ExitStatus{{Code: 0, Description: "Success."}, {Code: 2, Description: "Bad usage."}}
+	p.Files = []File{{Path: "~/.toolrc", Description: "Settings."}}
+
+	var b strings.Builder
+	if err := Man(&b, p); err != nil {
+		t.Fatalf("Man: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`.TH "TOOL" 1 "" "tool 1.2.3" "User Commands"` + "\n",
+		".SH NAME\ntool \\- do things\n",
+		"\\&.Dotted lines stay text.\n",
+		`.SS "tool run [options] <input>"` + "\n",
+		".TP\n.BI \\-\\-format \" \" \"string\"\nOutput format (default \"a\")\n",
+		".TP\n.B \\-\\-verbose\n",
+		".PP\nAliases: list\n",
+		".SH \"EXIT STATUS\"\n.TP\n.B 0\nSuccess.\n.TP\n.B 2\nBad usage.\n",
+		".SH FILES\n.TP\n.I ~/.toolrc\nSettings.\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package clidoc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Fish writes a fish completion script for p.
+func Fish(w io.Writer, p *Program) error {
+	bw := bufio.NewWriter(w)
+	fn := "__" + ident(p.Name)
+	sets := newValueSets(p, fn+"_values")
+
+	fmt.Fprintf(bw, "# fish completion for %s\n", p.Name)
+	fmt.Fprintf(bw, "# Generated by \"%s completion fish\"; do not edit.\n\n", p.Name)
+	for i, values := range sets.lists {
+		quoted := make([]string, len(values))
+		for j, v := range values {
+			quoted[j] = fishQuote(v)
+		}
+		fmt.Fprintf(bw, "set -g %s %s\n", sets.name(i), strings.Join(quoted, " "))
+	}
+
+	// using succeeds when the command being completed is one of its
+	// arguments; a first word that is no command runs the default one.
+	fmt.Fprintf(bw, "\nfunction %s_using\n", fn)
+	fmt.Fprintf(bw, "    set -l words (commandline -opc)\n")
+	fmt.Fprintf(bw, "    set -l cmd %s\n", p.DefaultCommand)
+	fmt.Fprintf(bw, "    if set -q words[2]\n")
+	fmt.Fprintf(bw, "        switch $words[2]\n")
+	for _, c := range p.Commands {
+		fmt.Fprintf(bw, "            case %s\n                set cmd %s\n", strings.Join(append([]string{c.Name}, c.Aliases...), " "), c.Name)
+	}
+	fmt.Fprintf(bw, "            case help\n                set cmd help\n")
+	fmt.Fprintf(bw, "        end\n    end\n")
+	fmt.Fprintf(bw, "    contains -- $cmd $argv\nend\n\n")
+
+	fmt.Fprintf(bw, "function %s_first\n", fn)
+	fmt.Fprintf(bw, "    test (count (commandline -opc)) -eq 1\nend\n\n")
+
+	fmt.Fprintf(bw, "complete -c %s -f\n", p.Name)
+	for _, c := range p.Commands {
+		fmt.Fprintf(bw, "complete -c %s -n %s_first -a %s -d %s\n", p.Name, fn, c.Name, fishQuote(c.Summary))
+		for _, alias := range c.Aliases {
+			fmt.Fprintf(bw, "complete -c %s -n %s_first -a %s -d %s\n", p.Name, fn, alias, fishQuote(c.Summary))
+		}
+	}
+	fmt.Fprintf(bw, "complete -c %s -n %s_first -a help -d 'Show the help of a command'\n", p.Name, fn)
+	fmt.Fprintf(bw, "complete -c %s -n '%s_using help' -a %s\n", p.Name, fn, fishQuote(strings.Join(p.names(), " ")))
+
+	for _, c := range p.Commands {
+		fmt.Fprintf(bw, "\n")
+		cond := fishQuote(fn + "_using " + c.Name)
+		for _, f := range c.Flags {
+			option := "-l " + f.Name
+			if len(f.Name) == 1 {
+				option = "-s " + f.Name
+			}
+			switch {
+			case f.Bool():
+			case f.Values != nil:
+				option += " -x -a '$" + sets.lookup(f.Values) + "'"
+			case f.Files:
+				option += " -r -F"
+			default:
+				option += " -x"
+			}
+			fmt.Fprintf(bw, "complete -c %s -n %s %s -d %s\n", p.Name, cond, option, fishQuote(f.Usage))
+		}
+		if len(c.Args) > 0 {
+			fmt.Fprintf(bw, "complete -c %s -n %s -a %s\n", p.Name, cond, fishQuote(strings.Join(c.Args, " ")))
+		}
+		if c.Files {
+			fmt.Fprintf(bw, "complete -c %s -n %s -F\n", p.Name, cond)
+		}
+	}
+	return bw.Flush()
+}
+
+// fishQuote quotes s for fish, where a backslash escapes itself and the
+// quote inside single quotes.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
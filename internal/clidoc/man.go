@@ -0,0 +1,97 @@
+package clidoc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Man writes a section 1 man page for p in roff.
+func Man(w io.Writer, p *Program) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, ".\\\" Generated by \"%s man\"; do not edit.\n", p.Name)
+	fmt.Fprintf(bw, ".TH %s 1 \"\" %s \"User Commands\"\n", roffArg(strings.ToUpper(p.Name)), roffArg(p.Name+" "+p.Version))
+	fmt.Fprintf(bw, ".SH NAME\n%s \\- %s\n", roffEscape(p.Name), roffEscape(p.Summary))
+
+	fmt.Fprintf(bw, ".SH SYNOPSIS\n")
+	fmt.Fprintf(bw, ".B %s\n.I command\n.RI [ options ] \" \" [ arguments ]\n", roffEscape(p.Name))
+	if p.DefaultCommand != "" {
+		fmt.Fprintf(bw, ".br\n.B %s\n.RI [ %s\\ options ] \" \" arguments\n", roffEscape(p.Name), roffEscape(p.DefaultCommand))
+	}
+
+	fmt.Fprintf(bw, ".SH DESCRIPTION\n")
+	roffText(bw, p.Description)
+
+	fmt.Fprintf(bw, ".SH COMMANDS\n")
+	for _, c := range p.Commands {
+		synopsis := p.Name + " " + c.Name + " [options]"
+		if c.Synopsis != "" {
+			synopsis += " " + c.Synopsis
+		}
+		fmt.Fprintf(bw, ".SS %s\n", roffArg(synopsis))
+		roffText(bw, c.Help)
+		if len(c.Aliases) > 0 {
+			fmt.Fprintf(bw, ".PP\nAliases: %s\n", roffEscape(strings.Join(c.Aliases, ", ")))
+		}
+		for _, f := range c.Flags {
+			if f.Bool() {
+				fmt.Fprintf(bw, ".TP\n.B %s\n", roffEscape(f.Option()))
+			} else {
+				fmt.Fprintf(bw, ".TP\n.BI %s \" \" %s\n", roffEscape(f.Option()), roffArg(f.Arg))
+			}
+			usage := f.Usage
+			if f.Default != "" {
+				usage += fmt.Sprintf(" (default %s)", f.Default)
+			}
+			fmt.Fprintf(bw, "%s\n", roffEscape(usage))
+		}
+	}
+
+	if len(p.ExitStatus) > 0 {
+		fmt.Fprintf(bw, ".SH \"EXIT STATUS\"\n")
+		for _, s := range p.ExitStatus {
+			fmt.Fprintf(bw, ".TP\n.B %d\n", s.Code)
+			roffText(bw, s.Description)
+		}
+	}
+
+	if len(p.Files) > 0 {
+		fmt.Fprintf(bw, ".SH FILES\n")
+		for _, f := range p.Files {
+			fmt.Fprintf(bw, ".TP\n.I %s\n", roffEscape(f.Path))
+			roffText(bw, f.Description)
+		}
+	}
+	return bw.Flush()
+}
+
+// roffText writes s as filled paragraphs separated by blank lines. As in
+// roff, an indented line starts on a line of its own.
+func roffText(w io.Writer, s string) {
+	for i, para := range strings.Split(strings.TrimSpace(s), "\n\n") {
+		if i > 0 {
+			fmt.Fprintf(w, ".PP\n")
+		}
+		for _, line := range strings.Split(para, "\n") {
+			fmt.Fprintf(w, "%s\n", roffEscape(line))
+		}
+	}
+}
+
+// roffEscape escapes s for use as a text line: backslashes and dashes are
+// escaped, and a line starting with a control character is protected.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// roffArg quotes s as a macro argument.
+func roffArg(s string) string {
+	return `"` + strings.ReplaceAll(roffEscape(s), `"`, `\(dq`) + `"`
+}
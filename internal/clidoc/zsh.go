@@ -0,0 +1,117 @@
+package clidoc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Zsh writes a zsh completion script for p. Installed as _<name> in a
+// directory of $fpath, it is loaded by compinit.
+func Zsh(w io.Writer, p *Program) error {
+	bw := bufio.NewWriter(w)
+	fn := "_" + ident(p.Name)
+	sets := newValueSets(p, fn+"_values")
+
+	fmt.Fprintf(bw, "#compdef %s\n", p.Name)
+	fmt.Fprintf(bw, "# zsh completion for %s\n", p.Name)
+	fmt.Fprintf(bw, "# Generated by \"%s completion zsh\"; do not edit.\n\n", p.Name)
+	for i, values := range sets.lists {
+		quoted := make([]string, len(values))
+		for j, v := range values {
+			quoted[j] = shellQuote(v)
+		}
+		fmt.Fprintf(bw, "%s=(%s)\n", sets.name(i), strings.Join(quoted, " "))
+	}
+
+	fmt.Fprintf(bw, "\n%s() {\n", fn)
+	fmt.Fprintf(bw, "  local -a commands\n  commands=(\n")
+	for _, c := range p.Commands {
+		fmt.Fprintf(bw, "    %s\n", shellQuote(zshEscape(c.Name, ":")+":"+c.Summary))
+		for _, alias := range c.Aliases {
+			fmt.Fprintf(bw, "    %s\n", shellQuote(zshEscape(alias, ":")+":"+c.Summary))
+		}
+	}
+	fmt.Fprintf(bw, "  )\n\n")
+
+	var def *Command
+	for i := range p.Commands {
+		if p.Commands[i].Name == p.DefaultCommand {
+			def = &p.Commands[i]
+		}
+	}
+	fmt.Fprintf(bw, "  if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then\n")
+	fmt.Fprintf(bw, "    _describe -t commands '%s command' commands\n", p.Name)
+	if def != nil && def.Files {
+		fmt.Fprintf(bw, "    _files\n")
+	}
+	fmt.Fprintf(bw, "    return\n  fi\n\n")
+
+	fmt.Fprintf(bw, "  local cmd=%s\n", p.DefaultCommand)
+	fmt.Fprintf(bw, "  case $words[2] in\n")
+	for _, c := range p.Commands {
+		fmt.Fprintf(bw, "    %s)\n", strings.Join(append([]string{c.Name}, c.Aliases...), "|"))
+		fmt.Fprintf(bw, "      cmd=%s; shift words; (( CURRENT-- )) ;;\n", c.Name)
+	}
+	fmt.Fprintf(bw, "    help)\n")
+	fmt.Fprintf(bw, "      (( CURRENT == 3 )) && _describe -t commands '%s command' commands\n", p.Name)
+	fmt.Fprintf(bw, "      return ;;\n")
+	fmt.Fprintf(bw, "  esac\n\n")
+
+	fmt.Fprintf(bw, "  case $cmd in\n")
+	for _, c := range p.Commands {
+		fmt.Fprintf(bw, "    %s)\n", c.Name)
+		fmt.Fprintf(bw, "      _arguments -S")
+		for _, spec := range zshSpecs(&c, sets) {
+			fmt.Fprintf(bw, " \\\n        %s", shellQuote(spec))
+		}
+		fmt.Fprintf(bw, " ;;\n")
+	}
+	fmt.Fprintf(bw, "  esac\n}\n\n")
+	fmt.Fprintf(bw, "%s \"$@\"\n", fn)
+	return bw.Flush()
+}
+
+// zshSpecs returns the _arguments specifications of c.
+func zshSpecs(c *Command, sets *valueSets) []string {
+	var specs []string
+	for _, f := range c.Flags {
+		desc := "[" + zshEscape(f.Usage, "[]:") + "]"
+		if f.Bool() {
+			specs = append(specs, f.Option()+desc)
+			continue
+		}
+		option := f.Option() + "="
+		if len(f.Name) == 1 {
+			option = f.Option() + "+"
+		}
+		action := " "
+		switch {
+		case f.Values != nil:
+			action = "compadd -a " + sets.lookup(f.Values)
+		case f.Files:
+			action = "_files"
+		}
+		specs = append(specs, option+desc+":"+zshEscape(f.Arg, ":")+":"+action)
+	}
+	if len(c.Args) > 0 {
+		specs = append(specs, "1:argument:("+strings.Join(c.Args, " ")+")")
+	}
+	if c.Files {
+		specs = append(specs, "*:file:_files")
+	}
+	return specs
+}
+
+// zshEscape backslash-escapes the characters of special in s.
+func zshEscape(s, special string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == '\\' || strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,243 @@
+// Package config loads default option values from TOML files.
+//
+// The user file, ~/.config/zweg/config.toml (or $XDG_CONFIG_HOME/zweg/
+// config.toml), is read first. A project file named .zweg.toml in the
+// working directory or the nearest parent overrides it. Command-line flags
+// override both; that part is up to the caller.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+	"github.com/chocoby/zweg/internal/privacy"
+)
+
+// ProjectFile is the name of the project-local configuration file.
+const ProjectFile = ".zweg.toml"
+
+// DefaultSource is the source reported for values no file sets.
+const DefaultSource = "default"
+
+// Config holds option defaults. Every field maps to a top-level TOML key.
+type Config struct {
+	OutputDir        string `toml:"output_dir"`
+	Timezone         string `toml:"timezone"`
+	FilenameTemplate string `toml:"filename_template"`
+	Format           string `toml:"format"`
+	InputFormat      string `toml:"input_format"`
+	Lang             string `toml:"lang"`
+	Creator          string `toml:"creator"`
+	Author           string `toml:"author"`
+	Copyright        string `toml:"copyright"`
+	License          string `toml:"license"`
+
+	// PrivacyZones accumulate across files instead of being replaced, so
+	// a project file cannot drop a zone set up in the user file.
+	PrivacyZones []Zone `toml:"privacy_zone"`
+
+	// Sources maps each TOML key to where its value came from: a file
+	// path or DefaultSource.
+	Sources map[string]string `toml:"-"`
+}
+
+// Zone is a [[privacy_zone]] table.
+type Zone struct {
+	Name   string  `toml:"name"`
+	Lat    float64 `toml:"lat"`
+	Lon    float64 `toml:"lon"`
+	Radius float64 `toml:"radius"` // metres
+
+	// Source is the file the zone was read from.
+	Source string `toml:"-"`
+}
+
+// Zones converts the configured privacy zones.
+func (c *Config) Zones() []privacy.Zone {
+	out := make([]privacy.Zone, len(c.PrivacyZones))
+	for i, z := range c.PrivacyZones {
+		out[i] = privacy.Zone{Name: z.Name, Lat: z.Lat, Lon: z.Lon, Radius: z.Radius}
+	}
+	return out
+}
+
+// Source returns where the value of key came from.
+func (c *Config) Source(key string) string {
+	if s, ok := c.Sources[key]; ok {
+		return s
+	}
+	return DefaultSource
+}
+
+// UserFile returns the path of the user configuration file.
+func UserFile() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "zweg", "config.toml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "zweg", "config.toml"), nil
+}
+
+// FindProjectFile returns the nearest ProjectFile in dir or its parents,
+// or "" when there is none.
+func FindProjectFile(dir string) string {
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load merges defaults, the user file and the project file found from the
+// working directory. Missing files are skipped.
+func Load(defaults Config) (*Config, error) {
+	var paths []string
+	if path, err := UserFile(); err == nil {
+		paths = append(paths, path)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if path := FindProjectFile(wd); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return LoadFiles(defaults, paths...)
+}
+
+// LoadFiles merges the files at paths over defaults, later files taking
+// precedence. Files that do not exist are skipped; unknown keys are an
+// error, since they are most likely typos.
+func LoadFiles(defaults Config, paths ...string) (*Config, error) {
+	c := defaults
+	c.PrivacyZones = append([]Zone(nil), defaults.PrivacyZones...)
+	c.Sources = make(map[string]string)
+
+	for _, path := range paths {
+		if err := c.merge(path); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+func (c *Config) merge(path string) error {
+	var file Config
+	md, err := toml.DecodeFile(path, &file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("config file %s: unknown key %q", path, undecoded[0].String())
+	}
+
+	for _, z := range file.PrivacyZones {
+		zone := privacy.Zone{Name: z.Name, Lat: z.Lat, Lon: z.Lon, Radius: z.Radius}
+		if err := zone.Validate(); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	// Relative output directories are relative to the file, not to
+	// wherever zweg happens to run.
+	if md.IsDefined("output_dir") {
+		file.OutputDir = resolveDir(file.OutputDir, filepath.Dir(path))
+	}
+
+	dst, src := reflect.ValueOf(c).Elem(), reflect.ValueOf(file)
+	for _, f := range fields() {
+		if !md.IsDefined(f.key) {
+			continue
+		}
+		if f.key == "privacy_zone" {
+			for _, z := range file.PrivacyZones {
+				z.Source = path
+				c.PrivacyZones = append(c.PrivacyZones, z)
+			}
+		} else {
+			dst.Field(f.index).Set(src.Field(f.index))
+		}
+		c.Sources[f.key] = path
+	}
+	return nil
+}
+
+// resolveDir expands a leading ~ and makes dir absolute relative to base.
+func resolveDir(dir, base string) string {
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	if dir != "" && !filepath.IsAbs(dir) {
+		dir = filepath.Join(base, dir)
+	}
+	return dir
+}
+
+// field is a TOML key of Config and the index of its struct field.
+type field struct {
+	key   string
+	index int
+}
+
+// fields lists the TOML keys of Config in declaration order.
+func fields() []field {
+	var out []field
+	typ := reflect.TypeFor[Config]()
+	for i := range typ.NumField() {
+		if key := typ.Field(i).Tag.Get("toml"); key != "" && key != "-" {
+			out = append(out, field{key, i})
+		}
+	}
+	return out
+}
+
+// Write prints c as TOML, annotating each value with its source.
+func (c *Config) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	v := reflect.ValueOf(c).Elem()
+	for _, f := range fields() {
+		if f.key == "privacy_zone" {
+			continue
+		}
+		_, _ = fmt.Fprintf(tw, "%s = %s\t# %s\n", f.key, strconv.Quote(v.Field(f.index).String()), c.Source(f.key))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(c.PrivacyZones) == 0 {
+		_, err := fmt.Fprintf(w, "\n# no privacy zones\n")
+		return err
+	}
+	for _, z := range c.PrivacyZones {
+		source := z.Source
+		if source == "" {
+			source = DefaultSource
+		}
+		if _, err := fmt.Fprintf(w, "\n[[privacy_zone]]  # %s\nname = %s\nlat = %g\nlon = %g\nradius = %g\n",
+			source, strconv.Quote(z.Name), z.Lat, z.Lon, z.Radius); err != nil {
+			return err
+		}
+	}
+	return nil
+}
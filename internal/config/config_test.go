@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "user", "config.toml")
+	project := filepath.Join(dir, "project", ProjectFile)
+	writeFile(t, user, `
+timezone = "+09:00"
+format = "geojson"
+author = "Hanako"
+
+[[privacy_zone]]
+name = "Home"
+lat = 35.6812
+lon = 139.7671
+radius = 200
+`)
+	writeFile(t, project, `
+format = "gpx"
+output_dir = "tracks"
+
+[[privacy_zone]]
+name = "Office"
+lat = 35.0
+lon = 139.0
+radius = 100
+`)
+
+	c, err := LoadFiles(Config{Timezone: "+00:00", Lang: "en"}, user, project, filepath.Join(dir, "missing.toml"))
+	if err != nil {
+		t.Fatalf("LoadFiles: %v", err)
+	}
+
+	if c.Timezone != "+09:00" || c.Source("timezone") != user {
+		t.Errorf("timezone = %q from %s, want +09:00 from the user file", c.Timezone, c.Source("timezone"))
+	}
+	if c.Format != "gpx" || c.Source("format") != project {
+		t.Errorf("format = %q from %s, want gpx from the project file", c.Format, c.Source("format"))
+	}
+	if c.Lang != "en" || c.Source("lang") != DefaultSource {
+		t.Errorf("lang = %q from %s, want the default", c.Lang, c.Source("lang"))
+	}
+	if want := filepath.Join(dir, "project", "tracks"); c.OutputDir != want {
+		t.Errorf("output_dir = %q, want %q relative to the project file", c.OutputDir, want)
+	}
+	if len(c.PrivacyZones) != 2 || c.PrivacyZones[0].Source != user || c.PrivacyZones[1].Source != project {
+		t.Errorf("privacy zones = %+v, want both files' zones", c.PrivacyZones)
+	}
+	if zones := c.Zones(); len(zones) != 2 || zones[0].Name != "Home" || zones[0].Radius != 200 {
+		t.Errorf("Zones() = %+v", zones)
+	}
+}
+
+func TestLoadFiles_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown key", `fromat = "gpx"`, `unknown key "fromat"`},
+		{"syntax", `format = gpx`, "failed to read config file"},
+		{"bad zone", "[[privacy_zone]]\nlat = 35\nlon = 139\n", "radius must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			writeFile(t, path, tt.content)
+			_, err := LoadFiles(Config{}, path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadFiles() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+	writeFile(t, filepath.Join(dir, "xdg", "zweg", "config.toml"), `lang = "ja"`)
+	writeFile(t, filepath.Join(dir, "work", ProjectFile), `author = "Taro"`)
+
+	sub := filepath.Join(dir, "work", "logs")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(sub)
+
+	c, err := Load(Config{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if c.Lang != "ja" || c.Author != "Taro" {
+		t.Errorf("Load() = lang %q, author %q; want ja and Taro", c.Lang, c.Author)
+	}
+}
+
+func TestConfig_Write(t *testing.T) {
+	c := &Config{
+		Timezone:     "+09:00",
+		Format:       "gpx",
+		PrivacyZones: []Zone{{Name: "Home", Lat: 35.5, Lon: 139.25, Radius: 200, Source: "/home/u/.config/zweg/config.toml"}},
+		Sources:      map[string]string{"timezone": "/work/.zweg.toml"},
+	}
+
+	var b strings.Builder
+	if err := c.Write(&b); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`timezone = "+09:00"`, "# /work/.zweg.toml",
+		`format = "gpx"`, "# default",
+		"[[privacy_zone]]  # /home/u/.config/zweg/config.toml", `name = "Home"`, "radius = 200",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+
+	// The output is itself a valid configuration file.
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, path, out)
+	back, err := LoadFiles(Config{}, path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if back.Timezone != "+09:00" || len(back.PrivacyZones) != 1 {
+		t.Errorf("reloaded %+v", back)
+	}
+}
@@ -1,13 +1,30 @@
 package converter
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"math"
+	"strings"
 	"time"
 
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/i18n"
 	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/stats"
+	"github.com/chocoby/zweg/internal/track"
 	"github.com/twpayne/go-gpx"
 )
 
+var (
+	// ErrNoPoints is returned when there are no points to convert.
+	ErrNoPoints = errors.New("no data points provided")
+	// ErrBadAltitude, ErrBadSpeed and ErrBadDistance are returned for a
+	// point whose altitude, speed or distance is not a number.
+	ErrBadAltitude = track.ErrBadAltitude
+	ErrBadSpeed    = track.ErrBadSpeed
+	ErrBadDistance = track.ErrBadDistance
+)
+
 // Converter defines the interface for converting GPS data to GPX format.
 type Converter interface {
 	Convert(points []models.Point, trackName string) (*gpx.GPX, error)
@@ -18,6 +35,27 @@ type Config struct {
 	Version         string
 	Creator         string
 	IncludeWaypoint bool
+
+	// Author is written as the metadata author name when set.
+	Author string
+	// Copyright is the copyright holder. When set, a copyright element is
+	// written for the year the track starts, with License if given.
+	Copyright string
+	License   string
+
+	// Lang selects the language of waypoint names, the default track name
+	// and the summary. The zero value is English.
+	Lang i18n.Lang
+
+	// Stops, when set, adds a waypoint for every stop between the start
+	// and goal waypoints. It has no effect without IncludeWaypoint.
+	Stops *stats.StopOptions
+	// Laps, when set, lists the laps of the track in its extensions, in
+	// the LapsNamespace schema.
+	Laps *stats.LapOptions
+	// Location is the time zone of the times in stop descriptions.
+	// Defaults to UTC.
+	Location *time.Location
 }
 
 // DefaultConfig returns the default configuration.
@@ -44,14 +82,44 @@ func New(config *Config) *GPXConverter {
 	}
 }
 
+// ctxCheckInterval is how many points are converted between context checks.
+const ctxCheckInterval = 1024
+
 // Convert converts ZweiteGPS points to GPX format.
 func (c *GPXConverter) Convert(points []models.Point, trackName string) (*gpx.GPX, error) {
+	return c.ConvertContext(context.Background(), points, trackName)
+}
+
+// ConvertContext is like Convert but stops early with ctx.Err() when ctx is
+// cancelled, which matters for multi-hour logs with tens of thousands of points.
+func (c *GPXConverter) ConvertContext(ctx context.Context, points []models.Point, trackName string) (*gpx.GPX, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if len(points) == 0 {
-		return nil, fmt.Errorf("no data points provided")
+		return nil, ErrNoPoints
+	}
+
+	t, err := track.FromZweite(points)
+	if err != nil {
+		return nil, err
+	}
+	return c.ConvertTrack(ctx, t, trackName)
+}
+
+// ConvertTrack converts a format-neutral track to GPX format.
+// An empty trackName is written as "Track".
+func (c *GPXConverter) ConvertTrack(ctx context.Context, t *track.Track, trackName string) (*gpx.GPX, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(t.Points) == 0 {
+		return nil, ErrNoPoints
 	}
 
+	lang := c.config.Lang
 	if trackName == "" {
-		trackName = "Track"
+		trackName = lang.T("Track")
 	}
 
 	g := &gpx.GPX{
@@ -59,74 +127,172 @@ func (c *GPXConverter) Convert(points []models.Point, trackName string) (*gpx.GP
 		Creator: c.config.Creator,
 	}
 
-	startTime := points[0].TimestampIn(time.UTC)
+	summary := stats.Compute(t)
+	desc := describe(summary, lang)
+
 	g.Metadata = &gpx.MetadataType{
-		Name: trackName,
-		Time: startTime,
+		Name:     trackName,
+		Desc:     desc,
+		Time:     t.Start().UTC(),
+		Keywords: keywords(t.Name, summary.Means, lang),
+		Bounds: &gpx.BoundsType{
+			MinLat: coord(summary.Bounds.MinLat),
+			MinLon: coord(summary.Bounds.MinLon),
+			MaxLat: coord(summary.Bounds.MaxLat),
+			MaxLon: coord(summary.Bounds.MaxLon),
+		},
+	}
+	if c.config.Author != "" {
+		g.Metadata.Author = &gpx.PersonType{Name: c.config.Author}
+	}
+	if c.config.Copyright != "" {
+		g.Metadata.Copyright = &gpx.CopyrightType{
+			Author:  c.config.Copyright,
+			Year:    t.Start().UTC().Year(),
+			License: c.config.License,
+		}
 	}
 
 	if c.config.IncludeWaypoint {
-		if err := c.addWaypoints(g, points); err != nil {
-			return nil, fmt.Errorf("failed to add waypoints: %w", err)
-		}
+		c.addWaypoints(g, t, lang)
 	}
 
-	track := &gpx.TrkType{
+	trk := &gpx.TrkType{
 		Name: trackName,
+		Desc: desc,
+	}
+	if m, ok := t.FirstMeans(); ok {
+		trk.Type = m.ActivityType()
+	}
+	if c.config.Laps != nil {
+		ext, err := lapsExtension(stats.Laps(t, *c.config.Laps))
+		if err != nil {
+			return nil, err
+		}
+		trk.Extensions = ext
 	}
 
 	segment := &gpx.TrkSegType{}
 
-	for i, point := range points {
-		alt, err := point.Altitude()
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse altitude at point %d: %w", i, err)
+	for i, point := range t.Points {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
 		}
 
-		timestamp := point.TimestampIn(time.UTC)
-
 		segment.TrkPt = append(segment.TrkPt, &gpx.WptType{
-			Lat:  point.La,
-			Lon:  point.Lo,
-			Ele:  alt,
-			Time: timestamp,
-			Desc: point.Dp,
-			HDOP: point.Ha,
-			VDOP: point.Va,
+			Lat:  coord(point.Lat),
+			Lon:  coord(point.Lon),
+			Ele:  ele(point),
+			Time: point.Time.UTC(),
+			Desc: point.Desc,
+			HDOP: point.HDOP,
+			VDOP: point.VDOP,
 		})
 	}
 
-	track.TrkSeg = append(track.TrkSeg, segment)
-	g.Trk = append(g.Trk, track)
+	trk.TrkSeg = append(trk.TrkSeg, segment)
+	g.Trk = append(g.Trk, trk)
 
 	return g, nil
 }
 
-// addWaypoints adds start and end waypoints to the GPX document.
-func (c *GPXConverter) addWaypoints(g *gpx.GPX, points []models.Point) error {
-	start, err := waypointFrom(points[0], "Start")
-	if err != nil {
-		return err
+// describe summarises a track for the metadata and track descriptions.
+func describe(s stats.Summary, lang i18n.Lang) string {
+	parts := []string{
+		lang.Sprintf("Distance: %.2f km", s.Distance/1000),
+		lang.Sprintf("Duration: %s", lang.Duration(s.Duration)),
+		lang.Sprintf("Elevation gain: %.0f m", s.ElevationGain),
 	}
-	goal, err := waypointFrom(points[len(points)-1], "Goal")
-	if err != nil {
-		return err
+	if len(s.Means) > 0 {
+		parts = append(parts, lang.Sprintf("Means: %s", lang.Join(translate(s.Means, lang))))
 	}
-	g.Wpt = append(g.Wpt, start, goal)
-	return nil
+	return lang.Join(parts)
 }
 
-func waypointFrom(p models.Point, name string) (*gpx.WptType, error) {
-	alt, err := p.Altitude()
-	if err != nil {
-		return nil, fmt.Errorf("waypoint %q altitude: %w", name, err)
+// keywords lists the recorded title and the means of transportation.
+func keywords(title string, means []string, lang i18n.Lang) string {
+	var out []string
+	if title != "" {
+		out = append(out, title)
+	}
+	out = append(out, translate(means, lang)...)
+	return strings.Join(out, ", ")
+}
+
+func translate(msgs []string, lang i18n.Lang) []string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = lang.T(m)
+	}
+	return out
+}
+
+// addWaypoints adds start and end waypoints to the GPX document, with the
+// stops in between when Config.Stops is set.
+func (c *GPXConverter) addWaypoints(g *gpx.GPX, t *track.Track, lang i18n.Lang) {
+	g.Wpt = append(g.Wpt, waypointFrom(t.Points[0], lang.T("Start")))
+	if c.config.Stops != nil {
+		for i, s := range stats.Stops(t, *c.config.Stops) {
+			g.Wpt = append(g.Wpt, c.stopWaypoint(s, i+1, lang))
+		}
+	}
+	g.Wpt = append(g.Wpt, waypointFrom(t.Points[len(t.Points)-1], lang.T("Goal")))
+}
+
+// stopTimeLayout formats arrival and departure times in stop descriptions.
+const stopTimeLayout = "2006-01-02 15:04"
+
+// stopWaypoint describes the nth stop, named after its memo if it has one.
+func (c *GPXConverter) stopWaypoint(s stats.Stop, n int, lang i18n.Lang) *gpx.WptType {
+	loc := c.config.Location
+	if loc == nil {
+		loc = time.UTC
 	}
+	name := s.Name
+	if name == "" {
+		name = lang.Sprintf("Stop %d", n)
+	}
+	return &gpx.WptType{
+		Lat:  coord(s.Lat),
+		Lon:  coord(s.Lon),
+		Time: s.Arrival,
+		Name: name,
+		Desc: lang.Sprintf("Arrival: %s, Departure: %s, Stay: %s",
+			s.Arrival.In(loc).Format(stopTimeLayout), s.Departure.In(loc).Format(stopTimeLayout), lang.Duration(s.Duration)),
+	}
+}
+
+// SeaLevel is the elevation written for a point recorded at exactly 0 m.
+// go-gpx leaves out an <ele> of 0, which is how a point without an
+// elevation is written, so sea level is kept as the smallest positive
+// float instead; fileio.GPXWriter writes it as <ele>0</ele>.
+const SeaLevel = math.SmallestNonzeroFloat64
+
+// ele returns the elevation to write for p: 0 for none, SeaLevel for 0 m.
+func ele(p track.Point) float64 {
+	if !p.HasEle {
+		return 0
+	}
+	if v := geo.Round(p.Ele, geo.EleDecimals); v != 0 {
+		return v
+	}
+	return SeaLevel
+}
+
+// coord rounds a latitude or longitude for output.
+func coord(v float64) float64 {
+	return geo.Round(v, geo.CoordDecimals)
+}
+
+func waypointFrom(p track.Point, name string) *gpx.WptType {
 	return &gpx.WptType{
-		Lat:  p.La,
-		Lon:  p.Lo,
-		Ele:  alt,
-		Time: p.TimestampIn(time.UTC),
+		Lat:  coord(p.Lat),
+		Lon:  coord(p.Lon),
+		Ele:  ele(p),
+		Time: p.Time.UTC(),
 		Name: name,
-		Desc: p.Dp,
-	}, nil
+		Desc: p.Desc,
+	}
 }
@@ -1,9 +1,15 @@
 package converter
 
 import (
+	"context"
+	"encoding/xml"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/chocoby/zweg/internal/i18n"
 	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/stats"
 )
 
 func TestGPXConverter_Convert(t *testing.T) {
@@ -210,6 +216,85 @@ func TestGPXConverter_Convert_WithConfig(t *testing.T) {
 	})
 }
 
+func TestGPXConverter_Convert_Metadata(t *testing.T) {
+	walking, train := models.MeansWalking, models.MeansTrain
+	points := []models.Point{
+		{Tm: 1609459200, Lo: 139.000, La: 35.000, Al: "10", Tl: "Commute", Ms: &walking},
+		{Tm: 1609459260, Lo: 139.000, La: 35.001, Al: "25"},
+		{Tm: 1609459320, Lo: 139.002, La: 35.001, Al: "20", Ms: &train},
+	}
+
+	c := New(&Config{Author: "Hanako", Copyright: "Hanako Yamada", License: "https://creativecommons.org/licenses/by/4.0/"})
+	g, err := c.Convert(points, "Commute")
+	if err != nil {
+		t.Fatalf("Convert() unexpected error = %v", err)
+	}
+
+	md := g.Metadata
+	wantDesc := "Distance: 0.29 km, Duration: 2m0s, Elevation gain: 15 m, Means: Walking, Train"
+	if md.Desc != wantDesc {
+		t.Errorf("Metadata.Desc = %q, want %q", md.Desc, wantDesc)
+	}
+	if g.Trk[0].Desc != wantDesc {
+		t.Errorf("Trk.Desc = %q, want %q", g.Trk[0].Desc, wantDesc)
+	}
+	if md.Keywords != "Commute, Walking, Train" {
+		t.Errorf("Metadata.Keywords = %q, want %q", md.Keywords, "Commute, Walking, Train")
+	}
+	if b := md.Bounds; b == nil || b.MinLat != 35 || b.MaxLat != 35.001 || b.MinLon != 139 || b.MaxLon != 139.002 {
+		t.Errorf("Metadata.Bounds = %+v", md.Bounds)
+	}
+	if md.Author == nil || md.Author.Name != "Hanako" {
+		t.Errorf("Metadata.Author = %+v, want Hanako", md.Author)
+	}
+	if cp := md.Copyright; cp == nil || cp.Author != "Hanako Yamada" || cp.Year != 2021 || cp.License == "" {
+		t.Errorf("Metadata.Copyright = %+v", md.Copyright)
+	}
+	if g.Trk[0].Type != "walking" {
+		t.Errorf("Trk.Type = %q, want walking", g.Trk[0].Type)
+	}
+
+	g, err = New(nil).Convert(points[1:2], "Plain")
+	if err != nil {
+		t.Fatalf("Convert() unexpected error = %v", err)
+	}
+	if g.Metadata.Author != nil || g.Metadata.Copyright != nil || g.Trk[0].Type != "" || g.Metadata.Keywords != "" {
+		t.Errorf("unexpected optional metadata: %+v, type %q", g.Metadata, g.Trk[0].Type)
+	}
+}
+
+func TestGPXConverter_Convert_Japanese(t *testing.T) {
+	walking := models.MeansWalking
+	points := []models.Point{
+		{Tm: 1609459200, Lo: 139.000, La: 35.000, Al: "10", Ms: &walking},
+		{Tm: 1609459260, Lo: 139.000, La: 35.001, Al: "25"},
+	}
+
+	config := DefaultConfig()
+	config.Lang = i18n.Japanese
+	g, err := New(config).Convert(points, "")
+	if err != nil {
+		t.Fatalf("Convert() unexpected error = %v", err)
+	}
+
+	if g.Metadata.Name != "トラック" {
+		t.Errorf("Metadata.Name = %q, want トラック", g.Metadata.Name)
+	}
+	if g.Wpt[0].Name != "スタート" || g.Wpt[1].Name != "ゴール" {
+		t.Errorf("waypoint names = %q, %q", g.Wpt[0].Name, g.Wpt[1].Name)
+	}
+	wantDesc := "距離: 0.11 km、所要時間: 1分0秒、獲得標高: 15 m、移動手段: 徒歩"
+	if g.Metadata.Desc != wantDesc {
+		t.Errorf("Metadata.Desc = %q, want %q", g.Metadata.Desc, wantDesc)
+	}
+	if g.Metadata.Keywords != "徒歩" {
+		t.Errorf("Metadata.Keywords = %q, want 徒歩", g.Metadata.Keywords)
+	}
+	if g.Trk[0].Type != "walking" {
+		t.Errorf("Trk.Type = %q, want walking regardless of language", g.Trk[0].Type)
+	}
+}
+
 func TestGPXConverter_Convert_AccuracyFields(t *testing.T) {
 	points := []models.Point{
 		{Tm: 1609459200, Lo: 139.7671, La: 35.6812, Al: "10.5", Ha: 5.0, Va: 3.0},
@@ -270,6 +355,100 @@ func TestGPXConverter_Convert_DescriptionFromDp(t *testing.T) {
 	}
 }
 
+func TestGPXConverter_Convert_Stops(t *testing.T) {
+	// Ten minutes at the station, then two at a crossing.
+	points := []models.Point{
+		{Tm: 1609459200, Lo: 139.7671, La: 35.6800},
+		{Tm: 1609459260, Lo: 139.7671, La: 35.6812, Dp: "Station"},
+		{Tm: 1609459560, Lo: 139.7672, La: 35.6813},
+		{Tm: 1609459860, Lo: 139.7671, La: 35.6812},
+		{Tm: 1609459920, Lo: 139.7671, La: 35.6830},
+		{Tm: 1609460040, Lo: 139.7671, La: 35.6830},
+		{Tm: 1609460100, Lo: 139.7671, La: 35.6850},
+	}
+
+	config := DefaultConfig()
+	config.Stops = &stats.StopOptions{MinDuration: 2 * time.Minute}
+	config.Location = time.FixedZone("", 9*3600)
+	g, err := New(config).Convert(points, "")
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	if len(g.Wpt) != 4 {
+		t.Fatalf("Wpt count = %d, want Start, 2 stops and Goal", len(g.Wpt))
+	}
+	if g.Wpt[0].Name != "Start" || g.Wpt[3].Name != "Goal" {
+		t.Errorf("first and last waypoints = %q, %q, want Start, Goal", g.Wpt[0].Name, g.Wpt[3].Name)
+	}
+	station := g.Wpt[1]
+	if station.Name != "Station" || !station.Time.Equal(time.Unix(1609459260, 0)) {
+		t.Errorf("stop = %q at %v, want Station at arrival", station.Name, station.Time)
+	}
+	if want := "Arrival: 2021-01-01 09:01, Departure: 2021-01-01 09:11, Stay: 10m0s"; station.Desc != want {
+		t.Errorf("stop Desc = %q, want %q", station.Desc, want)
+	}
+	if g.Wpt[2].Name != "Stop 2" {
+		t.Errorf("unnamed stop = %q, want Stop 2", g.Wpt[2].Name)
+	}
+
+	config.Lang = i18n.Japanese
+	g, err = New(config).Convert(points, "")
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if want := "到着: 2021-01-01 09:01、出発: 2021-01-01 09:11、滞在: 10分0秒"; g.Wpt[1].Desc != want {
+		t.Errorf("Japanese stop Desc = %q, want %q", g.Wpt[1].Desc, want)
+	}
+	if g.Wpt[2].Name != "滞在 2" {
+		t.Errorf("Japanese unnamed stop = %q, want 滞在 2", g.Wpt[2].Name)
+	}
+}
+
+func TestGPXConverter_Convert_Laps(t *testing.T) {
+	// 0.01 degree of latitude is about 1.1 km.
+	points := []models.Point{
+		{Tm: 1609459200, Lo: 139, La: 35.00, Al: "10", Ws: 0},
+		{Tm: 1609459500, Lo: 139, La: 35.01, Al: "20", Ws: 900},
+		{Tm: 1609459800, Lo: 139, La: 35.02, Al: "15", Ws: 1800},
+	}
+
+	config := DefaultConfig()
+	if g, err := New(config).Convert(points, ""); err != nil || g.Trk[0].Extensions != nil {
+		t.Fatalf("without Laps: extensions = %v, %v; want none", g.Trk[0].Extensions, err)
+	}
+
+	config.Laps = &stats.LapOptions{}
+	g, err := New(config).Convert(points, "")
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	ext := g.Trk[0].Extensions
+	if ext == nil {
+		t.Fatal("track has no extensions")
+	}
+	var laps struct {
+		XMLName xml.Name
+		Laps    []struct {
+			Distance float64 `xml:"distance"`
+			PaceUnit string  `xml:"paceUnit"`
+			Cadence  float64 `xml:"cadence"`
+		} `xml:"lap"`
+	}
+	if err := xml.Unmarshal(ext.XML, &laps); err != nil {
+		t.Fatalf("Unmarshal %s: %v", ext.XML, err)
+	}
+	if laps.XMLName.Space != LapsNamespace || laps.XMLName.Local != "laps" {
+		t.Errorf("root = %v, want laps in %s", laps.XMLName, LapsNamespace)
+	}
+	if len(laps.Laps) != 3 {
+		t.Fatalf("got %d laps, want 3 for 2.2 km\n%s", len(laps.Laps), ext.XML)
+	}
+	if l := laps.Laps[0]; l.Distance != 1000 || l.PaceUnit != "km" || l.Cadence != 180 {
+		t.Errorf("first lap = %+v, want 1000 m in km at 180 spm", l)
+	}
+}
+
 func TestDefaultConfig(t *testing.T) {
 	config := DefaultConfig()
 
@@ -285,3 +464,16 @@ func TestDefaultConfig(t *testing.T) {
 		t.Error("Default IncludeWaypoint = false, want true")
 	}
 }
+
+func TestGPXConverter_ConvertContext_Cancelled(t *testing.T) {
+	points := []models.Point{
+		{Tm: 1609459200, Lo: 139.7671, La: 35.6812, Al: "10.5"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := New(nil).ConvertContext(ctx, points, "Test"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ConvertContext() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/stats"
+	"github.com/twpayne/go-gpx"
+)
+
+// LapsNamespace is the XML namespace of the laps written into the
+// extensions of a GPX track.
+const LapsNamespace = "https://github.com/chocoby/zweg/xmlschemas/laps/v1"
+
+// lapsXML is the <laps> element of a track extension. Distances are in
+// meters, durations and paces in seconds and cadence in steps per minute.
+type lapsXML struct {
+	XMLName xml.Name `xml:"https://github.com/chocoby/zweg/xmlschemas/laps/v1 laps"`
+	Laps    []lapXML `xml:"lap"`
+}
+
+type lapXML struct {
+	Start         string  `xml:"start"`
+	End           string  `xml:"end"`
+	Duration      float64 `xml:"duration"`
+	Distance      float64 `xml:"distance"`
+	Pace          float64 `xml:"pace"`
+	PaceUnit      string  `xml:"paceUnit"`
+	ElevationGain float64 `xml:"elevationGain"`
+	ElevationLoss float64 `xml:"elevationLoss"`
+	Cadence       float64 `xml:"cadence,omitempty"`
+}
+
+// lapsExtension returns the track extension listing laps, or nil when
+// there are none.
+func lapsExtension(laps []stats.Lap) (*gpx.ExtensionsType, error) {
+	if len(laps) == 0 {
+		return nil, nil
+	}
+	var doc lapsXML
+	for _, l := range laps {
+		doc.Laps = append(doc.Laps, lapXML{
+			Start:         l.Start.Format(time.RFC3339Nano),
+			End:           l.End.Format(time.RFC3339Nano),
+			Duration:      geo.Round(l.Duration.Seconds(), 3),
+			Distance:      geo.Round(l.Distance, geo.EleDecimals),
+			Pace:          geo.Round(l.Pace().Seconds(), 1),
+			PaceUnit:      string(l.Unit),
+			ElevationGain: geo.Round(l.ElevationGain, geo.EleDecimals),
+			ElevationLoss: geo.Round(l.ElevationLoss, geo.EleDecimals),
+			Cadence:       geo.Round(l.Cadence, 1),
+		})
+	}
+	data, err := xml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode laps: %w", err)
+	}
+	return &gpx.ExtensionsType{XML: data}, nil
+}
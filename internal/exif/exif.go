@@ -0,0 +1,442 @@
+// Package exif reads the capture time of JPEG photos and writes GPS tags
+// into them.
+//
+// It understands just enough of EXIF to do that without disturbing the
+// rest of the metadata. Nothing already in the file is moved: the new GPS
+// IFD and a copy of IFD0 pointing at it are appended to the end of the
+// TIFF data, and the header is updated to use the new IFD0. Offsets inside
+// maker notes, which other tools cannot rewrite, therefore stay valid. A
+// block appended by an earlier run is replaced rather than kept, so
+// tagging a photo again does not grow it.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoExif is returned when a JPEG has no EXIF segment.
+var ErrNoExif = errors.New("no EXIF data")
+
+// ErrNoDateTime is returned when the EXIF data has no DateTimeOriginal.
+var ErrNoDateTime = errors.New("no DateTimeOriginal")
+
+const (
+	markerSOI  = 0xd8
+	markerEOI  = 0xd9
+	markerSOS  = 0xda
+	markerAPP1 = 0xe1
+
+	// maxSegment is the largest JPEG segment payload, including its length.
+	maxSegment = 0xffff
+)
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// Tags used by this package.
+const (
+	tagSubIFDs            = 0x014a
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+
+	tagGPSVersionID       = 0x0000
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+	tagGPSAltitudeRef     = 0x0005
+	tagGPSAltitude        = 0x0006
+	tagGPSTimeStamp       = 0x0007
+	tagGPSTrackRef        = 0x000e
+	tagGPSTrack           = 0x000f
+	tagGPSImgDirectionRef = 0x0010
+	tagGPSImgDirection    = 0x0011
+	tagGPSDateStamp       = 0x001d
+)
+
+// TIFF field types.
+const (
+	typeByte     = 1
+	typeASCII    = 2
+	typeShort    = 3
+	typeLong     = 4
+	typeRational = 5
+)
+
+var typeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// entry is one IFD entry. value holds the raw 4-byte value-or-offset field.
+type entry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value [4]byte
+}
+
+func (e entry) size() uint32 { return typeSizes[e.typ] * e.count }
+
+// File is a parsed JPEG with an EXIF segment.
+type File struct {
+	data []byte
+	// start and end delimit the APP1 segment, marker included.
+	start, end int
+	tiff       []byte
+	order      byteOrder
+}
+
+// byteOrder is the byte order of the TIFF data, for reading and appending.
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// Parse locates the EXIF segment of a JPEG.
+func Parse(data []byte) (*File, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != markerSOI {
+		return nil, fmt.Errorf("not a JPEG file")
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return nil, fmt.Errorf("corrupt JPEG: expected marker at offset %d", i)
+		}
+		marker := data[i+1]
+		if marker == 0xff { // fill byte
+			i++
+			continue
+		}
+		if marker == markerSOS || marker == markerEOI {
+			break
+		}
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			i += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, fmt.Errorf("corrupt JPEG: segment at offset %d overruns file", i)
+		}
+		payload := data[i+4 : end]
+		if marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader) {
+			f := &File{data: data, start: i, end: end, tiff: payload[len(exifHeader):]}
+			if err := f.readHeader(); err != nil {
+				return nil, err
+			}
+			return f, nil
+		}
+		i = end
+	}
+	return nil, ErrNoExif
+}
+
+func (f *File) readHeader() error {
+	if len(f.tiff) < 8 {
+		return fmt.Errorf("corrupt EXIF: short TIFF header")
+	}
+	switch string(f.tiff[:2]) {
+	case "II":
+		f.order = binary.LittleEndian
+	case "MM":
+		f.order = binary.BigEndian
+	default:
+		return fmt.Errorf("corrupt EXIF: unknown byte order %q", f.tiff[:2])
+	}
+	if f.order.Uint16(f.tiff[2:]) != 42 {
+		return fmt.Errorf("corrupt EXIF: bad TIFF magic")
+	}
+	return nil
+}
+
+// readIFD returns the entries at offset and the offset of the next IFD.
+func (f *File) readIFD(offset uint32) ([]entry, uint32, error) {
+	if uint64(offset)+2 > uint64(len(f.tiff)) {
+		return nil, 0, fmt.Errorf("corrupt EXIF: IFD offset %d out of range", offset)
+	}
+	n := uint32(f.order.Uint16(f.tiff[offset:]))
+	end := uint64(offset) + 2 + uint64(n)*12 + 4
+	if end > uint64(len(f.tiff)) {
+		return nil, 0, fmt.Errorf("corrupt EXIF: IFD at %d overruns segment", offset)
+	}
+
+	entries := make([]entry, n)
+	for i := range entries {
+		b := f.tiff[offset+2+uint32(i)*12:]
+		entries[i] = entry{tag: f.order.Uint16(b), typ: f.order.Uint16(b[2:]), count: f.order.Uint32(b[4:])}
+		copy(entries[i].value[:], b[8:12])
+	}
+	return entries, f.order.Uint32(f.tiff[end-4:]), nil
+}
+
+// bytesOf returns the value bytes of e.
+func (f *File) bytesOf(e entry) ([]byte, error) {
+	size := e.size()
+	if size <= 4 {
+		return e.value[:size], nil
+	}
+	offset := f.order.Uint32(e.value[:])
+	if uint64(offset)+uint64(size) > uint64(len(f.tiff)) {
+		return nil, fmt.Errorf("corrupt EXIF: tag 0x%04x value out of range", e.tag)
+	}
+	return f.tiff[offset : offset+size], nil
+}
+
+func (f *File) ascii(e entry) (string, error) {
+	if e.typ != typeASCII {
+		return "", fmt.Errorf("tag 0x%04x is not ASCII", e.tag)
+	}
+	b, err := f.bytesOf(e)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\x00 "), nil
+}
+
+func find(entries []entry, tag uint16) (entry, bool) {
+	for _, e := range entries {
+		if e.tag == tag {
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+// DateTimeOriginal returns the capture time as recorded by the camera,
+// "YYYY:MM:DD HH:MM:SS" in camera local time, and the OffsetTimeOriginal
+// ("+09:00") when the camera recorded one.
+func (f *File) DateTimeOriginal() (value, offset string, err error) {
+	ifd0, _, err := f.readIFD(f.order.Uint32(f.tiff[4:]))
+	if err != nil {
+		return "", "", err
+	}
+	ptr, ok := find(ifd0, tagExifIFD)
+	if !ok {
+		return "", "", ErrNoDateTime
+	}
+	exifIFD, _, err := f.readIFD(f.order.Uint32(ptr.value[:]))
+	if err != nil {
+		return "", "", err
+	}
+
+	e, ok := find(exifIFD, tagDateTimeOriginal)
+	if !ok {
+		return "", "", ErrNoDateTime
+	}
+	if value, err = f.ascii(e); err != nil {
+		return "", "", err
+	}
+	if e, ok := find(exifIFD, tagOffsetTimeOriginal); ok {
+		offset, _ = f.ascii(e)
+	}
+	return value, offset, nil
+}
+
+// ParseDateTime parses an EXIF date-time in loc.
+func ParseDateTime(value string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid EXIF date-time %q", value)
+	}
+	return t, nil
+}
+
+// GPS is the position written by SetGPS. Altitude, Track and ImgDirection
+// are written only when their Has flag is set.
+type GPS struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64 // meters above sea level
+	// HasAltitude reports whether Altitude is known; 0 is sea level.
+	HasAltitude bool
+	// Track is the direction of movement in degrees from true north.
+	Track    float64
+	HasTrack bool
+	// ImgDirection is the direction the device faced in degrees from true north.
+	ImgDirection    float64
+	HasImgDirection bool
+	Time            time.Time
+}
+
+// SetGPS replaces the GPS IFD with g.
+func (f *File) SetGPS(g GPS) error {
+	ifd0Offset := f.order.Uint32(f.tiff[4:])
+	ifd0, next, err := f.readIFD(ifd0Offset)
+	if err != nil {
+		return err
+	}
+
+	b := &ifdBuilder{order: f.order}
+	b.tiff = append(b.tiff, f.tiff[:f.appendedBlock(ifd0Offset, ifd0, next)]...)
+	b.align()
+
+	gpsOffset := b.writeIFD(b.gpsFields(g))
+
+	ifd0 = withPointer(ifd0, tagGPSIFD, gpsOffset, f.order)
+	newIFD0 := b.writeIFDRaw(ifd0, next)
+	f.order.PutUint32(b.tiff[4:], newIFD0)
+
+	segLen := 2 + len(exifHeader) + len(b.tiff)
+	if segLen > maxSegment {
+		return fmt.Errorf("EXIF data too large (%d bytes)", segLen)
+	}
+
+	seg := make([]byte, 0, 2+segLen)
+	seg = append(seg, 0xff, markerAPP1, byte(segLen>>8), byte(segLen))
+	seg = append(seg, exifHeader...)
+	seg = append(seg, b.tiff...)
+
+	data := make([]byte, 0, len(f.data)-(f.end-f.start)+len(seg))
+	data = append(data, f.data[:f.start]...)
+	data = append(data, seg...)
+	data = append(data, f.data[f.end:]...)
+
+	f.data = data
+	f.end = f.start + len(seg)
+	f.tiff = data[f.start+4+len(exifHeader) : f.end]
+	return nil
+}
+
+// appendedBlock returns where the block appended by an earlier SetGPS
+// starts, or the length of the TIFF data when it does not end with one.
+// SetGPS writes the GPS IFD, its values in entry order and then the copy
+// of IFD0, so the block is recognised by that layout ending the data, with
+// nothing else in IFD0 pointing into it.
+func (f *File) appendedBlock(ifd0Offset uint32, ifd0 []entry, next uint32) uint32 {
+	end := uint32(len(f.tiff))
+	if uint64(ifd0Offset)+2+uint64(len(ifd0))*12+4 != uint64(end) {
+		return end
+	}
+	ptr, ok := find(ifd0, tagGPSIFD)
+	if !ok {
+		return end
+	}
+	start := f.order.Uint32(ptr.value[:])
+	if start < 8 || start >= ifd0Offset || next >= start {
+		return end
+	}
+	gps, _, err := f.readIFD(start)
+	if err != nil {
+		return end
+	}
+	size := uint64(2 + len(gps)*12 + 4)
+	for _, e := range gps {
+		if n := uint64(typeSizes[e.typ]) * uint64(e.count); n > 4 {
+			if uint64(f.order.Uint32(e.value[:])) != uint64(start)+size {
+				return end
+			}
+			size += n + n%2
+		}
+	}
+	if uint64(start)+size != uint64(ifd0Offset) {
+		return end
+	}
+
+	for _, e := range ifd0 {
+		pointer := e.tag == tagExifIFD || e.tag == tagSubIFDs || uint64(typeSizes[e.typ])*uint64(e.count) > 4
+		if e.tag != tagGPSIFD && pointer && f.order.Uint32(e.value[:]) >= start {
+			return end
+		}
+	}
+	return start
+}
+
+// Bytes returns the JPEG with any changes applied.
+func (f *File) Bytes() []byte { return f.data }
+
+// withPointer returns entries with tag set to a LONG offset, keeping the
+// entries sorted by tag as TIFF requires.
+func withPointer(entries []entry, tag uint16, offset uint32, order byteOrder) []entry {
+	e := entry{tag: tag, typ: typeLong, count: 1}
+	order.PutUint32(e.value[:], offset)
+
+	out := make([]entry, 0, len(entries)+1)
+	for _, old := range entries {
+		if old.tag != tag {
+			out = append(out, old)
+		}
+	}
+	out = append(out, e)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].tag < out[j].tag })
+	return out
+}
+
+// GPS returns the position recorded in the GPS IFD. The bool is false when
+// the file has no GPS position.
+func (f *File) GPS() (GPS, bool, error) {
+	ifd0, _, err := f.readIFD(f.order.Uint32(f.tiff[4:]))
+	if err != nil {
+		return GPS{}, false, err
+	}
+	ptr, ok := find(ifd0, tagGPSIFD)
+	if !ok {
+		return GPS{}, false, nil
+	}
+	entries, _, err := f.readIFD(f.order.Uint32(ptr.value[:]))
+	if err != nil {
+		return GPS{}, false, err
+	}
+
+	var g GPS
+	coord := func(tag, refTag uint16, neg string) (float64, bool) {
+		v, ok := f.rationals(entries, tag)
+		if !ok || len(v) != 3 {
+			return 0, false
+		}
+		deg := v[0] + v[1]/60 + v[2]/3600
+		if e, ok := find(entries, refTag); ok {
+			if ref, _ := f.ascii(e); ref == neg {
+				deg = -deg
+			}
+		}
+		return deg, true
+	}
+
+	var okLat, okLon bool
+	g.Latitude, okLat = coord(tagGPSLatitude, tagGPSLatitudeRef, "S")
+	g.Longitude, okLon = coord(tagGPSLongitude, tagGPSLongitudeRef, "W")
+	if !okLat || !okLon {
+		return GPS{}, false, nil
+	}
+	if v, ok := f.rationals(entries, tagGPSAltitude); ok && len(v) == 1 {
+		g.Altitude, g.HasAltitude = v[0], true
+		if e, ok := find(entries, tagGPSAltitudeRef); ok && e.value[0] == 1 {
+			g.Altitude = -g.Altitude
+		}
+	}
+	if v, ok := f.rationals(entries, tagGPSTrack); ok && len(v) == 1 {
+		g.Track, g.HasTrack = v[0], true
+	}
+	if v, ok := f.rationals(entries, tagGPSImgDirection); ok && len(v) == 1 {
+		g.ImgDirection, g.HasImgDirection = v[0], true
+	}
+	return g, true, nil
+}
+
+// rationals returns the RATIONAL values of tag as floats.
+func (f *File) rationals(entries []entry, tag uint16) ([]float64, bool) {
+	e, ok := find(entries, tag)
+	if !ok || e.typ != typeRational {
+		return nil, false
+	}
+	b, err := f.bytesOf(e)
+	if err != nil {
+		return nil, false
+	}
+	out := make([]float64, e.count)
+	for i := range out {
+		num, den := f.order.Uint32(b[i*8:]), f.order.Uint32(b[i*8+4:])
+		if den == 0 {
+			return nil, false
+		}
+		out[i] = float64(num) / float64(den)
+	}
+	return out, true
+}
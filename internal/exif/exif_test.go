@@ -0,0 +1,228 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"math"
+	"testing"
+	"time"
+)
+
+// makerNote stands in for vendor data with absolute offsets, which must
+// not move when GPS tags are written.
+var makerNote = []byte("MAKERNOTE-OFFSETS-MUST-STAY-PUT")
+
+// testJPEG returns a small JPEG whose EXIF records dateTime and, when not
+// empty, offset.
+func testJPEG(t *testing.T, order byteOrder, dateTime, offset string) []byte {
+	t.Helper()
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+
+	b := &ifdBuilder{order: order}
+	if order == binary.LittleEndian {
+		b.tiff = append(b.tiff, "II"...)
+	} else {
+		b.tiff = append(b.tiff, "MM"...)
+	}
+	b.tiff = order.AppendUint16(b.tiff, 42)
+	b.tiff = order.AppendUint32(b.tiff, 0) // IFD0 offset, patched below
+
+	exifFields := []field{asciiField(tagDateTimeOriginal, dateTime)}
+	if offset != "" {
+		exifFields = append(exifFields, asciiField(tagOffsetTimeOriginal, offset))
+	}
+	exifFields = append(exifFields, field{tag: 0x927c, typ: 7, count: uint32(len(makerNote)), data: makerNote})
+	exifIFD := b.writeIFD(exifFields)
+
+	var ptr [4]byte
+	order.PutUint32(ptr[:], exifIFD)
+	ifd0 := b.writeIFD([]field{
+		asciiField(0x010f, "zweg"),
+		{tag: tagExifIFD, typ: typeLong, count: 1, data: ptr[:]},
+	})
+	order.PutUint32(b.tiff[4:], ifd0)
+
+	segLen := 2 + len(exifHeader) + len(b.tiff)
+	var out bytes.Buffer
+	out.Write(img.Bytes()[:2])
+	out.Write([]byte{0xff, markerAPP1, byte(segLen >> 8), byte(segLen)})
+	out.Write(exifHeader)
+	out.Write(b.tiff)
+	out.Write(img.Bytes()[2:])
+	return out.Bytes()
+}
+
+func TestDateTimeOriginal(t *testing.T) {
+	for _, order := range []byteOrder{binary.LittleEndian, binary.BigEndian} {
+		f, err := Parse(testJPEG(t, order, "2024:05:01 09:30:15", "+09:00"))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		value, offset, err := f.DateTimeOriginal()
+		if err != nil {
+			t.Fatalf("DateTimeOriginal: %v", err)
+		}
+		if value != "2024:05:01 09:30:15" || offset != "+09:00" {
+			t.Errorf("DateTimeOriginal() = %q, %q, want 2024:05:01 09:30:15, +09:00", value, offset)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	if _, err := Parse([]byte("not a jpeg")); err == nil {
+		t.Error("Parse(non-JPEG) error = nil, want error")
+	}
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+	if _, err := Parse(img.Bytes()); !errors.Is(err, ErrNoExif) {
+		t.Errorf("Parse(no EXIF) error = %v, want ErrNoExif", err)
+	}
+}
+
+func TestSetGPS(t *testing.T) {
+	want := GPS{
+		Latitude:        35.681236,
+		Longitude:       -139.767125,
+		Altitude:        -12.5,
+		HasAltitude:     true,
+		Track:           275.25,
+		HasTrack:        true,
+		ImgDirection:    90,
+		HasImgDirection: true,
+		Time:            time.Date(2024, 5, 1, 0, 30, 15, 0, time.UTC),
+	}
+
+	for _, order := range []byteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := testJPEG(t, order, "2024:05:01 09:30:15", "")
+		f, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		makerAt := bytes.Index(f.tiff, makerNote)
+
+		// Writing twice replaces the first position.
+		if err := f.SetGPS(GPS{Latitude: 1, Longitude: 1}); err != nil {
+			t.Fatalf("SetGPS: %v", err)
+		}
+		if err := f.SetGPS(want); err != nil {
+			t.Fatalf("SetGPS: %v", err)
+		}
+
+		// Reparse the written bytes, as a reader would.
+		f, err = Parse(f.Bytes())
+		if err != nil {
+			t.Fatalf("Parse(written): %v", err)
+		}
+		got, ok, err := f.GPS()
+		if err != nil || !ok {
+			t.Fatalf("GPS() = %v, %v, want a position", ok, err)
+		}
+		if math.Abs(got.Latitude-want.Latitude) > 1e-7 || math.Abs(got.Longitude-want.Longitude) > 1e-7 {
+			t.Errorf("position = (%v, %v), want (%v, %v)", got.Latitude, got.Longitude, want.Latitude, want.Longitude)
+		}
+		if got.Altitude != want.Altitude || !got.HasAltitude || got.Track != want.Track || !got.HasTrack || got.ImgDirection != want.ImgDirection || !got.HasImgDirection {
+			t.Errorf("GPS() = %+v, want %+v", got, want)
+		}
+
+		if value, _, err := f.DateTimeOriginal(); err != nil || value != "2024:05:01 09:30:15" {
+			t.Errorf("DateTimeOriginal after SetGPS = %q, %v", value, err)
+		}
+		if at := bytes.Index(f.tiff, makerNote); at != makerAt {
+			t.Errorf("maker note moved from %d to %d", makerAt, at)
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(f.Bytes())); err != nil {
+			t.Errorf("written JPEG does not decode: %v", err)
+		}
+
+		// Tagging again replaces the appended block instead of adding one.
+		size := len(f.Bytes())
+		for range 3 {
+			if err := f.SetGPS(want); err != nil {
+				t.Fatalf("SetGPS: %v", err)
+			}
+		}
+		if len(f.Bytes()) != size {
+			t.Errorf("size after tagging again = %d, want %d", len(f.Bytes()), size)
+		}
+		if got, ok, err := f.GPS(); err != nil || !ok || got.Altitude != want.Altitude {
+			t.Errorf("GPS() after tagging again = %+v, %v, %v", got, ok, err)
+		}
+		if value, _, err := f.DateTimeOriginal(); err != nil || value != "2024:05:01 09:30:15" {
+			t.Errorf("DateTimeOriginal after tagging again = %q, %v", value, err)
+		}
+	}
+}
+
+func TestSetGPS_Altitude(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   GPS
+	}{
+		{"sea level", GPS{Latitude: 35, Longitude: 139, HasAltitude: true}},
+		{"unknown", GPS{Latitude: 35, Longitude: 139}},
+	} {
+		f, err := Parse(testJPEG(t, binary.BigEndian, "2024:05:01 09:30:15", ""))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		if err := f.SetGPS(tt.in); err != nil {
+			t.Fatalf("SetGPS: %v", err)
+		}
+		got, ok, err := f.GPS()
+		if err != nil || !ok {
+			t.Fatalf("GPS() = %v, %v", ok, err)
+		}
+		if got.HasAltitude != tt.in.HasAltitude || got.Altitude != 0 {
+			t.Errorf("%s: altitude = %v (known %v), want %v (known %v)", tt.name, got.Altitude, got.HasAltitude, 0, tt.in.HasAltitude)
+		}
+	}
+}
+
+func TestSetGPS_Direction(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   GPS
+	}{
+		{"due north", GPS{Latitude: 35, Longitude: 139, HasTrack: true, HasImgDirection: true}},
+		{"unknown", GPS{Latitude: 35, Longitude: 139}},
+	} {
+		f, err := Parse(testJPEG(t, binary.BigEndian, "2024:05:01 09:30:15", ""))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		if err := f.SetGPS(tt.in); err != nil {
+			t.Fatalf("SetGPS: %v", err)
+		}
+		got, ok, err := f.GPS()
+		if err != nil || !ok {
+			t.Fatalf("GPS() = %v, %v", ok, err)
+		}
+		if got.HasTrack != tt.in.HasTrack || got.HasImgDirection != tt.in.HasImgDirection || got.Track != 0 || got.ImgDirection != 0 {
+			t.Errorf("%s: track %v (known %v), direction %v (known %v), want 0 (known %v)",
+				tt.name, got.Track, got.HasTrack, got.ImgDirection, got.HasImgDirection, tt.in.HasTrack)
+		}
+	}
+}
+
+func TestDMS(t *testing.T) {
+	got := dms(-35.5)
+	want := []rational{{35, 1}, {30, 1}, {0, 10000}}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("dms(-35.5) = %v, want %v", got, want)
+		}
+	}
+	if d := direction(-90); d != (rational{27000, 100}) {
+		t.Errorf("direction(-90) = %v, want 270", d)
+	}
+}
@@ -0,0 +1,156 @@
+package exif
+
+import "math"
+
+// field is an IFD entry with its value still to be laid out.
+type field struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+// ifdBuilder appends IFDs to a copy of the TIFF data.
+type ifdBuilder struct {
+	order byteOrder
+	tiff  []byte
+}
+
+// align pads to a word boundary, where TIFF requires IFDs and values to start.
+func (b *ifdBuilder) align() {
+	if len(b.tiff)%2 != 0 {
+		b.tiff = append(b.tiff, 0)
+	}
+}
+
+// writeIFD appends an IFD with its out-of-line values directly after it
+// and returns its offset. fields must be sorted by tag.
+func (b *ifdBuilder) writeIFD(fields []field) uint32 {
+	offset := uint32(len(b.tiff))
+	valueOffset := offset + 2 + uint32(len(fields))*12 + 4
+
+	entries := make([]entry, len(fields))
+	var values []byte
+	for i, fl := range fields {
+		e := entry{tag: fl.tag, typ: fl.typ, count: fl.count}
+		if len(fl.data) <= 4 {
+			copy(e.value[:], fl.data)
+		} else {
+			b.order.PutUint32(e.value[:], valueOffset+uint32(len(values)))
+			values = append(values, fl.data...)
+			if len(values)%2 != 0 {
+				values = append(values, 0)
+			}
+		}
+		entries[i] = e
+	}
+
+	b.writeIFDRaw(entries, 0)
+	b.tiff = append(b.tiff, values...)
+	return offset
+}
+
+// writeIFDRaw appends entries as an IFD followed by the next-IFD offset.
+func (b *ifdBuilder) writeIFDRaw(entries []entry, next uint32) uint32 {
+	offset := uint32(len(b.tiff))
+	b.tiff = b.order.AppendUint16(b.tiff, uint16(len(entries)))
+	for _, e := range entries {
+		b.tiff = b.order.AppendUint16(b.tiff, e.tag)
+		b.tiff = b.order.AppendUint16(b.tiff, e.typ)
+		b.tiff = b.order.AppendUint32(b.tiff, e.count)
+		b.tiff = append(b.tiff, e.value[:]...)
+	}
+	b.tiff = b.order.AppendUint32(b.tiff, next)
+	return offset
+}
+
+// gpsFields returns the GPS IFD fields for g, sorted by tag.
+func (b *ifdBuilder) gpsFields(g GPS) []field {
+	fields := []field{
+		{tag: tagGPSVersionID, typ: typeByte, count: 4, data: []byte{2, 3, 0, 0}},
+		asciiField(tagGPSLatitudeRef, hemisphere(g.Latitude, "N", "S")),
+		b.rationalField(tagGPSLatitude, dms(g.Latitude)...),
+		asciiField(tagGPSLongitudeRef, hemisphere(g.Longitude, "E", "W")),
+		b.rationalField(tagGPSLongitude, dms(g.Longitude)...),
+	}
+
+	if g.HasAltitude {
+		ref := byte(0)
+		if g.Altitude < 0 {
+			ref = 1
+		}
+		fields = append(fields,
+			field{tag: tagGPSAltitudeRef, typ: typeByte, count: 1, data: []byte{ref}},
+			b.rationalField(tagGPSAltitude, rational{uint32(math.Round(math.Abs(g.Altitude) * 100)), 100}),
+		)
+	}
+
+	if !g.Time.IsZero() {
+		utc := g.Time.UTC()
+		fields = append(fields, b.rationalField(tagGPSTimeStamp,
+			rational{uint32(utc.Hour()), 1},
+			rational{uint32(utc.Minute()), 1},
+			rational{uint32(utc.Second()), 1},
+		))
+	}
+
+	if g.HasTrack {
+		fields = append(fields,
+			asciiField(tagGPSTrackRef, "T"),
+			b.rationalField(tagGPSTrack, direction(g.Track)),
+		)
+	}
+	if g.HasImgDirection {
+		fields = append(fields,
+			asciiField(tagGPSImgDirectionRef, "T"),
+			b.rationalField(tagGPSImgDirection, direction(g.ImgDirection)),
+		)
+	}
+
+	if !g.Time.IsZero() {
+		fields = append(fields, asciiField(tagGPSDateStamp, g.Time.UTC().Format("2006:01:02")))
+	}
+	return fields
+}
+
+type rational struct {
+	num, den uint32
+}
+
+func (b *ifdBuilder) rationalField(tag uint16, values ...rational) field {
+	data := make([]byte, 0, 8*len(values))
+	for _, r := range values {
+		data = b.order.AppendUint32(data, r.num)
+		data = b.order.AppendUint32(data, r.den)
+	}
+	return field{tag: tag, typ: typeRational, count: uint32(len(values)), data: data}
+}
+
+// asciiField returns a NUL-terminated ASCII field.
+func asciiField(tag uint16, s string) field {
+	data := append([]byte(s), 0)
+	return field{tag: tag, typ: typeASCII, count: uint32(len(data)), data: data}
+}
+
+func hemisphere(v float64, pos, neg string) string {
+	if v < 0 {
+		return neg
+	}
+	return pos
+}
+
+// dms splits an absolute coordinate into degrees, minutes and seconds,
+// keeping seconds to 1/10000 (about 3 mm).
+func dms(v float64) []rational {
+	v = math.Abs(v)
+	deg := math.Floor(v)
+	minutes := math.Floor((v - deg) * 60)
+	seconds := math.Round(((v-deg)*60 - minutes) * 60 * 10000)
+	return []rational{{uint32(deg), 1}, {uint32(minutes), 1}, {uint32(seconds), 10000}}
+}
+
+// direction normalises degrees to [0, 360) with 1/100 degree resolution.
+func direction(v float64) rational {
+	v = math.Mod(math.Mod(v, 360)+360, 360)
+	return rational{uint32(math.Round(v * 100)), 100}
+}
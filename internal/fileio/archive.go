@@ -0,0 +1,148 @@
+package fileio
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Leading bytes of gzip streams and zip archives.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// Decompress returns a reader of the content of r, decompressing it when
+// it is gzip-compressed. Other content is passed through unchanged, so
+// callers need not know how an input was stored.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	if !bytes.Equal(head, gzipMagic) {
+		return br, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, withKind(fmt.Errorf("failed to decompress gzip: %w", err), ErrBadArchive)
+	}
+	return zr, nil
+}
+
+// Gzip wraps encode so that its output is gzip-compressed. The header
+// carries no name or time, so the same content compresses to the same
+// bytes.
+func Gzip(encode func(io.Writer) error) func(io.Writer) error {
+	return func(w io.Writer) error {
+		zw := gzip.NewWriter(w)
+		if err := encode(zw); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to compress output: %w", err)
+		}
+		return nil
+	}
+}
+
+// TrimCompressionExt removes a trailing .gz from name, so that
+// "20240101.json.gz" is treated like "20240101.json".
+func TrimCompressionExt(name string) string {
+	if strings.EqualFold(path.Ext(name), ".gz") {
+		return name[:len(name)-len(".gz")]
+	}
+	return name
+}
+
+// IsZip reports whether the file at filename is a zip archive. It is
+// false for a file that cannot be read, leaving the error to the reader.
+func IsZip(filename string) bool {
+	file, err := Open(filename)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = file.Close() }()
+
+	head := make([]byte, len(zipMagic))
+	if _, err := io.ReadFull(file, head); err != nil {
+		return false
+	}
+	return bytes.Equal(head, zipMagic)
+}
+
+// Archive is an open zip archive of logs, such as a ZweiteGPS export
+// bundle.
+type Archive struct {
+	// Entries are the logs in the archive, in archive order.
+	Entries []*ArchiveEntry
+
+	zr *zip.ReadCloser
+}
+
+// ArchiveEntry is one log inside an Archive.
+type ArchiveEntry struct {
+	// Name is the slash-separated path of the log inside the archive.
+	Name string
+
+	file *zip.File
+}
+
+// OpenArchive opens the zip archive at filename and lists the .json and
+// .json.gz files in it. Directories and the resource forks macOS adds
+// under __MACOSX are skipped. An archive without any log is ErrEmptyLog.
+func OpenArchive(filename string) (*Archive, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, withKind(fmt.Errorf("failed to open zip archive %q: %w", filename, err), ErrBadArchive)
+	}
+
+	a := &Archive{zr: zr}
+	for _, f := range zr.File {
+		if isLogEntry(f) {
+			a.Entries = append(a.Entries, &ArchiveEntry{Name: f.Name, file: f})
+		}
+	}
+	if len(a.Entries) == 0 {
+		_ = zr.Close()
+		return nil, withKind(fmt.Errorf("no JSON logs found in zip archive %q", filename), ErrEmptyLog)
+	}
+	return a, nil
+}
+
+// isLogEntry reports whether f is a log rather than a directory or
+// metadata added by the archiver.
+func isLogEntry(f *zip.File) bool {
+	if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") || strings.HasPrefix(path.Base(f.Name), ".") {
+		return false
+	}
+	return strings.EqualFold(path.Ext(TrimCompressionExt(f.Name)), ".json")
+}
+
+// Close closes the archive.
+func (a *Archive) Close() error {
+	return a.zr.Close()
+}
+
+// Open opens the entry for reading, decompressing a .json.gz entry.
+func (e *ArchiveEntry) Open() (io.ReadCloser, error) {
+	rc, err := e.file.Open()
+	if err != nil {
+		return nil, withKind(fmt.Errorf("failed to open %q in zip archive: %w", e.Name, err), ErrBadArchive)
+	}
+	r, err := Decompress(rc)
+	if err != nil {
+		_ = rc.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, rc}, nil
+}
@@ -0,0 +1,119 @@
+package fileio
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ManifestName is the name of the manifest inside a bundle.
+const ManifestName = "manifest.json"
+
+// Manifest lists the files of a bundle.
+type Manifest struct {
+	Files []BundleFile `json:"files"`
+}
+
+// BundleFile is a file in a bundle and its manifest entry.
+type BundleFile struct {
+	// Input names the log the file was converted from.
+	Input string `json:"input"`
+	// Output is the slash-separated name of the file in the bundle.
+	Output string `json:"output"`
+	Points int    `json:"points"`
+	// SHA256 is the hex checksum of the file, set by Bundle.Add.
+	SHA256 string `json:"sha256"`
+}
+
+// Bundle is a zip archive that collects the outputs of a batch run,
+// written with a manifest on Close.
+type Bundle struct {
+	name     string
+	file     *os.File
+	zw       *zip.Writer
+	manifest Manifest
+	names    map[string]bool
+	newest   time.Time // latest modification time of the files
+}
+
+// CreateBundle creates the zip archive filename.
+func CreateBundle(filename string) (*Bundle, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, withKind(fmt.Errorf("failed to create file %q: %w", filename, err), ErrWrite)
+	}
+	return &Bundle{
+		name:     filename,
+		file:     file,
+		zw:       zip.NewWriter(&errWriter{w: file}),
+		manifest: Manifest{Files: []BundleFile{}},
+		names:    make(map[string]bool),
+	}, nil
+}
+
+// Name returns the file name the bundle was created with.
+func (b *Bundle) Name() string {
+	return b.name
+}
+
+// Add encodes a file into the bundle with the modification time
+// modified. The output is buffered first, so that a failed encode leaves
+// no partial entry behind.
+func (b *Bundle) Add(f BundleFile, modified time.Time, encode func(io.Writer) error) error {
+	if f.Output == ManifestName || b.names[f.Output] {
+		return fmt.Errorf("%q is already in the bundle", f.Output)
+	}
+
+	var buf bytes.Buffer
+	if err := encode(&buf); err != nil {
+		return err
+	}
+	f.SHA256 = SHA256(buf.Bytes())
+
+	if err := b.write(f.Output, modified, buf.Bytes()); err != nil {
+		return err
+	}
+	b.names[f.Output] = true
+	if modified.After(b.newest) {
+		b.newest = modified
+	}
+	b.manifest.Files = append(b.manifest.Files, f)
+	return nil
+}
+
+func (b *Bundle) write(name string, modified time.Time, data []byte) error {
+	w, err := b.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err == nil {
+		_, err = w.Write(data)
+	}
+	if err != nil {
+		return withKind(fmt.Errorf("failed to write %q to bundle: %w", name, err), ErrWrite)
+	}
+	return nil
+}
+
+// Close writes the manifest, dated like the newest file, and closes the
+// archive.
+func (b *Bundle) Close() (err error) {
+	defer func() {
+		if closeErr := b.file.Close(); closeErr != nil && err == nil {
+			err = withKind(fmt.Errorf("failed to close file: %w", closeErr), ErrWrite)
+		}
+	}()
+
+	data, err := json.MarshalIndent(b.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := b.write(ManifestName, b.newest, append(data, '\n')); err != nil {
+		return err
+	}
+	if err := b.zw.Close(); err != nil {
+		return withKind(fmt.Errorf("failed to write bundle: %w", err), ErrWrite)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package fileio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumExt is appended to a file name to name its checksum sidecar.
+const ChecksumExt = ".sha256"
+
+// SHA256 returns the hex SHA-256 checksum of data.
+func SHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Checksum returns the hex SHA-256 checksum of the file filename.
+func Checksum(filename string) (string, error) {
+	file, err := Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("failed to read file %q: %w", filename, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteChecksum writes the checksum of filename to its sidecar, in the
+// format of sha256sum so that "sha256sum -c" can check it too.
+func WriteChecksum(filename string) error {
+	sum, err := Checksum(filename)
+	if err != nil {
+		return err
+	}
+	return WriteFile(filename+ChecksumExt, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "%s  %s\n", sum, filepath.Base(filename))
+		return err
+	})
+}
+
+// ReadChecksum returns the checksum recorded in the sidecar of filename.
+// A missing sidecar is ErrInputNotFound.
+func ReadChecksum(filename string) (string, error) {
+	data, err := ReadFile(filename + ChecksumExt)
+	if err != nil {
+		return "", err
+	}
+	sum, _, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+		return "", fmt.Errorf("%s%s does not hold a SHA-256 checksum", filename, ChecksumExt)
+	}
+	return strings.ToLower(sum), nil
+}
@@ -0,0 +1,96 @@
+package fileio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Errors that callers can tell apart with errors.Is, for example to skip a
+// bad input but retry after a failed write. The messages of the errors
+// returned stay as they were; the kind is only added to the chain.
+var (
+	// ErrInputNotFound is returned when an input file does not exist.
+	ErrInputNotFound = errors.New("input file not found")
+	// ErrInvalidJSON is returned for input that is not a JSON array of
+	// ZweiteGPS points.
+	ErrInvalidJSON = errors.New("failed to parse JSON")
+	// ErrEmptyLog is returned for a log without any points, and for a zip
+	// archive without any log.
+	ErrEmptyLog = errors.New("no data points found in JSON")
+	// ErrBadArchive is returned for gzip or zip input that cannot be
+	// decompressed.
+	ErrBadArchive = errors.New("failed to decompress input")
+	// ErrWrite is returned when an output file or its directory cannot be
+	// created or written, for example because the disk is full.
+	ErrWrite = errors.New("failed to write output")
+)
+
+// kindError adds kind to the chain of err without changing its message.
+type kindError struct {
+	err  error
+	kind error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.err, e.kind} }
+
+func withKind(err, kind error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{err: err, kind: kind}
+}
+
+// Open opens an input file. A missing file is reported as
+// ErrInputNotFound.
+func Open(filename string) (*os.File, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		err = fmt.Errorf("failed to open file %q: %w", filename, err)
+		if errors.Is(err, fs.ErrNotExist) {
+			err = withKind(err, ErrInputNotFound)
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// ReadFile reads the whole file filename. A missing file is reported as
+// ErrInputNotFound.
+func ReadFile(filename string) ([]byte, error) {
+	file, err := Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", filename, err)
+	}
+	return data, nil
+}
+
+// MkdirAll creates the directory of an output file and its parents. A
+// failure is reported as ErrWrite.
+func MkdirAll(dir string) error {
+	return withKind(os.MkdirAll(dir, 0755), ErrWrite)
+}
+
+// errWriter remembers the first error of w, so that a failed write can be
+// told apart from an encoder that gave up for another reason.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	n, err := e.w.Write(p)
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
@@ -1,11 +1,17 @@
 package fileio
 
 import (
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/chocoby/zweg/internal/converter"
 	"github.com/chocoby/zweg/internal/models"
@@ -129,6 +135,185 @@ func TestJSONReader_Read(t *testing.T) {
 	})
 }
 
+// gzipped returns data compressed with gzip.
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeZip writes a zip archive with the given entries, in order.
+func writeZip(t *testing.T, path string, entries [][2]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONReader_ReadAll(t *testing.T) {
+	tmpDir := t.TempDir()
+	one := `[{"tm": 1609459200, "lo": 139.7671, "la": 35.6812, "al": "10.5", "sp": "5.0", "ds": "0"}]`
+	two := `[{"tm": 1609459200, "lo": 139.7671, "la": 35.6812}, {"tm": 1609459260, "lo": 139.7672, "la": 35.6813}]`
+
+	t.Run("gzip", func(t *testing.T) {
+		filename := filepath.Join(tmpDir, "log.json.gz")
+		if err := os.WriteFile(filename, gzipped(t, one), 0644); err != nil {
+			t.Fatal(err)
+		}
+		points, err := NewJSONReader().Read(filename)
+		if err != nil {
+			t.Fatalf("Read() unexpected error = %v", err)
+		}
+		if len(points) != 1 || points[0].Al != "10.5" {
+			t.Errorf("Read() = %+v, want the decompressed point", points)
+		}
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		filename := filepath.Join(tmpDir, "export.zip")
+		writeZip(t, filename, [][2]string{
+			{"logs/", ""},
+			{"logs/a.json", one},
+			{"README.txt", "not a log"},
+			{"__MACOSX/logs/._a.json", "resource fork"},
+			{"logs/b.json.gz", string(gzipped(t, two))},
+		})
+
+		logs, err := NewJSONReader().ReadAll(filename)
+		if err != nil {
+			t.Fatalf("ReadAll() unexpected error = %v", err)
+		}
+		if len(logs) != 2 || logs[0].Name != "logs/a.json" || len(logs[0].Points) != 1 ||
+			logs[1].Name != "logs/b.json.gz" || len(logs[1].Points) != 2 {
+			t.Errorf("ReadAll() = %+v, want logs/a.json with 1 point and logs/b.json.gz with 2", logs)
+		}
+		if _, err := NewJSONReader().Read(filename); err == nil || !strings.Contains(err.Error(), "contains 2 logs") {
+			t.Errorf("Read() error = %v, want an error for several logs", err)
+		}
+	})
+
+	t.Run("zip entry error names the entry", func(t *testing.T) {
+		filename := filepath.Join(tmpDir, "bad.zip")
+		writeZip(t, filename, [][2]string{{"a.json", one}, {"b.json", "{"}})
+		_, err := NewJSONReader().ReadAll(filename)
+		if !errors.Is(err, ErrInvalidJSON) || !strings.HasPrefix(err.Error(), "b.json: ") {
+			t.Errorf("ReadAll() error = %v, want ErrInvalidJSON for b.json", err)
+		}
+	})
+}
+
+func TestBundle(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out.zip")
+	b, err := CreateBundle(filename)
+	if err != nil {
+		t.Fatalf("CreateBundle() unexpected error = %v", err)
+	}
+	modified := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	write := func(s string) func(io.Writer) error {
+		return func(w io.Writer) error { _, err := io.WriteString(w, s); return err }
+	}
+
+	if err := b.Add(BundleFile{Input: "a.json", Output: "a.gpx", Points: 3}, modified, write("abc")); err != nil {
+		t.Fatalf("Add() unexpected error = %v", err)
+	}
+	if err := b.Add(BundleFile{Input: "b.json", Output: "b.gpx"}, modified, func(io.Writer) error { return errors.New("encode failed") }); err == nil {
+		t.Error("Add() error = nil, want the encode error")
+	}
+	if err := b.Add(BundleFile{Input: "c.json", Output: "a.gpx"}, modified, write("")); err == nil {
+		t.Error("Add(duplicate) error = nil, want error")
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close() unexpected error = %v", err)
+	}
+
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatalf("open bundle: %v", err)
+	}
+	defer func() { _ = zr.Close() }()
+	if len(zr.File) != 2 || zr.File[0].Name != "a.gpx" || zr.File[1].Name != ManifestName {
+		t.Fatalf("bundle files = %v, want a.gpx and the manifest", zr.File)
+	}
+	rc, err := zr.File[1].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rc.Close() }()
+	var m Manifest
+	if err := json.NewDecoder(rc).Decode(&m); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	want := BundleFile{Input: "a.json", Output: "a.gpx", Points: 3,
+		SHA256: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"}
+	if len(m.Files) != 1 || m.Files[0] != want {
+		t.Errorf("manifest = %+v, want only %+v", m.Files, want)
+	}
+}
+
+func TestGzip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Gzip(func(w io.Writer) error { _, err := io.WriteString(w, "track"); return err })(&buf); err != nil {
+		t.Fatalf("Gzip() unexpected error = %v", err)
+	}
+	r, err := Decompress(&buf)
+	if err != nil {
+		t.Fatalf("Decompress() unexpected error = %v", err)
+	}
+	if data, err := io.ReadAll(r); err != nil || string(data) != "track" {
+		t.Errorf("round trip = %q, %v, want \"track\"", data, err)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out.gpx")
+	if err := os.WriteFile(filename, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadChecksum(filename); !errors.Is(err, ErrInputNotFound) {
+		t.Errorf("ReadChecksum(no sidecar) error = %v, want ErrInputNotFound", err)
+	}
+
+	if err := WriteChecksum(filename); err != nil {
+		t.Fatalf("WriteChecksum() unexpected error = %v", err)
+	}
+	const abc = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	data, err := os.ReadFile(filename + ChecksumExt)
+	if err != nil || string(data) != abc+"  out.gpx\n" {
+		t.Errorf("sidecar = %q, %v, want sha256sum format", data, err)
+	}
+	if sum, err := ReadChecksum(filename); err != nil || sum != abc {
+		t.Errorf("ReadChecksum() = %q, %v, want %q", sum, err, abc)
+	}
+
+	if err := os.WriteFile(filename+ChecksumExt, []byte("not a checksum\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadChecksum(filename); err == nil {
+		t.Error("ReadChecksum(garbage) error = nil, want error")
+	}
+}
+
 func TestGPXWriter_Encode(t *testing.T) {
 	// Create a simple GPX structure for testing
 	points := []models.Point{
@@ -276,3 +461,93 @@ func TestGPXWriter_Write(t *testing.T) {
 		}
 	})
 }
+
+func TestErrorKinds(t *testing.T) {
+	tmpDir := t.TempDir()
+	errEncode := errors.New("encode failed")
+	notDir := filepath.Join(tmpDir, "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantKind error
+	}{
+		{
+			name: "missing input",
+			err: func() error {
+				_, err := NewJSONReader().Read(filepath.Join(tmpDir, "missing.json"))
+				return err
+			}(),
+			wantKind: ErrInputNotFound,
+		},
+		{
+			name: "invalid JSON",
+			err: func() error {
+				_, err := NewJSONReader().Decode(strings.NewReader("{"))
+				return err
+			}(),
+			wantKind: ErrInvalidJSON,
+		},
+		{
+			name: "empty log",
+			err: func() error {
+				_, err := NewJSONReader().Decode(strings.NewReader("[]"))
+				return err
+			}(),
+			wantKind: ErrEmptyLog,
+		},
+		{
+			name: "corrupt gzip",
+			err: func() error {
+				_, err := Decompress(bytes.NewReader([]byte{0x1f, 0x8b, 0}))
+				return err
+			}(),
+			wantKind: ErrBadArchive,
+		},
+		{
+			name: "zip without logs",
+			err: func() error {
+				filename := filepath.Join(tmpDir, "empty.zip")
+				writeZip(t, filename, [][2]string{{"notes.txt", ""}})
+				_, err := OpenArchive(filename)
+				return err
+			}(),
+			wantKind: ErrEmptyLog,
+		},
+		{
+			name: "create in missing directory",
+			err: WriteFile(filepath.Join(tmpDir, "missing", "out.gpx"), func(io.Writer) error {
+				return nil
+			}),
+			wantKind: ErrWrite,
+		},
+		{
+			name:     "directory under a file",
+			err:      MkdirAll(filepath.Join(notDir, "sub")),
+			wantKind: ErrWrite,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.wantKind) {
+				t.Errorf("error = %v, want %v", tt.err, tt.wantKind)
+			}
+		})
+	}
+
+	t.Run("encoder error is not a write error", func(t *testing.T) {
+		err := WriteFile(filepath.Join(tmpDir, "encode.gpx"), func(w io.Writer) error {
+			if _, err := w.Write([]byte("partial")); err != nil {
+				return err
+			}
+			return errEncode
+		})
+		if !errors.Is(err, errEncode) || errors.Is(err, ErrWrite) {
+			t.Errorf("WriteFile() error = %v, want only %v", err, errEncode)
+		}
+	})
+}
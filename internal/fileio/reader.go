@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/chocoby/zweg/internal/models"
 )
@@ -22,15 +21,79 @@ func NewJSONReader() *JSONReader {
 	return &JSONReader{}
 }
 
-// Read reads and parses ZweiteGPS JSON data from a file.
+// Log is one ZweiteGPS log read by ReadAll.
+type Log struct {
+	// Name is the path of the log inside a zip archive, or empty for a
+	// plain file.
+	Name   string
+	Points []models.Point
+}
+
+// Read reads and parses ZweiteGPS JSON data from a file, which may be
+// gzip-compressed. A zip archive must hold exactly one log; use ReadAll
+// for archives with several.
 func (r *JSONReader) Read(filename string) ([]models.Point, error) {
-	file, err := os.Open(filename)
+	logs, err := r.ReadAll(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) > 1 {
+		return nil, fmt.Errorf("zip archive %q contains %d logs, expected one", filename, len(logs))
+	}
+	return logs[0].Points, nil
+}
+
+// ReadAll reads every log in a file: the file itself, decompressed when
+// it is gzip-compressed, or each .json entry of a zip archive in archive
+// order.
+func (r *JSONReader) ReadAll(filename string) ([]Log, error) {
+	if IsZip(filename) {
+		return r.readArchive(filename)
+	}
+
+	file, err := Open(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file %q: %w", filename, err)
+		return nil, err
 	}
 	defer func() { _ = file.Close() }()
 
-	return r.Decode(file)
+	in, err := Decompress(file)
+	if err != nil {
+		return nil, err
+	}
+	points, err := r.Decode(in)
+	if err != nil {
+		return nil, err
+	}
+	return []Log{{Points: points}}, nil
+}
+
+func (r *JSONReader) readArchive(filename string) ([]Log, error) {
+	a, err := OpenArchive(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = a.Close() }()
+
+	logs := make([]Log, 0, len(a.Entries))
+	for _, e := range a.Entries {
+		points, err := r.decodeEntry(e)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name, err)
+		}
+		logs = append(logs, Log{Name: e.Name, Points: points})
+	}
+	return logs, nil
+}
+
+func (r *JSONReader) decodeEntry(e *ArchiveEntry) ([]models.Point, error) {
+	rc, err := e.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	return r.Decode(rc)
 }
 
 // Decode reads and parses ZweiteGPS JSON data from an io.Reader.
@@ -38,11 +101,11 @@ func (r *JSONReader) Decode(reader io.Reader) ([]models.Point, error) {
 	var points []models.Point
 	decoder := json.NewDecoder(reader)
 	if err := decoder.Decode(&points); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJSON, err)
 	}
 
 	if len(points) == 0 {
-		return nil, fmt.Errorf("no data points found in JSON")
+		return nil, ErrEmptyLog
 	}
 
 	return points, nil
@@ -1,10 +1,13 @@
 package fileio
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 
+	"github.com/chocoby/zweg/internal/converter"
 	"github.com/twpayne/go-gpx"
 )
 
@@ -29,18 +32,34 @@ func NewGPXWriter(indent string) *GPXWriter {
 }
 
 // Write writes GPX data to a file.
-func (w *GPXWriter) Write(filename string, g *gpx.GPX) (err error) {
+func (w *GPXWriter) Write(filename string, g *gpx.GPX) error {
+	return WriteFile(filename, func(out io.Writer) error {
+		return w.Encode(out, g)
+	})
+}
+
+// WriteFile creates filename and streams encode's output into it,
+// reporting close errors that would otherwise lose buffered data. Errors
+// in creating, writing or closing the file are reported as ErrWrite.
+func WriteFile(filename string, encode func(io.Writer) error) (err error) {
 	file, err := os.Create(filename)
 	if err != nil {
-		return fmt.Errorf("failed to create file %q: %w", filename, err)
+		return withKind(fmt.Errorf("failed to create file %q: %w", filename, err), ErrWrite)
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil && err == nil {
-			err = fmt.Errorf("failed to close file: %w", closeErr)
+			err = withKind(fmt.Errorf("failed to close file: %w", closeErr), ErrWrite)
 		}
 	}()
 
-	return w.Encode(file, g)
+	w := &errWriter{w: file}
+	if err := encode(w); err != nil {
+		if w.err != nil {
+			return withKind(err, ErrWrite)
+		}
+		return err
+	}
+	return nil
 }
 
 // Encode writes GPX data to an io.Writer.
@@ -50,8 +69,15 @@ func (w *GPXWriter) Encode(writer io.Writer, g *gpx.GPX) error {
 		return fmt.Errorf("failed to write XML declaration: %w", err)
 	}
 
-	if err := g.WriteIndent(writer, "", w.indent); err != nil {
+	var buf bytes.Buffer
+	if err := g.WriteIndent(&buf, "", w.indent); err != nil {
+		return fmt.Errorf("failed to write GPX: %w", err)
+	}
+	if _, err := writer.Write(bytes.ReplaceAll(buf.Bytes(), seaLevelEle, []byte("<ele>0</ele>"))); err != nil {
 		return fmt.Errorf("failed to write GPX: %w", err)
 	}
 	return nil
 }
+
+// seaLevelEle is how go-gpx writes converter.SeaLevel.
+var seaLevelEle = []byte("<ele>" + strconv.FormatFloat(converter.SeaLevel, 'f', -1, 64) + "</ele>")
@@ -0,0 +1,271 @@
+// Package format is the registry of input and output track formats.
+//
+// Each format registers itself from an init function in this package with a
+// name, the file extensions it claims and the functions it supports. Readers
+// produce a track.Track and writers consume one, so any readable format can
+// be converted to any writable one.
+package format
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// sniffLen is how many leading bytes are offered to Sniff functions.
+const sniffLen = 512
+
+// DecodeOptions carries the settings readers may honour. A nil
+// *DecodeOptions is valid and means strict decoding.
+type DecodeOptions struct {
+	// Lenient treats numeric values that do not parse as not recorded
+	// instead of failing, and passes each of them to Malformed, if set.
+	Lenient   bool
+	Malformed func(track.Malformed)
+}
+
+func (o *DecodeOptions) lenient() bool { return o != nil && o.Lenient }
+
+func (o *DecodeOptions) report(malformed []track.Malformed) {
+	if o == nil || o.Malformed == nil {
+		return
+	}
+	for _, m := range malformed {
+		o.Malformed(m)
+	}
+}
+
+// EncodeOptions carries the settings writers may honour.
+type EncodeOptions struct {
+	// TrackName is the resolved name of the track.
+	TrackName string
+
+	// GPX configures formats built on the GPX converter.
+	GPX *converter.Config
+
+	// Indent is the indentation for text formats that support it.
+	Indent string
+
+	// Location is the time zone for human-readable times. Defaults to UTC.
+	Location *time.Location
+}
+
+func (o *EncodeOptions) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+// Format describes one registered format. Decode is nil for output-only
+// formats and Encode is nil for input-only formats.
+type Format struct {
+	Name        string
+	Description string
+
+	// Extensions are the lower-case file extensions, including the leading
+	// dot, that map to this format.
+	Extensions []string
+
+	// MediaType is the MIME type used when the format is served over HTTP.
+	MediaType string
+
+	// Sniff reports whether head, the first bytes of a file, looks like this
+	// format. It is consulted when the extension is unknown.
+	Sniff func(head []byte) bool
+
+	Decode func(r io.Reader, opts *DecodeOptions) (*track.Track, error)
+	Encode func(ctx context.Context, w io.Writer, t *track.Track, opts *EncodeOptions) error
+}
+
+// CanRead reports whether the format can be used as input.
+func (f *Format) CanRead() bool { return f.Decode != nil }
+
+// CanWrite reports whether the format can be used as output.
+func (f *Format) CanWrite() bool { return f.Encode != nil }
+
+// Ext returns the primary file extension of the format.
+func (f *Format) Ext() string {
+	if len(f.Extensions) == 0 {
+		return "." + f.Name
+	}
+	return f.Extensions[0]
+}
+
+// Registry is a set of formats addressable by name and extension.
+type Registry struct {
+	mu      sync.RWMutex
+	formats map[string]*Format
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{formats: make(map[string]*Format)}
+}
+
+// Default is the registry the built-in formats register themselves with.
+var Default = NewRegistry()
+
+// Register adds f to the Default registry. It panics on a duplicate name,
+// since that can only be a programming error.
+func Register(f *Format) {
+	if err := Default.Register(f); err != nil {
+		panic(err)
+	}
+}
+
+// Register adds f to the registry.
+func (r *Registry) Register(f *Format) error {
+	if f.Name == "" {
+		return fmt.Errorf("format name is empty")
+	}
+	if !f.CanRead() && !f.CanWrite() {
+		return fmt.Errorf("format %q can neither read nor write", f.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, dup := r.formats[f.Name]; dup {
+		return fmt.Errorf("format %q already registered", f.Name)
+	}
+	r.formats[f.Name] = f
+	return nil
+}
+
+// Lookup returns the format registered under name.
+func (r *Registry) Lookup(name string) (*Format, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	f, ok := r.formats[strings.ToLower(name)]
+	return f, ok
+}
+
+// Formats returns every registered format sorted by name.
+func (r *Registry) Formats() []*Format {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]*Format, 0, len(r.formats))
+	for _, f := range r.formats {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// byExtension returns the first format, in name order, that claims the
+// extension of path and satisfies want. A trailing .gz is ignored.
+func (r *Registry) byExtension(path string, want func(*Format) bool) *Format {
+	ext := strings.ToLower(filepath.Ext(fileio.TrimCompressionExt(path)))
+	if ext == "" {
+		return nil
+	}
+	for _, f := range r.Formats() {
+		if !want(f) {
+			continue
+		}
+		for _, e := range f.Extensions {
+			if e == ext {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// Input resolves the input format. An explicit name wins; otherwise the
+// extension of path is used, and finally the content in head is sniffed.
+func (r *Registry) Input(name, path string, head []byte) (*Format, error) {
+	if name != "" {
+		f, ok := r.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown input format %q", name)
+		}
+		if !f.CanRead() {
+			return nil, fmt.Errorf("format %q cannot be used as input", name)
+		}
+		return f, nil
+	}
+
+	if f := r.byExtension(path, (*Format).CanRead); f != nil {
+		return f, nil
+	}
+
+	for _, f := range r.Formats() {
+		if f.CanRead() && f.Sniff != nil && f.Sniff(head) {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot detect input format of %q", path)
+}
+
+// Output resolves the output format. An explicit name wins; otherwise the
+// extension of path is used, falling back to fallback.
+func (r *Registry) Output(name, path, fallback string) (*Format, error) {
+	if name == "" {
+		if f := r.byExtension(path, (*Format).CanWrite); f != nil {
+			return f, nil
+		}
+		name = fallback
+	}
+
+	f, ok := r.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q", name)
+	}
+	if !f.CanWrite() {
+		return nil, fmt.Errorf("format %q cannot be used as output", name)
+	}
+	return f, nil
+}
+
+// ReadFile opens path, resolves its format as Input does and decodes it
+// with opts, which may be nil.
+func (r *Registry) ReadFile(path, name string, opts *DecodeOptions) (*track.Track, *Format, error) {
+	file, err := fileio.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	return r.Read(file, path, name, opts)
+}
+
+// Read is like ReadFile for input read from in. path is only used to
+// resolve the format and in messages. Gzip-compressed input is
+// decompressed first.
+func (r *Registry) Read(in io.Reader, path, name string, opts *DecodeOptions) (*track.Track, *Format, error) {
+	in, err := fileio.Decompress(in)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReaderSize(in, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, nil, fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+
+	f, err := r.Input(name, path, head)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t, err := f.Decode(br, opts)
+	if err != nil {
+		return nil, f, err
+	}
+	return t, f, nil
+}
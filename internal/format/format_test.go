@@ -0,0 +1,319 @@
+package format
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+const zweiteLog = `[{"tm":1609459200,"lo":139.7454,"la":35.6812,"al":"10.5","sp":"1.5","co":90,"th":0,"he":0,"ds":"12","tl":"Run"}]`
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	decode := func(io.Reader, *DecodeOptions) (*track.Track, error) { return nil, nil }
+
+	if err := r.Register(&Format{Name: "a", Decode: decode}); err != nil {
+		t.Fatalf("Register() unexpected error = %v", err)
+	}
+	if err := r.Register(&Format{Name: "a", Decode: decode}); err == nil {
+		t.Error("Register() duplicate error = nil, want error")
+	}
+	if err := r.Register(&Format{Name: "b"}); err == nil {
+		t.Error("Register() without Decode or Encode error = nil, want error")
+	}
+	if err := r.Register(&Format{Decode: decode}); err == nil {
+		t.Error("Register() without name error = nil, want error")
+	}
+}
+
+func TestDefault_Input(t *testing.T) {
+	tests := []struct {
+		name     string
+		explicit string
+		path     string
+		head     string
+		want     string
+		wantErr  bool
+	}{
+		{"by extension", "", "log.json", "", "zweite", false},
+		{"extension is case insensitive", "", "LOG.GPX", "", "gpx", false},
+		{"sniff zweite", "", "log.txt", ` [{"tm":1}]`, "zweite", false},
+		{"sniff gpx", "", "export", `<?xml version="1.0"?><gpx version="1.1">`, "gpx", false},
+		{"explicit wins", "gpx", "log.json", "", "gpx", false},
+		{"explicit write-only", "geojson", "log.json", "", "", true},
+		{"explicit unknown", "kml", "log.json", "", "", true},
+		{"undetectable", "", "log.txt", "hello", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Default.Input(tt.explicit, tt.path, []byte(tt.head))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Input() = %q, want error", f.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Input() unexpected error = %v", err)
+			}
+			if f.Name != tt.want {
+				t.Errorf("Input() = %q, want %q", f.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefault_Output(t *testing.T) {
+	tests := []struct {
+		name     string
+		explicit string
+		path     string
+		want     string
+		wantErr  bool
+	}{
+		{"fallback", "", "", "gpx", false},
+		{"by extension", "", "out.geojson", "geojson", false},
+		{"unknown extension falls back", "", "out.xml", "gpx", false},
+		{"explicit wins", "geojson", "out.gpx", "geojson", false},
+		{"read-only format", "zweite", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Default.Output(tt.explicit, tt.path, "gpx")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Output() = %q, want error", f.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Output() unexpected error = %v", err)
+			}
+			if f.Name != tt.want {
+				t.Errorf("Output() = %q, want %q", f.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefault_ReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.dat")
+	if err := os.WriteFile(path, []byte(zweiteLog), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	tr, f, err := Default.ReadFile(path, "", nil)
+	if err != nil {
+		t.Fatalf("ReadFile() unexpected error = %v", err)
+	}
+	if f.Name != "zweite" {
+		t.Errorf("detected format = %q, want zweite", f.Name)
+	}
+	if len(tr.Points) != 1 || tr.Name != "Run" {
+		t.Errorf("track = %d points named %q, want 1 point named Run", len(tr.Points), tr.Name)
+	}
+	if got := tr.Points[0].Speed; got != 1.5 {
+		t.Errorf("Speed = %v, want 1.5", got)
+	}
+}
+
+func TestDefault_ReadFile_Gzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(zweiteLog))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "log.json.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	tr, f, err := Default.ReadFile(path, "", nil)
+	if err != nil {
+		t.Fatalf("ReadFile() unexpected error = %v", err)
+	}
+	if f.Name != "zweite" || len(tr.Points) != 1 {
+		t.Errorf("ReadFile() = %d points as %q, want 1 point as zweite", len(tr.Points), f.Name)
+	}
+}
+
+func TestGPX_RoundTrip(t *testing.T) {
+	zweite, _ := Default.Lookup("zweite")
+	gpxFormat, _ := Default.Lookup("gpx")
+
+	tr, err := zweite.Decode(strings.NewReader(zweiteLog), nil)
+	if err != nil {
+		t.Fatalf("decode zweite: %v", err)
+	}
+
+	var first bytes.Buffer
+	opts := &EncodeOptions{TrackName: "Run", Indent: "  "}
+	if err := gpxFormat.Encode(context.Background(), &first, tr, opts); err != nil {
+		t.Fatalf("encode gpx: %v", err)
+	}
+
+	back, err := gpxFormat.Decode(bytes.NewReader(first.Bytes()), nil)
+	if err != nil {
+		t.Fatalf("decode gpx: %v", err)
+	}
+	if back.Name != "Run" || len(back.Points) != 1 {
+		t.Fatalf("decoded track = %d points named %q", len(back.Points), back.Name)
+	}
+
+	var second bytes.Buffer
+	if err := gpxFormat.Encode(context.Background(), &second, back, opts); err != nil {
+		t.Fatalf("re-encode gpx: %v", err)
+	}
+	if first.String() != second.String() {
+		t.Errorf("GPX round trip differs\n--- first ---\n%s\n--- second ---\n%s", first.String(), second.String())
+	}
+}
+
+func TestGPX_SeaLevelRoundTrip(t *testing.T) {
+	zweite, _ := Default.Lookup("zweite")
+	gpxFormat, _ := Default.Lookup("gpx")
+
+	log := `[{"tm":1609459200,"lo":139.7454,"la":35.6812,"al":"0"},{"tm":1609459260,"lo":139.7460,"la":35.6815,"al":""}]`
+	tr, err := zweite.Decode(strings.NewReader(log), nil)
+	if err != nil {
+		t.Fatalf("decode zweite: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := gpxFormat.Encode(context.Background(), &buf, tr, &EncodeOptions{TrackName: "Beach"}); err != nil {
+		t.Fatalf("encode gpx: %v", err)
+	}
+	// The trkpt and the Start waypoint at sea level; nothing for the point
+	// without an altitude.
+	if n := strings.Count(buf.String(), "<ele>0</ele>"); n != 2 {
+		t.Errorf("got %d <ele>0</ele>, want 2:\n%s", n, buf.String())
+	}
+	if n := strings.Count(buf.String(), "<ele>"); n != 2 {
+		t.Errorf("got %d <ele>, want 2:\n%s", n, buf.String())
+	}
+
+	back, err := gpxFormat.Decode(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatalf("decode gpx: %v", err)
+	}
+	if p := back.Points[0]; !p.HasEle || p.Ele != 0 {
+		t.Errorf("point 0 = (%v, %v), want sea level", p.Ele, p.HasEle)
+	}
+	if back.Points[1].HasEle {
+		t.Error("point 1 HasEle = true, want false")
+	}
+}
+
+func TestGPX_DecodeElevation(t *testing.T) {
+	gpxFormat, _ := Default.Lookup("gpx")
+	doc := `<?xml version="1.0"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1"><trk><trkseg>
+<trkpt lat="35.0" lon="139.0"><ele>0</ele></trkpt>
+<trkpt lat="35.1" lon="139.1"></trkpt>
+<trkpt lat="35.2" lon="139.2"><ele>12.5</ele></trkpt>
+</trkseg></trk></gpx>`
+
+	tr, err := gpxFormat.Decode(strings.NewReader(doc), nil)
+	if err != nil {
+		t.Fatalf("decode gpx: %v", err)
+	}
+	for i, want := range []bool{true, false, true} {
+		if got := tr.Points[i].HasEle; got != want {
+			t.Errorf("point %d HasEle = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestGeoJSON_Encode(t *testing.T) {
+	zweite, _ := Default.Lookup("zweite")
+	geojson, _ := Default.Lookup("geojson")
+
+	tr, err := zweite.Decode(strings.NewReader(zweiteLog), nil)
+	if err != nil {
+		t.Fatalf("decode zweite: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := geojson.Encode(context.Background(), &buf, tr, &EncodeOptions{TrackName: "Run"}); err != nil {
+		t.Fatalf("Encode() unexpected error = %v", err)
+	}
+
+	var fc geoJSONFeatureCollection
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if len(fc.Features) != 1 {
+		t.Fatalf("features = %d, want 1", len(fc.Features))
+	}
+	got := fc.Features[0]
+	if got.Properties.Name != "Run" || got.Geometry.Type != "LineString" {
+		t.Errorf("feature = %+v", got)
+	}
+	if want := []float64{139.7454, 35.6812, 10.5}; len(got.Geometry.Coordinates) != 1 || got.Geometry.Coordinates[0][0] != want[0] || got.Geometry.Coordinates[0][2] != want[2] {
+		t.Errorf("coordinates = %v, want [%v]", got.Geometry.Coordinates, want)
+	}
+	if got.Properties.CoordTimes[0] != "2021-01-01T00:00:00Z" {
+		t.Errorf("coordTimes[0] = %q", got.Properties.CoordTimes[0])
+	}
+}
+
+func TestHTML_Encode(t *testing.T) {
+	html, _ := Default.Lookup("html")
+	tr := &track.Track{Points: []track.Point{
+		{Time: time.Unix(1609459200, 0), Lat: 35.6812, Lon: 139.7454, Ele: 10, HasEle: true, Desc: "start <here>"},
+		{Time: time.Unix(1609459260, 0), Lat: 35.6815, Lon: 139.7460, Ele: 12, HasEle: true},
+	}}
+
+	var buf bytes.Buffer
+	err := html.Encode(context.Background(), &buf, tr, &EncodeOptions{
+		TrackName: "Tokyo & Back",
+		Location:  time.FixedZone("", 9*3600),
+	})
+	if err != nil {
+		t.Fatalf("Encode() unexpected error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"<title>Tokyo &amp; Back</title>",
+		"<svg",
+		">Elevation</text>",
+		">Speed</text>",
+		"start &lt;here&gt;",
+		"2021-01-01 09:00:00",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+	for _, banned := range []string{"<script", "<link", "src="} {
+		if strings.Contains(out, banned) {
+			t.Errorf("HTML contains %q; the report must not load external resources", banned)
+		}
+	}
+
+	gpx := converter.DefaultConfig()
+	gpx.Lang = i18n.Japanese
+	buf.Reset()
+	if err := html.Encode(context.Background(), &buf, tr, &EncodeOptions{TrackName: "Run", GPX: gpx}); err != nil {
+		t.Fatalf("Encode(ja) unexpected error = %v", err)
+	}
+	for _, want := range []string{`<html lang="ja">`, "<h2>概要</h2>", "<td>距離</td>", "<td>1分0秒</td>", "<h2>メモ</h2>", ">スタート</text>", ">標高</text>"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Japanese HTML missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package format
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+func init() {
+	Register(&Format{
+		Name:        "geojson",
+		Description: "GeoJSON LineString feature",
+		Extensions:  []string{".geojson"},
+		MediaType:   "application/geo+json",
+		Encode:      encodeGeoJSON,
+	})
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	Properties geoJSONProperties `json:"properties"`
+	Geometry   geoJSONGeometry   `json:"geometry"`
+}
+
+// geoJSONProperties follows the coordTimes convention used by
+// togeojson and Mapbox so timestamps survive the round trip.
+type geoJSONProperties struct {
+	Name       string   `json:"name"`
+	CoordTimes []string `json:"coordTimes"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates [][]float64 `json:"coordinates"`
+}
+
+func encodeGeoJSON(ctx context.Context, w io.Writer, t *track.Track, opts *EncodeOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(t.Points) == 0 {
+		return converter.ErrNoPoints
+	}
+
+	feature := geoJSONFeature{
+		Type:       "Feature",
+		Properties: geoJSONProperties{Name: opts.TrackName},
+		Geometry:   geoJSONGeometry{Type: "LineString"},
+	}
+	for _, p := range t.Points {
+		// A position without an elevation has only two coordinates.
+		coords := []float64{geo.Round(p.Lon, geo.CoordDecimals), geo.Round(p.Lat, geo.CoordDecimals)}
+		if p.HasEle {
+			coords = append(coords, geo.Round(p.Ele, geo.EleDecimals))
+		}
+		feature.Geometry.Coordinates = append(feature.Geometry.Coordinates, coords)
+		feature.Properties.CoordTimes = append(feature.Properties.CoordTimes, p.Time.UTC().Format(time.RFC3339))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", opts.Indent)
+	if err := enc.Encode(geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []geoJSONFeature{feature},
+	}); err != nil {
+		return fmt.Errorf("failed to write GeoJSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package format
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/track"
+	"github.com/twpayne/go-gpx"
+)
+
+func init() {
+	Register(&Format{
+		Name:        "gpx",
+		Description: "GPX 1.1",
+		Extensions:  []string{".gpx"},
+		MediaType:   "application/gpx+xml",
+		Sniff:       sniffGPX,
+		Decode:      decodeGPX,
+		Encode:      encodeGPX,
+	})
+}
+
+func sniffGPX(head []byte) bool {
+	return bytes.Contains(head, []byte("<gpx"))
+}
+
+// decodeGPX reads every trkpt of every track segment, in document order.
+// Waypoints are ignored; zweg writes its own Start and Goal on output. GPX
+// values are typed by the parser, so opts changes nothing.
+func decodeGPX(r io.Reader, opts *DecodeOptions) (*track.Track, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GPX: %w", err)
+	}
+	g, err := gpx.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GPX: %w", err)
+	}
+	hasEle, err := gpxElevations(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GPX: %w", err)
+	}
+
+	t := &track.Track{}
+	if g.Metadata != nil {
+		t.Name = g.Metadata.Name
+	}
+	for _, trk := range g.Trk {
+		if t.Name == "" {
+			t.Name = trk.Name
+		}
+		for _, seg := range trk.TrkSeg {
+			for _, pt := range seg.TrkPt {
+				t.Points = append(t.Points, track.Point{
+					Time:   pt.Time,
+					Lat:    pt.Lat,
+					Lon:    pt.Lon,
+					Ele:    pt.Ele,
+					HasEle: hasEle[len(t.Points)],
+					Speed:  pt.Speed,
+					Course: pt.Course,
+					HDOP:   pt.HDOP,
+					VDOP:   pt.VDOP,
+					Desc:   pt.Desc,
+				})
+			}
+		}
+	}
+
+	if len(t.Points) == 0 {
+		return nil, fmt.Errorf("no track points found in GPX")
+	}
+	return t, nil
+}
+
+// gpxElevations reports, for every trkpt in document order, whether it has
+// an <ele>. go-gpx reads a missing <ele> as 0, which is also sea level.
+func gpxElevations(data []byte) ([]bool, error) {
+	var doc struct {
+		Trk []struct {
+			TrkSeg []struct {
+				TrkPt []struct {
+					Ele *string `xml:"ele"`
+				} `xml:"trkpt"`
+			} `xml:"trkseg"`
+		} `xml:"trk"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var out []bool
+	for _, trk := range doc.Trk {
+		for _, seg := range trk.TrkSeg {
+			for _, pt := range seg.TrkPt {
+				out = append(out, pt.Ele != nil)
+			}
+		}
+	}
+	return out, nil
+}
+
+func encodeGPX(ctx context.Context, w io.Writer, t *track.Track, opts *EncodeOptions) error {
+	cfg := opts.GPX
+	if cfg != nil && cfg.Stops != nil && cfg.Location == nil {
+		withLoc := *cfg
+		withLoc.Location = opts.location()
+		cfg = &withLoc
+	}
+	g, err := converter.New(cfg).ConvertTrack(ctx, t, opts.TrackName)
+	if err != nil {
+		return fmt.Errorf("failed to convert data: %w", err)
+	}
+	return fileio.NewGPXWriter(opts.Indent).Encode(w, g)
+}
@@ -0,0 +1,170 @@
+package format
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/render"
+	"github.com/chocoby/zweg/internal/stats"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+//go:embed templates/report.html.tmpl
+var reportTemplateText string
+
+var reportTemplate = template.Must(template.New("report").Parse(reportTemplateText))
+
+func init() {
+	Register(&Format{
+		Name:        "html",
+		Description: "Self-contained HTML report with map and profiles",
+		Extensions:  []string{".html", ".htm"},
+		MediaType:   "text/html; charset=utf-8",
+		Encode:      encodeHTML,
+	})
+}
+
+const (
+	reportChartWidth  = 900
+	reportChartHeight = 220
+	reportTimeLayout  = "2006-01-02 15:04:05 -07:00"
+)
+
+type reportRow struct {
+	Label string
+	Value string
+}
+
+type reportMemo struct {
+	Index int
+	Time  string
+	Lat   string
+	Lon   string
+	Text  string
+}
+
+type reportData struct {
+	// Lang translates the headings of the template.
+	Lang      i18n.Lang
+	Generator string
+	Name      string
+	Start     string
+	End       string
+	Map       template.HTML
+	Elevation template.HTML
+	Speed     template.HTML
+	Summary   []reportRow
+	Memos     []reportMemo
+}
+
+// encodeHTML writes a single HTML file with inline SVG figures and CSS, so
+// it renders offline and can be attached to a report as is.
+func encodeHTML(ctx context.Context, w io.Writer, t *track.Track, opts *EncodeOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(t.Points) == 0 {
+		return converter.ErrNoPoints
+	}
+
+	loc := opts.location()
+	s := stats.Compute(t)
+
+	generator := converter.DefaultConfig().Creator
+	lang := i18n.English
+	if opts.GPX != nil {
+		if opts.GPX.Creator != "" {
+			generator = opts.GPX.Creator
+		}
+		if opts.GPX.Lang != "" {
+			lang = opts.GPX.Lang
+		}
+	}
+
+	data := reportData{
+		Lang:      lang,
+		Generator: generator,
+		Name:      opts.TrackName,
+		Start:     s.Start.In(loc).Format(reportTimeLayout),
+		End:       s.End.In(loc).Format(reportTimeLayout),
+		Summary:   summaryRows(s, lang),
+	}
+
+	mapOpts := render.DefaultMapOptions()
+	mapOpts.Lang = lang
+	var err error
+	if data.Map, err = svgHTML(func(b io.Writer) error { return render.MapSVG(b, t, mapOpts) }); err != nil {
+		return err
+	}
+	// A log without any altitude has no elevation profile.
+	if elevation := render.ElevationChart(t, reportChartWidth, reportChartHeight); len(elevation.Series[0].X) > 0 {
+		elevation.Translate(lang)
+		if data.Elevation, err = svgHTML(elevation.WriteSVG); err != nil {
+			return err
+		}
+	}
+	speed := render.SpeedChart(t, reportChartWidth, reportChartHeight)
+	speed.Translate(lang)
+	if data.Speed, err = svgHTML(speed.WriteSVG); err != nil {
+		return err
+	}
+
+	for i, p := range t.Points {
+		if p.Desc == "" {
+			continue
+		}
+		data.Memos = append(data.Memos, reportMemo{
+			Index: i,
+			Time:  p.Time.In(loc).Format(reportTimeLayout),
+			Lat:   strconv.FormatFloat(p.Lat, 'f', 6, 64),
+			Lon:   strconv.FormatFloat(p.Lon, 'f', 6, 64),
+			Text:  p.Desc,
+		})
+	}
+
+	if err := reportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to write HTML: %w", err)
+	}
+	return nil
+}
+
+// svgHTML renders an SVG figure for inclusion in the report. The markup is
+// produced by the render package, which escapes all text it embeds.
+func svgHTML(draw func(io.Writer) error) (template.HTML, error) {
+	var b bytes.Buffer
+	if err := draw(&b); err != nil {
+		return "", fmt.Errorf("failed to render figure: %w", err)
+	}
+	return template.HTML(b.String()), nil
+}
+
+// summaryRows labels the summary as stats.WriteText does.
+func summaryRows(s stats.Summary, lang i18n.Lang) []reportRow {
+	rows := []reportRow{
+		{"Distance", fmt.Sprintf("%.2f km", s.Distance/1000)},
+		{"Duration", lang.Duration(s.Duration)},
+		{"Moving time", lang.Duration(s.MovingTime)},
+		{"Average speed", fmt.Sprintf("%.1f km/h", s.AvgSpeed*3.6)},
+		{"Max speed", fmt.Sprintf("%.1f km/h", s.MaxSpeed*3.6)},
+		{"Elevation gain", fmt.Sprintf("%.0f m", s.ElevationGain)},
+		{"Elevation loss", fmt.Sprintf("%.0f m", s.ElevationLoss)},
+	}
+	if s.HasElevation {
+		rows = append(rows, reportRow{"Elevation range", fmt.Sprintf("%.0f – %.0f m", s.MinElevation, s.MaxElevation)})
+	}
+	rows = append(rows, reportRow{"Points", strconv.Itoa(s.Points)})
+	for _, m := range s.Means {
+		rows = append(rows, reportRow{"Means", lang.T(m)})
+	}
+	for i := range rows {
+		rows[i].Label = lang.Context("stats", rows[i].Label)
+	}
+	return rows
+}
@@ -0,0 +1,40 @@
+package format
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+func init() {
+	Register(&Format{
+		Name:        "zweite",
+		Description: "ZweiteGPS JSON log",
+		Extensions:  []string{".json"},
+		MediaType:   "application/json",
+		Sniff:       sniffZweite,
+		Decode:      decodeZweite,
+	})
+}
+
+// sniffZweite matches a JSON array whose first object carries the
+// ZweiteGPS "tm" timestamp key.
+func sniffZweite(head []byte) bool {
+	head = bytes.TrimLeft(head, " \t\r\n")
+	return bytes.HasPrefix(head, []byte("[")) && bytes.Contains(head, []byte(`"tm"`))
+}
+
+func decodeZweite(r io.Reader, opts *DecodeOptions) (*track.Track, error) {
+	points, err := fileio.NewJSONReader().Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.lenient() {
+		return track.FromZweite(points)
+	}
+	t, malformed := track.FromZweiteLenient(points)
+	opts.report(malformed)
+	return t, nil
+}
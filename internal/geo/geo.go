@@ -0,0 +1,59 @@
+// Package geo provides the small amount of spherical geometry zweg needs.
+package geo
+
+import "math"
+
+// EarthRadius is the mean Earth radius in meters used by every function here.
+const EarthRadius = 6371008.8
+
+// Decimal places that coordinates and elevations are written with. Seven
+// decimals of a degree are about a centimetre, far finer than any GPS fix,
+// so rounding loses nothing recorded. It keeps the floating point noise of
+// interpolation out of the output, where it could differ between
+// platforms and make the same input convert to different bytes.
+const (
+	CoordDecimals = 7
+	EleDecimals   = 2
+)
+
+// Round rounds v to decimals places.
+func Round(v float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(v*scale) / scale
+}
+
+func toRad(deg float64) float64 { return deg * math.Pi / 180 }
+
+// Distance returns the great-circle distance in meters between two points
+// using the haversine formula.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := toRad(lat1), toRad(lat2)
+	dPhi := phi2 - phi1
+	dLambda := toRad(lon2 - lon1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func toDeg(rad float64) float64 { return rad * 180 / math.Pi }
+
+// Interpolate returns the point a fraction f of the way from the first point
+// to the second along the great circle joining them.
+func Interpolate(lat1, lon1, lat2, lon2, f float64) (lat, lon float64) {
+	phi1, lambda1 := toRad(lat1), toRad(lon1)
+	phi2, lambda2 := toRad(lat2), toRad(lon2)
+
+	// Angular distance between the points.
+	delta := Distance(lat1, lon1, lat2, lon2) / EarthRadius
+	if delta < 1e-12 {
+		return lat1 + (lat2-lat1)*f, lon1 + (lon2-lon1)*f
+	}
+
+	a := math.Sin((1-f)*delta) / math.Sin(delta)
+	b := math.Sin(f*delta) / math.Sin(delta)
+	x := a*math.Cos(phi1)*math.Cos(lambda1) + b*math.Cos(phi2)*math.Cos(lambda2)
+	y := a*math.Cos(phi1)*math.Sin(lambda1) + b*math.Cos(phi2)*math.Sin(lambda2)
+	z := a*math.Sin(phi1) + b*math.Sin(phi2)
+
+	return toDeg(math.Atan2(z, math.Hypot(x, y))), toDeg(math.Atan2(y, x))
+}
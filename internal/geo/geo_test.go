@@ -0,0 +1,79 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want, tolerance        float64
+	}{
+		{"same point", 35.6812, 139.7671, 35.6812, 139.7671, 0, 1e-9},
+		{"one degree of latitude", 0, 0, 1, 0, 111195, 1},
+		{"Tokyo to Osaka", 35.6812, 139.7671, 34.7025, 135.4959, 403000, 2000},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111195, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Distance(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("Distance() = %.1f, want %.1f ± %.1f", got, tt.want, tt.tolerance)
+			}
+		})
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		f                      float64
+		wantLat, wantLon       float64
+	}{
+		{"start", 35, 139, 36, 140, 0, 35, 139},
+		{"end", 35, 139, 36, 140, 1, 36, 140},
+		{"along the equator", 0, 10, 0, 20, 0.25, 0, 12.5},
+		{"along a meridian", 10, 5, 20, 5, 0.5, 15, 5},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 0.5, 0, 180},
+		{"same point", 35, 139, 35, 139, 0.5, 35, 139},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lon := Interpolate(tt.lat1, tt.lon1, tt.lat2, tt.lon2, tt.f)
+			if math.Abs(lat-tt.wantLat) > 1e-9 || math.Abs(math.Mod(lon-tt.wantLon+540, 360)-180) > 1e-9 {
+				t.Errorf("Interpolate() = (%v, %v), want (%v, %v)", lat, lon, tt.wantLat, tt.wantLon)
+			}
+		})
+	}
+
+	// Halfway along a long route lies halfway in distance from both ends.
+	lat, lon := Interpolate(35.6812, 139.7671, 34.7025, 135.4959, 0.5)
+	d1 := Distance(35.6812, 139.7671, lat, lon)
+	d2 := Distance(lat, lon, 34.7025, 135.4959)
+	if math.Abs(d1-d2) > 1e-3 {
+		t.Errorf("midpoint distances %.3f and %.3f differ", d1, d2)
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		v        float64
+		decimals int
+		want     float64
+	}{
+		{35.6812, CoordDecimals, 35.6812},
+		{14.999999999984098, EleDecimals, 15},
+		{139.76710004999, CoordDecimals, 139.7671},
+		{-0.125, 2, -0.13},
+	}
+	for _, tt := range tests {
+		if got := Round(tt.v, tt.decimals); got != tt.want {
+			t.Errorf("Round(%v, %d) = %v, want %v", tt.v, tt.decimals, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package geotag writes track positions into photos taken along the track.
+//
+// Each JPEG's EXIF DateTimeOriginal is read in the camera's time zone,
+// corrected for the camera clock offset and looked up in the track; the
+// interpolated position is written back as EXIF GPS tags.
+package geotag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chocoby/zweg/internal/exif"
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/privacy"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// DefaultMaxGap is the longest recording gap, or distance beyond either end
+// of the track, that a photo may fall into when Options.MaxGap is zero.
+const DefaultMaxGap = 5 * time.Minute
+
+// Options controls matching and writing.
+type Options struct {
+	// Location is the camera's time zone. When nil, the OffsetTimeOriginal
+	// recorded by the camera is used, then the local time zone.
+	Location *time.Location
+	// ClockOffset is how far the camera clock is ahead of the true time;
+	// negative when it is behind.
+	ClockOffset time.Duration
+	// MaxGap defaults to DefaultMaxGap.
+	MaxGap time.Duration
+	// DryRun matches photos without modifying them.
+	DryRun bool
+	// PrivacyZones are places no photo is tagged in. A position
+	// interpolated across a gap left by removed points may still fall
+	// inside one.
+	PrivacyZones []privacy.Zone
+}
+
+// Match is the outcome for one photo. Err is set when the photo could not
+// be matched or written; the other fields are valid as far as matching got.
+type Match struct {
+	Photo string
+	// Time is the corrected capture time.
+	Time time.Time
+	// Point is the interpolated track position at Time.
+	Point track.Point
+	// Nearest is the recorded point closest in time, and Distance and
+	// TimeDelta how far Point is from it.
+	Nearest   int
+	Distance  float64
+	TimeDelta time.Duration
+	Err       error
+}
+
+// Photos matches every photo to t and, unless opts.DryRun is set, writes
+// the position into it.
+func Photos(t *track.Track, photos []string, opts Options) []Match {
+	if opts.MaxGap <= 0 {
+		opts.MaxGap = DefaultMaxGap
+	}
+
+	matches := make([]Match, len(photos))
+	for i, path := range photos {
+		matches[i] = photo(t, path, opts)
+	}
+	return matches
+}
+
+func photo(t *track.Track, path string, opts Options) Match {
+	m := Match{Photo: path}
+	if len(t.Points) == 0 {
+		m.Err = fmt.Errorf("track has no points")
+		return m
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		m.Err = fmt.Errorf("failed to read photo: %w", err)
+		return m
+	}
+	f, err := exif.Parse(data)
+	if err != nil {
+		m.Err = err
+		return m
+	}
+
+	if m.Time, err = captureTime(f, opts); err != nil {
+		m.Err = err
+		return m
+	}
+
+	m.Point, m.Nearest, err = t.At(m.Time, opts.MaxGap)
+	nearest := t.Points[m.Nearest]
+	m.TimeDelta = m.Time.Sub(nearest.Time)
+	if err != nil {
+		m.Err = fmt.Errorf("no position at %s: %w", m.Time.Format(time.RFC3339), err)
+		return m
+	}
+	m.Distance = geo.Distance(m.Point.Lat, m.Point.Lon, nearest.Lat, nearest.Lon)
+	if privacy.Contains(opts.PrivacyZones, m.Point.Lat, m.Point.Lon) {
+		m.Err = fmt.Errorf("position lies inside a privacy zone")
+		return m
+	}
+
+	if opts.DryRun {
+		return m
+	}
+
+	if err := f.SetGPS(exif.GPS{
+		Latitude:        m.Point.Lat,
+		Longitude:       m.Point.Lon,
+		Altitude:        m.Point.Ele,
+		HasAltitude:     m.Point.HasEle,
+		Track:           m.Point.Course,
+		HasTrack:        m.Point.Course >= 0,
+		ImgDirection:    m.Point.Heading,
+		HasImgDirection: m.Point.Heading >= 0,
+		Time:            m.Time,
+	}); err != nil {
+		m.Err = err
+		return m
+	}
+	if err := writeFile(path, f.Bytes()); err != nil {
+		m.Err = err
+	}
+	return m
+}
+
+// captureTime returns the corrected capture time of a photo.
+func captureTime(f *exif.File, opts Options) (time.Time, error) {
+	value, offset, err := f.DateTimeOriginal()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	loc := opts.Location
+	if loc == nil && offset != "" {
+		if t, err := time.Parse("-07:00", offset); err == nil {
+			_, secs := t.Zone()
+			loc = time.FixedZone(offset, secs)
+		}
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	t, err := exif.ParseDateTime(value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(-opts.ClockOffset), nil
+}
+
+// writeFile replaces path with data via a temporary file in the same
+// directory, so an interrupted write never leaves a truncated photo.
+func writeFile(path string, data []byte) (err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to write photo: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write photo: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write photo: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write photo: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write photo: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write photo: %w", err)
+	}
+	return nil
+}
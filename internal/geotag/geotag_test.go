@@ -0,0 +1,186 @@
+package geotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/exif"
+	"github.com/chocoby/zweg/internal/privacy"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// writePhoto writes a JPEG whose EXIF records only DateTimeOriginal.
+func writePhoto(t *testing.T, path, dateTime string) {
+	t.Helper()
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+
+	// Big-endian TIFF: header, IFD0 with an Exif IFD pointer, Exif IFD
+	// with DateTimeOriginal, then the 20-byte date-time string.
+	be := binary.BigEndian
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = be.AppendUint16(tiff, 1)
+	tiff = be.AppendUint16(tiff, 0x8769)
+	tiff = be.AppendUint16(tiff, 4)
+	tiff = be.AppendUint32(tiff, 1)
+	tiff = be.AppendUint32(tiff, 26)
+	tiff = be.AppendUint32(tiff, 0)
+	tiff = be.AppendUint16(tiff, 1)
+	tiff = be.AppendUint16(tiff, 0x9003)
+	tiff = be.AppendUint16(tiff, 2)
+	tiff = be.AppendUint32(tiff, 20)
+	tiff = be.AppendUint32(tiff, 44)
+	tiff = be.AppendUint32(tiff, 0)
+	tiff = append(tiff, dateTime+"\x00"...)
+
+	segLen := 2 + 6 + len(tiff)
+	var out bytes.Buffer
+	out.Write(img.Bytes()[:2])
+	out.Write([]byte{0xff, 0xe1, byte(segLen >> 8), byte(segLen)})
+	out.WriteString("Exif\x00\x00")
+	out.Write(tiff)
+	out.Write(img.Bytes()[2:])
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatalf("write photo: %v", err)
+	}
+}
+
+func testTrack() *track.Track {
+	base := time.Date(2024, 5, 1, 0, 30, 0, 0, time.UTC)
+	return &track.Track{Points: []track.Point{
+		{Time: base, Lat: 35.000, Lon: 139, Ele: 10, HasEle: true, Course: 90, Heading: 80},
+		{Time: base.Add(20 * time.Second), Lat: 35.002, Lon: 139, Ele: 30, HasEle: true, Course: 90, Heading: 100},
+	}}
+}
+
+func readGPS(t *testing.T, path string) (exif.GPS, bool) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read photo: %v", err)
+	}
+	f, err := exif.Parse(data)
+	if err != nil {
+		t.Fatalf("exif.Parse: %v", err)
+	}
+	g, ok, err := f.GPS()
+	if err != nil {
+		t.Fatalf("GPS: %v", err)
+	}
+	return g, ok
+}
+
+func TestPhotos(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "a.jpg")
+	// 09:30:15 JST with a camera clock 10s fast is 00:30:05 UTC.
+	writePhoto(t, photo, "2024:05:01 09:30:15")
+
+	opts := Options{Location: time.FixedZone("JST", 9*3600), ClockOffset: 10 * time.Second}
+
+	dry := opts
+	dry.DryRun = true
+	m := Photos(testTrack(), []string{photo}, dry)[0]
+	if m.Err != nil {
+		t.Fatalf("dry run: %v", m.Err)
+	}
+	if want := time.Date(2024, 5, 1, 0, 30, 5, 0, time.UTC); !m.Time.Equal(want) {
+		t.Errorf("Time = %v, want %v", m.Time, want)
+	}
+	if m.Nearest != 0 || m.TimeDelta != 5*time.Second {
+		t.Errorf("nearest = %d (%v), want point 0 (5s)", m.Nearest, m.TimeDelta)
+	}
+	if math.Abs(m.Distance-55.6) > 0.5 {
+		t.Errorf("Distance = %.1f, want about 55.6", m.Distance)
+	}
+	if _, ok := readGPS(t, photo); ok {
+		t.Fatal("dry run modified the photo")
+	}
+
+	m = Photos(testTrack(), []string{photo}, opts)[0]
+	if m.Err != nil {
+		t.Fatalf("Photos: %v", m.Err)
+	}
+	g, ok := readGPS(t, photo)
+	if !ok {
+		t.Fatal("photo has no GPS position after tagging")
+	}
+	if math.Abs(g.Latitude-35.0005) > 1e-6 || math.Abs(g.Longitude-139) > 1e-6 {
+		t.Errorf("position = (%v, %v), want (35.0005, 139)", g.Latitude, g.Longitude)
+	}
+	if g.Altitude != 15 || g.Track != 90 || g.ImgDirection != 85 {
+		t.Errorf("GPS = %+v, want altitude 15, track 90, direction 85", g)
+	}
+}
+
+func TestPhotos_UnknownDirection(t *testing.T) {
+	photo := filepath.Join(t.TempDir(), "a.jpg")
+	writePhoto(t, photo, "2024:05:01 00:30:05")
+
+	// iOS records an unknown course or heading as -1; a due-north course
+	// of 0 is still written.
+	tr := testTrack()
+	for i := range tr.Points {
+		tr.Points[i].Course = 0
+	}
+	tr.Points[1].Heading = -1
+
+	if m := Photos(tr, []string{photo}, Options{Location: time.UTC})[0]; m.Err != nil {
+		t.Fatalf("Photos: %v", m.Err)
+	}
+	g, ok := readGPS(t, photo)
+	if !ok {
+		t.Fatal("photo has no GPS position after tagging")
+	}
+	if !g.HasTrack || g.Track != 0 {
+		t.Errorf("track = %v (known %v), want due north", g.Track, g.HasTrack)
+	}
+	if g.HasImgDirection {
+		t.Errorf("direction = %v, want none between a known and an unknown heading", g.ImgDirection)
+	}
+}
+
+func TestPhotos_Errors(t *testing.T) {
+	dir := t.TempDir()
+	late := filepath.Join(dir, "late.jpg")
+	writePhoto(t, late, "2024:05:01 12:00:00")
+	plain := filepath.Join(dir, "plain.jpg")
+	if err := os.WriteFile(plain, []byte("not a jpeg"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	matches := Photos(testTrack(), []string{late, plain, filepath.Join(dir, "missing.jpg")}, Options{Location: time.UTC})
+	for _, m := range matches {
+		if m.Err == nil {
+			t.Errorf("%s: error = nil, want error", filepath.Base(m.Photo))
+		}
+	}
+	if _, ok := readGPS(t, late); ok {
+		t.Error("unmatched photo was modified")
+	}
+}
+
+func TestPhotos_PrivacyZone(t *testing.T) {
+	photo := filepath.Join(t.TempDir(), "home.jpg")
+	writePhoto(t, photo, "2024:05:01 00:30:05")
+
+	// The photo lies between the points, 55 m north of the first.
+	zones := []privacy.Zone{{Lat: 35.0005, Lon: 139, Radius: 30}}
+	m := Photos(testTrack(), []string{photo}, Options{Location: time.UTC, PrivacyZones: zones})[0]
+	if m.Err == nil {
+		t.Fatal("photo inside a privacy zone: error = nil, want error")
+	}
+	if _, ok := readGPS(t, photo); ok {
+		t.Error("photo inside a privacy zone was tagged")
+	}
+}
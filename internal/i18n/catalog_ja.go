@@ -0,0 +1,109 @@
+package i18n
+
+// japanese is the Japanese catalog. Keys must keep the verbs of the
+// English format string in the same order.
+var japanese = map[string]string{
+	// List separator used by Lang.Join.
+	", ": "、",
+
+	// Waypoints and default names.
+	"Track": "トラック",
+	"Start": "スタート",
+	"Goal":  "ゴール",
+
+	// Stop waypoints.
+	"Stop %d":                              "滞在 %d",
+	"Arrival: %s, Departure: %s, Stay: %s": "到着: %s、出発: %s、滞在: %s",
+
+	// Means of transportation.
+	"Walking":    "徒歩",
+	"Jogging":    "ジョギング",
+	"Bicycle":    "自転車",
+	"MotorCycle": "バイク",
+	"AutoMobile": "自動車",
+	"Train":      "電車",
+	"Misc":       "その他",
+
+	// Track summary in GPX descriptions.
+	"Distance: %.2f km":      "距離: %.2f km",
+	"Duration: %s":           "所要時間: %s",
+	"Elevation gain: %.0f m": "獲得標高: %.0f m",
+	"Means: %s":              "移動手段: %s",
+
+	// Stats labels.
+	"stats\x04Start":  "開始",
+	"Points":          "ポイント数",
+	"End":             "終了",
+	"Duration":        "所要時間",
+	"Moving time":     "移動時間",
+	"Distance":        "距離",
+	"Elevation gain":  "獲得標高",
+	"Elevation loss":  "累積下降",
+	"Elevation range": "標高範囲",
+	"Average speed":   "平均速度",
+	"Moving speed":    "移動中の平均速度",
+	"Max speed":       "最高速度",
+	"Means":           "移動手段",
+	"Stop":            "滞在",
+	"Lap":             "ラップ",
+
+	// Charts.
+	"Elevation":           "標高",
+	"Speed":               "速度",
+	"Cadence":             "ケイデンス",
+	"Distance (km)":       "距離 (km)",
+	"Elevation (m)":       "標高 (m)",
+	"Speed (km/h)":        "速度 (km/h)",
+	"Elapsed time (h:mm)": "経過時間 (h:mm)",
+	"Cadence (steps/min)": "ケイデンス (歩/分)",
+
+	// HTML report.
+	"Summary":   "概要",
+	"Profiles":  "プロファイル",
+	"Memos":     "メモ",
+	"Time":      "時刻",
+	"Latitude":  "緯度",
+	"Longitude": "経度",
+	"Memo":      "メモ",
+
+	// Command output.
+	"Successfully converted %d points to %s: %s\n":            "%d ポイントを %s に変換しました: %s\n",
+	"Successfully rendered %d points to %s: %s\n":             "%d ポイントを %s に描画しました: %s\n",
+	"Successfully plotted %s profile of %d points: %s\n":      "%s プロファイル (%d ポイント) を出力しました: %s\n",
+	"%s: %d error(s), %d warning(s)":                          "%s: エラー %d 件、警告 %d 件",
+	"Tagged %d of %d photo(s)\n":                              "%d / %d 枚の写真に位置情報を書き込みました\n",
+	"Matched %d of %d photo(s) (dry run, no files changed)\n": "%d / %d 枚の写真が一致しました (ドライラン、ファイルは変更していません)\n",
+	"Error: %v\n":         "エラー: %v\n",
+	"OK: %s matches %s\n": "OK: %s は %s と一致しています\n",
+	"Warning: %s: treated %d malformed value(s) as not recorded at points %s\n": "警告: %s: 不正な値 %d 件を未記録として扱いました。対象ポイント: %s\n",
+
+	// Errors.
+	"input file is required":                                  "入力ファイルを指定してください",
+	"invalid output directory: %w":                            "出力ディレクトリが不正です: %w",
+	"invalid output file path: %w":                            "出力ファイルのパスが不正です: %w",
+	"failed to read input file: %w":                           "入力ファイルを読み込めませんでした: %w",
+	"failed to resample track: %w":                            "トラックをリサンプリングできませんでした: %w",
+	"failed to smooth track: %w":                              "トラックを平滑化できませんでした: %w",
+	"failed to generate output filename: %w":                  "出力ファイル名を生成できませんでした: %w",
+	"failed to create output directory: %w":                   "出力ディレクトリを作成できませんでした: %w",
+	"failed to write output file: %w":                         "出力ファイルを書き込めませんでした: %w",
+	"failed to write output message: %w":                      "メッセージを出力できませんでした: %w",
+	"failed to write stats: %w":                               "統計を出力できませんでした: %w",
+	"failed to write validation report: %w":                   "検証結果を出力できませんでした: %w",
+	"failed to write format list: %w":                         "フォーマット一覧を出力できませんでした: %w",
+	"failed to build %s profile: %w":                          "%s プロファイルを作成できませんでした: %w",
+	"track log is required":                                   "トラックログを指定してください",
+	"no photos given":                                         "写真を指定してください",
+	"failed to read track log: %w":                            "トラックログを読み込めませんでした: %w",
+	"failed to write geotag report: %w":                       "ジオタグの結果を出力できませんでした: %w",
+	"all points lie inside privacy zones":                     "すべてのポイントがプライバシーゾーン内にあります",
+	"%d of %d photo(s) could not be tagged":                   "%d / %d 枚の写真に位置情報を書き込めませんでした",
+	"output file is required":                                 "出力ファイルを指定してください",
+	"failed to read output file: %w":                          "出力ファイルを読み込めませんでした: %w",
+	"failed to read checksum: %w":                             "チェックサムを読み込めませんでした: %w",
+	"failed to write checksum: %w":                            "チェックサムを書き込めませんでした: %w",
+	"failed to convert: %w":                                   "変換できませんでした: %w",
+	"%s does not match its checksum":                          "%s はチェックサムと一致しません",
+	"%s differs from a conversion of %s at byte %d":           "%s は %s の変換結果と %d バイト目から異なります",
+	"%s contains %d logs, which cannot share one output file": "%s には %d 件のログが含まれているため、1 つの出力ファイルにはまとめられません",
+}
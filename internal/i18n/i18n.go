@@ -0,0 +1,132 @@
+// Package i18n translates user-facing text.
+//
+// Messages are looked up by their English format string, so English needs
+// no catalog and any message missing from a catalog falls back to English.
+// The zero Lang is English.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Lang is a supported output language.
+type Lang string
+
+const (
+	English  Lang = "en"
+	Japanese Lang = "ja"
+)
+
+// catalogs maps each non-English language to its messages.
+var catalogs = map[Lang]map[string]string{
+	Japanese: japanese,
+}
+
+// Parse validates a language code; the empty string means English.
+func Parse(s string) (Lang, error) {
+	switch l := Lang(strings.ToLower(s)); l {
+	case "":
+		return English, nil
+	case English, Japanese:
+		return l, nil
+	default:
+		return "", fmt.Errorf("unsupported language %q (expected en or ja)", s)
+	}
+}
+
+// T returns the translation of msg.
+func (l Lang) T(msg string) string {
+	if s, ok := catalogs[l][msg]; ok {
+		return s
+	}
+	return msg
+}
+
+// Context returns the translation of msg in ctx, for English words that
+// translate differently depending on where they appear. Catalogs key such
+// messages as ctx + "\x04" + msg, as gettext does, and a message without a
+// contextual entry falls back to T.
+func (l Lang) Context(ctx, msg string) string {
+	if s, ok := catalogs[l][ctx+"\x04"+msg]; ok {
+		return s
+	}
+	return l.T(msg)
+}
+
+// Sprintf formats the translation of format.
+func (l Lang) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(l.T(format), args...)
+}
+
+// Errorf is fmt.Errorf with a translated format; %w still wraps.
+func (l Lang) Errorf(format string, args ...any) error {
+	return fmt.Errorf(l.T(format), args...)
+}
+
+// Join joins elems with the list separator of l, e.g. ", " or "、".
+func (l Lang) Join(elems []string) string {
+	return strings.Join(elems, l.T(", "))
+}
+
+// Duration formats d to the second, e.g. "1h2m3s" or "1時間2分3秒".
+func (l Lang) Duration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if l != Japanese {
+		return d.String()
+	}
+
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		fmt.Fprintf(&b, "%d時間", h)
+	}
+	if h > 0 || m > 0 {
+		fmt.Fprintf(&b, "%d分", m)
+	}
+	fmt.Fprintf(&b, "%d秒", s)
+	return b.String()
+}
+
+// Width returns the number of terminal columns s occupies, counting East
+// Asian wide characters as two.
+func Width(s string) int {
+	w := 0
+	for _, r := range s {
+		if wide(r) {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	return w
+}
+
+// Pad right-pads s with spaces to width columns.
+func Pad(s string, width int) string {
+	if n := width - Width(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// wide reports whether r is displayed double-width. The ranges cover CJK
+// ideographs, kana, Hangul and full-width forms.
+func wide(r rune) bool {
+	if r < 0x1100 || r == utf8.RuneError {
+		return false
+	}
+	return r <= 0x115f ||
+		(r >= 0x2e80 && r <= 0xa4cf && r != 0x303f) ||
+		(r >= 0xac00 && r <= 0xd7a3) ||
+		(r >= 0xf900 && r <= 0xfaff) ||
+		(r >= 0xfe30 && r <= 0xfe4f) ||
+		(r >= 0xff00 && r <= 0xff60) ||
+		(r >= 0xffe0 && r <= 0xffe6)
+}
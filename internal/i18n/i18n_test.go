@@ -0,0 +1,105 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+// verbs matches fmt verbs, ignoring flags, width and precision.
+var verbs = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]+)?[a-zA-Z%]`)
+
+func TestCatalogsKeepVerbs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, msg := range catalog {
+			want, got := verbs.FindAllString(key, -1), verbs.FindAllString(msg, -1)
+			if !slices.Equal(want, got) {
+				t.Errorf("%s: %q has verbs %v, want %v", lang, msg, got, want)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Lang
+		wantErr bool
+	}{
+		{"", English, false},
+		{"en", English, false},
+		{"JA", Japanese, false},
+		{"de", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Parse(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLang_T(t *testing.T) {
+	if got := Japanese.T("Start"); got != "スタート" {
+		t.Errorf("T(Start) = %q, want スタート", got)
+	}
+	if got := Japanese.T("not in the catalog"); got != "not in the catalog" {
+		t.Errorf("missing message = %q, want it unchanged", got)
+	}
+	if got := Japanese.Context("stats", "Start"); got != "開始" {
+		t.Errorf("Context(stats, Start) = %q, want 開始", got)
+	}
+	if got := Japanese.Context("stats", "End"); got != "終了" {
+		t.Errorf("Context(stats, End) = %q, want the plain translation 終了", got)
+	}
+	if got := Lang("").T("Start"); got != "Start" {
+		t.Errorf("zero Lang T(Start) = %q, want Start", got)
+	}
+}
+
+func TestLang_Join(t *testing.T) {
+	elems := []string{"a", "b"}
+	if got := English.Join(elems); got != "a, b" {
+		t.Errorf("English.Join = %q, want \"a, b\"", got)
+	}
+	if got := Japanese.Join(elems); got != "a、b" {
+		t.Errorf("Japanese.Join = %q, want \"a、b\"", got)
+	}
+}
+
+func TestLang_Duration(t *testing.T) {
+	tests := []struct {
+		lang Lang
+		d    time.Duration
+		want string
+	}{
+		{English, time.Hour + 2*time.Minute + 3*time.Second, "1h2m3s"},
+		{Japanese, time.Hour + 2*time.Minute + 3*time.Second, "1時間2分3秒"},
+		{Japanese, 2 * time.Hour, "2時間0分0秒"},
+		{Japanese, 45*time.Second + 400*time.Millisecond, "45秒"},
+		{Japanese, -90 * time.Second, "-1分30秒"},
+	}
+	for _, tt := range tests {
+		if got := tt.lang.Duration(tt.d); got != tt.want {
+			t.Errorf("%s Duration(%v) = %q, want %q", tt.lang, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"Points:", 10, "Points:   "},
+		{"距離:", 10, "距離:     "},
+		{"移動中の平均速度:", 10, "移動中の平均速度:"},
+	}
+	for _, tt := range tests {
+		if got := Pad(tt.in, tt.width); got != tt.want {
+			t.Errorf("Pad(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
@@ -30,6 +30,19 @@ func (m Means) String() string {
 	return meansNames[m]
 }
 
+// activityTypes are the GPX <trk><type> values that Strava, OsmAnd and
+// similar tools recognise, indexed by Means. Misc has no equivalent.
+var activityTypes = [...]string{"walking", "running", "cycling", "motorcycling", "driving", "train", ""}
+
+// ActivityType returns the GPX activity type for the means of
+// transportation, or an empty string when there is none.
+func (m Means) ActivityType() string {
+	if m < 0 || int(m) >= len(activityTypes) {
+		return ""
+	}
+	return activityTypes[m]
+}
+
 // Point represents a single GPS point from ZweiteGPS JSON format.
 // Field semantics follow the official ZweiteGPS JSON specification.
 type Point struct {
@@ -73,15 +86,38 @@ func (p *Point) TimestampIn(loc *time.Location) time.Time {
 	return time.Unix(p.Tm, 0).In(loc)
 }
 
+// Altitude parses Al in meters. An empty value is 0; HasAltitude tells it
+// apart from a recorded 0.
 func (p *Point) Altitude() (float64, error) {
-	if p.Al == "" {
+	return parseNumber("altitude", p.Al)
+}
+
+// HasAltitude reports whether Al is recorded. It may still fail to parse.
+func (p *Point) HasAltitude() bool {
+	return p.Al != ""
+}
+
+// Speed parses Sp in meters per second. An empty value is 0.
+func (p *Point) Speed() (float64, error) {
+	return parseNumber("speed", p.Sp)
+}
+
+// Distance parses Ds, the cumulative distance in meters. An empty value
+// is 0.
+func (p *Point) Distance() (float64, error) {
+	return parseNumber("distance", p.Ds)
+}
+
+// parseNumber parses one of the string-encoded numeric fields.
+func parseNumber(field, s string) (float64, error) {
+	if s == "" {
 		return 0, nil
 	}
-	alt, err := strconv.ParseFloat(p.Al, 64)
+	v, err := strconv.ParseFloat(s, 64)
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse altitude %q: %w", p.Al, err)
+		return 0, fmt.Errorf("failed to parse %s %q: %w", field, s, err)
 	}
-	return alt, nil
+	return v, nil
 }
 
 // FirstTitle returns the first non-empty Tl (log title) found in the slice.
@@ -105,3 +141,17 @@ func FirstMeans(points []Point) (Means, bool) {
 	}
 	return 0, false
 }
+
+// DefaultTrackName returns the name a track gets when none is given explicitly:
+// the first Tl, then the English name of the first Ms, then "Track".
+func DefaultTrackName(points []Point) string {
+	if title := FirstTitle(points); title != "" {
+		return title
+	}
+	if m, ok := FirstMeans(points); ok {
+		if name := m.String(); name != "" {
+			return name
+		}
+	}
+	return "Track"
+}
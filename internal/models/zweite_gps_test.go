@@ -2,6 +2,8 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -28,6 +30,31 @@ func TestFirstTitle(t *testing.T) {
 	}
 }
 
+func TestDefaultTrackName(t *testing.T) {
+	bicycle := MeansBicycle
+	unknown := Means(99)
+
+	tests := []struct {
+		name   string
+		points []Point
+		want   string
+	}{
+		{"empty slice", nil, "Track"},
+		{"title wins", []Point{{Tl: "Morning Run", Ms: &bicycle}}, "Morning Run"},
+		{"means fallback", []Point{{Ms: &bicycle}}, "Bicycle"},
+		{"unknown means falls back to Track", []Point{{Ms: &unknown}}, "Track"},
+		{"nothing recorded", []Point{{}}, "Track"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultTrackName(tt.points); got != tt.want {
+				t.Errorf("DefaultTrackName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPoint_DecodeFullSpec(t *testing.T) {
 	raw := `{
 		"tm": 1609459200, "lo": 139.745438, "la": 35.658581,
@@ -149,6 +176,27 @@ func TestMeans_String(t *testing.T) {
 	}
 }
 
+func TestMeans_ActivityType(t *testing.T) {
+	tests := []struct {
+		m    Means
+		want string
+	}{
+		{MeansWalking, "walking"},
+		{MeansJogging, "running"},
+		{MeansBicycle, "cycling"},
+		{MeansMotorCycle, "motorcycling"},
+		{MeansAutoMobile, "driving"},
+		{MeansTrain, "train"},
+		{MeansMisc, ""},
+		{Means(99), ""},
+	}
+	for _, tt := range tests {
+		if got := tt.m.ActivityType(); got != tt.want {
+			t.Errorf("Means(%d).ActivityType() = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
 func TestFirstMeans(t *testing.T) {
 	walking := MeansWalking
 	bicycle := MeansBicycle
@@ -302,3 +350,38 @@ func TestPoint_Altitude(t *testing.T) {
 		})
 	}
 }
+
+func TestPoint_SpeedAndDistance(t *testing.T) {
+	tests := []struct {
+		name         string
+		point        Point
+		wantSpeed    float64
+		wantDistance float64
+		wantErr      string
+	}{
+		{"valid", Point{Sp: "2.5", Ds: "120.5"}, 2.5, 120.5, ""},
+		{"empty", Point{}, 0, 0, ""},
+		{"invalid speed", Point{Sp: "fast", Ds: "1"}, 0, 1, `failed to parse speed "fast"`},
+		{"invalid distance", Point{Sp: "1", Ds: "-"}, 1, 0, `failed to parse distance "-"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			speed, speedErr := tt.point.Speed()
+			distance, distanceErr := tt.point.Distance()
+			if speed != tt.wantSpeed || distance != tt.wantDistance {
+				t.Errorf("Speed(), Distance() = %v, %v, want %v, %v", speed, distance, tt.wantSpeed, tt.wantDistance)
+			}
+			err := errors.Join(speedErr, distanceErr)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
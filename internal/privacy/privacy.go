@@ -0,0 +1,119 @@
+// Package privacy removes the parts of a track near sensitive places.
+//
+// A zone is a circle around a place such as home or work. Points inside
+// any zone are dropped, so the published track starts and ends at the zone
+// edge without revealing the address it leads to.
+package privacy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// Zone is a circle of Radius metres around Lat, Lon.
+type Zone struct {
+	Name   string
+	Lat    float64
+	Lon    float64
+	Radius float64
+}
+
+// Contains reports whether lat, lon lies within the zone.
+func (z Zone) Contains(lat, lon float64) bool {
+	return geo.Distance(z.Lat, z.Lon, lat, lon) <= z.Radius
+}
+
+// Validate checks the coordinates and radius of z.
+func (z Zone) Validate() error {
+	if z.Lat < -90 || z.Lat > 90 || z.Lon < -180 || z.Lon > 180 {
+		return fmt.Errorf("privacy zone %s: coordinates %g,%g out of range", z.label(), z.Lat, z.Lon)
+	}
+	if z.Radius <= 0 {
+		return fmt.Errorf("privacy zone %s: radius must be positive", z.label())
+	}
+	return nil
+}
+
+func (z Zone) label() string {
+	if z.Name != "" {
+		return strconv.Quote(z.Name)
+	}
+	return fmt.Sprintf("%g,%g", z.Lat, z.Lon)
+}
+
+// String formats z as ParseZone accepts it.
+func (z Zone) String() string {
+	s := fmt.Sprintf("%g,%g,%g", z.Lat, z.Lon, z.Radius)
+	if z.Name != "" {
+		s += "," + z.Name
+	}
+	return s
+}
+
+// ParseZone parses "lat,lon,radius" with an optional trailing ",name",
+// the radius in metres.
+func ParseZone(s string) (Zone, error) {
+	parts := strings.SplitN(s, ",", 4)
+	if len(parts) < 3 {
+		return Zone{}, fmt.Errorf("invalid privacy zone %q (expected lat,lon,radius[,name])", s)
+	}
+
+	var nums [3]float64
+	for i := range nums {
+		v, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+		if err != nil {
+			return Zone{}, fmt.Errorf("invalid privacy zone %q (expected lat,lon,radius[,name])", s)
+		}
+		nums[i] = v
+	}
+
+	z := Zone{Lat: nums[0], Lon: nums[1], Radius: nums[2]}
+	if len(parts) == 4 {
+		z.Name = strings.TrimSpace(parts[3])
+	}
+	return z, z.Validate()
+}
+
+// Apply removes the points of t that lie inside any of zones and returns
+// how many were removed. Memos on removed points are dropped with them, but
+// a means of transportation recorded inside a zone carries over to the next
+// kept point so the activity is not lost.
+func Apply(t *track.Track, zones []Zone) int {
+	if len(zones) == 0 {
+		return 0
+	}
+
+	kept := t.Points[:0]
+	var means *models.Means
+	for _, p := range t.Points {
+		if Contains(zones, p.Lat, p.Lon) {
+			if p.Means != nil {
+				means = p.Means
+			}
+			continue
+		}
+		if p.Means == nil {
+			p.Means = means
+		}
+		means = nil
+		kept = append(kept, p)
+	}
+	removed := len(t.Points) - len(kept)
+	t.Points = kept
+	return removed
+}
+
+// Contains reports whether lat, lon lies within any of zones.
+func Contains(zones []Zone, lat, lon float64) bool {
+	for _, z := range zones {
+		if z.Contains(lat, lon) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package privacy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+func TestParseZone(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Zone
+		wantErr bool
+	}{
+		{"35.6812,139.7671,200", Zone{Lat: 35.6812, Lon: 139.7671, Radius: 200}, false},
+		{"35.6812, 139.7671, 200, Home, sweet home", Zone{Name: "Home, sweet home", Lat: 35.6812, Lon: 139.7671, Radius: 200}, false},
+		{"35.6812,139.7671", Zone{}, true},
+		{"35.6812,east,200", Zone{}, true},
+		{"95,139,200", Zone{}, true},
+		{"35,139,0", Zone{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseZone(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseZone(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseZone(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestZone_String(t *testing.T) {
+	z := Zone{Name: "Home", Lat: 35.5, Lon: 139.25, Radius: 150}
+	got, err := ParseZone(z.String())
+	if err != nil || got != z {
+		t.Errorf("ParseZone(%q) = %+v, %v; want %+v", z.String(), got, err, z)
+	}
+}
+
+func TestApply(t *testing.T) {
+	walking := models.MeansWalking
+	base := time.Unix(1609459200, 0).UTC()
+	// Points roughly 111 m apart going north from the zone centre.
+	tr := &track.Track{Points: []track.Point{
+		{Time: base, Lat: 35.000, Lon: 139, Means: &walking, Desc: "front door"},
+		{Time: base.Add(time.Minute), Lat: 35.001, Lon: 139},
+		{Time: base.Add(2 * time.Minute), Lat: 35.002, Lon: 139},
+		{Time: base.Add(3 * time.Minute), Lat: 35.003, Lon: 139},
+	}}
+
+	removed := Apply(tr, []Zone{{Name: "Home", Lat: 35, Lon: 139, Radius: 150}})
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+	if len(tr.Points) != 2 || tr.Points[0].Lat != 35.002 {
+		t.Fatalf("kept points = %+v", tr.Points)
+	}
+	if tr.Points[0].Means == nil || *tr.Points[0].Means != walking {
+		t.Errorf("means was not carried over to the first kept point")
+	}
+	if tr.Points[0].Desc != "" {
+		t.Errorf("Desc = %q, want memo inside the zone dropped", tr.Points[0].Desc)
+	}
+	if tr.Points[1].Means != nil {
+		t.Errorf("means carried beyond the first kept point")
+	}
+
+	if n := Apply(tr, nil); n != 0 || len(tr.Points) != 2 {
+		t.Errorf("Apply(nil zones) removed %d points", n)
+	}
+}
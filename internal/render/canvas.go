@@ -0,0 +1,36 @@
+package render
+
+// canvas is the drawing surface shared by the SVG and PNG backends, so
+// map layout is written once. Coordinates are pixels with y growing
+// downwards; colors are "#rrggbb" strings.
+type canvas interface {
+	background(color string)
+	// path strokes an open polyline with round joins and caps.
+	path(xs, ys []float64, color string, width float64)
+	circle(x, y, r float64, fill, stroke string, strokeWidth float64)
+	// text draws s with its baseline at y; anchor is "start", "middle" or "end".
+	text(x, y float64, anchor, color, s string)
+}
+
+// svgCanvas draws onto an svgWriter.
+type svgCanvas struct {
+	s *svgWriter
+}
+
+func (c svgCanvas) background(color string) {
+	c.s.printf(`<rect width="100%%" height="100%%" fill="%s"/>`+"\n", color)
+}
+
+func (c svgCanvas) path(xs, ys []float64, color string, width float64) {
+	c.s.printf(`<polyline fill="none" stroke="%s" stroke-width="%s" stroke-linejoin="round" stroke-linecap="round" points="%s"/>`+"\n",
+		color, num(width), polylinePoints(xs, ys))
+}
+
+func (c svgCanvas) circle(x, y, r float64, fill, stroke string, strokeWidth float64) {
+	c.s.printf(`<circle cx="%s" cy="%s" r="%s" fill="%s" stroke="%s" stroke-width="%s"/>`+"\n",
+		num(x), num(y), num(r), fill, stroke, num(strokeWidth))
+}
+
+func (c svgCanvas) text(x, y float64, anchor, color, s string) {
+	c.s.text(x, y, anchor, color, s)
+}
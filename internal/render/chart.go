@@ -0,0 +1,253 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/chocoby/zweg/internal/i18n"
+)
+
+// ErrNoData is returned for a chart with no values to plot, such as an
+// elevation profile of a log without altitudes.
+var ErrNoData = errors.New("no data to plot")
+
+// Series is one line in a Chart. X and Y must have the same length.
+type Series struct {
+	Name  string
+	X     []float64
+	Y     []float64
+	Color string
+	// Secondary plots the series against the right-hand axis.
+	Secondary bool
+}
+
+// Chart is a simple line chart with labelled axes.
+type Chart struct {
+	Title  string
+	XLabel string
+	YLabel string
+	// Y2Label labels the right-hand axis used by secondary series.
+	Y2Label string
+	Width   int
+	Height  int
+	// XFormat formats x-axis tick labels. Defaults to a plain number.
+	XFormat func(float64) string
+	Series  []Series
+}
+
+// Translate translates the title, axis labels and series names of c into
+// lang.
+func (c *Chart) Translate(lang i18n.Lang) {
+	c.Title = lang.T(c.Title)
+	c.XLabel = lang.T(c.XLabel)
+	c.YLabel = lang.T(c.YLabel)
+	c.Y2Label = lang.T(c.Y2Label)
+	for i := range c.Series {
+		c.Series[i].Name = lang.T(c.Series[i].Name)
+	}
+}
+
+const (
+	chartLeft   = 56.0
+	chartRight  = 16.0
+	chartTop    = 28.0
+	chartBottom = 40.0
+	chartTicks  = 5
+	axisColor   = "#868e96"
+	gridColor   = "#e9ecef"
+)
+
+// WriteSVG draws the chart as an SVG document.
+func (c *Chart) WriteSVG(w io.Writer) error {
+	xMin, xMax, yMin, yMax, ok := c.extent(false)
+	if !ok {
+		return fmt.Errorf("chart %q: %w", c.Title, ErrNoData)
+	}
+	x2Min, x2Max, y2Min, y2Max, secondary := c.extent(true)
+	if secondary {
+		xMin, xMax = math.Min(xMin, x2Min), math.Max(xMax, x2Max)
+	}
+
+	right := chartRight
+	if secondary {
+		right = chartLeft
+	}
+	plotW := float64(c.Width) - chartLeft - right
+	plotH := float64(c.Height) - chartTop - chartBottom
+
+	xTicks := niceTicks(xMin, xMax, chartTicks)
+	yTicks := niceTicks(yMin, yMax, chartTicks)
+	xMin, xMax = math.Min(xMin, xTicks[0]), math.Max(xMax, xTicks[len(xTicks)-1])
+	yMin, yMax = yTicks[0], yTicks[len(yTicks)-1]
+
+	// The secondary axis gets as many ticks as the primary so both share
+	// the grid lines.
+	var y2Ticks []float64
+	if secondary {
+		y2Ticks = alignedTicks(y2Min, y2Max, len(yTicks)-1)
+		y2Min, y2Max = y2Ticks[0], y2Ticks[len(y2Ticks)-1]
+	}
+
+	px := func(x float64) float64 { return chartLeft + scale(x, xMin, xMax)*plotW }
+	py := func(y float64) float64 { return chartTop + plotH - scale(y, yMin, yMax)*plotH }
+	py2 := func(y float64) float64 { return chartTop + plotH - scale(y, y2Min, y2Max)*plotH }
+
+	xFormat := c.XFormat
+	if xFormat == nil {
+		xFormat = formatTick
+	}
+
+	s := &svgWriter{w: w}
+	s.open(c.Width, c.Height)
+	s.printf(`<rect width="100%%" height="100%%" fill="#fff"/>` + "\n")
+	if c.Title != "" {
+		s.text(chartLeft, 18, "start", "#212529", c.Title)
+	}
+
+	for _, y := range yTicks {
+		s.printf(`<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s"/>`+"\n", num(chartLeft), num(py(y)), num(chartLeft+plotW), num(py(y)), gridColor)
+		s.text(chartLeft-6, py(y)+4, "end", axisColor, formatTick(y))
+	}
+	for _, y := range y2Ticks {
+		s.text(chartLeft+plotW+6, py2(y)+4, "start", axisColor, formatTick(y))
+	}
+	for _, x := range xTicks {
+		if x < xMin || x > xMax {
+			continue
+		}
+		s.text(px(x), chartTop+plotH+16, "middle", axisColor, xFormat(x))
+	}
+	s.printf(`<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s"/>`+"\n", num(chartLeft), num(chartTop+plotH), num(chartLeft+plotW), num(chartTop+plotH), axisColor)
+	s.printf(`<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s"/>`+"\n", num(chartLeft), num(chartTop), num(chartLeft), num(chartTop+plotH), axisColor)
+
+	if c.XLabel != "" {
+		s.text(chartLeft+plotW/2, float64(c.Height)-6, "middle", axisColor, c.XLabel)
+	}
+	if c.YLabel != "" {
+		s.printf(`<text transform="translate(14 %s) rotate(-90)" text-anchor="middle" fill="%s">%s</text>`+"\n",
+			num(chartTop+plotH/2), axisColor, escape(c.YLabel))
+	}
+	if secondary {
+		s.printf(`<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s"/>`+"\n", num(chartLeft+plotW), num(chartTop), num(chartLeft+plotW), num(chartTop+plotH), axisColor)
+		if c.Y2Label != "" {
+			s.printf(`<text transform="translate(%s %s) rotate(90)" text-anchor="middle" fill="%s">%s</text>`+"\n",
+				num(float64(c.Width)-14), num(chartTop+plotH/2), axisColor, escape(c.Y2Label))
+		}
+	}
+
+	for _, sr := range c.Series {
+		toY := py
+		if sr.Secondary {
+			toY = py2
+		}
+		xs := make([]float64, len(sr.X))
+		ys := make([]float64, len(sr.Y))
+		for i := range sr.X {
+			xs[i], ys[i] = px(sr.X[i]), toY(sr.Y[i])
+		}
+		s.printf(`<polyline fill="none" stroke="%s" stroke-width="1.5" stroke-linejoin="round" points="%s"/>`+"\n",
+			sr.Color, polylinePoints(xs, ys))
+	}
+
+	// A legend is only needed to tell several series apart.
+	if len(c.Series) > 1 {
+		x := chartLeft + plotW
+		for i := len(c.Series) - 1; i >= 0; i-- {
+			s.text(x, 18, "end", c.Series[i].Color, c.Series[i].Name)
+			x -= float64(len(c.Series[i].Name))*7 + 16
+		}
+	}
+
+	s.close()
+	return s.err
+}
+
+// extent returns the data range over the primary or secondary series.
+func (c *Chart) extent(secondary bool) (xMin, xMax, yMin, yMax float64, ok bool) {
+	xMin, yMin = math.Inf(1), math.Inf(1)
+	xMax, yMax = math.Inf(-1), math.Inf(-1)
+	for _, sr := range c.Series {
+		if sr.Secondary != secondary {
+			continue
+		}
+		for i := range sr.X {
+			xMin, xMax = math.Min(xMin, sr.X[i]), math.Max(xMax, sr.X[i])
+			yMin, yMax = math.Min(yMin, sr.Y[i]), math.Max(yMax, sr.Y[i])
+			ok = true
+		}
+	}
+	return xMin, xMax, yMin, yMax, ok
+}
+
+// scale maps v from [lo, hi] to [0, 1], centring degenerate ranges.
+func scale(v, lo, hi float64) float64 {
+	if hi <= lo {
+		return 0.5
+	}
+	return (v - lo) / (hi - lo)
+}
+
+// niceTicks returns about n evenly spaced round tick values covering [lo, hi].
+func niceTicks(lo, hi float64, n int) []float64 {
+	if hi <= lo {
+		return []float64{lo - 1, lo, lo + 1}
+	}
+	step := niceStep((hi - lo) / float64(n))
+	start := math.Floor(lo/step) * step
+	end := math.Ceil(hi/step) * step
+
+	var ticks []float64
+	for v := start; v <= end+step/2; v += step {
+		// Snap to the step grid to avoid 0.30000000000000004-style labels.
+		ticks = append(ticks, math.Round(v/step)*step)
+	}
+	return ticks
+}
+
+// alignedTicks returns n+1 evenly spaced round ticks starting at or below
+// lo and reaching at least hi.
+func alignedTicks(lo, hi float64, n int) []float64 {
+	if n < 1 {
+		n = 1
+	}
+	if hi <= lo {
+		hi = lo + 1
+	}
+	step := niceStep((hi - lo) / float64(n))
+	start := math.Floor(lo/step) * step
+	for start+step*float64(n) < hi {
+		step = niceStep(step * 1.01)
+		start = math.Floor(lo/step) * step
+	}
+
+	ticks := make([]float64, n+1)
+	for i := range ticks {
+		ticks[i] = math.Round((start+step*float64(i))/step) * step
+	}
+	return ticks
+}
+
+// niceStep rounds raw up to 1, 2 or 5 times a power of ten.
+func niceStep(raw float64) float64 {
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch f := raw / mag; {
+	case f <= 1:
+		return mag
+	case f <= 2:
+		return 2 * mag
+	case f <= 5:
+		return 5 * mag
+	default:
+		return 10 * mag
+	}
+}
+
+func formatTick(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
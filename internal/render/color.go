@@ -0,0 +1,111 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/chocoby/zweg/internal/models"
+)
+
+// ColorBy selects how the track line is coloured.
+type ColorBy string
+
+const (
+	ColorBySolid     ColorBy = "solid"
+	ColorBySpeed     ColorBy = "speed"
+	ColorByElevation ColorBy = "elevation"
+	ColorByMeans     ColorBy = "means"
+)
+
+// ParseColorBy validates a ColorBy name; the empty string means solid.
+func ParseColorBy(s string) (ColorBy, error) {
+	switch c := ColorBy(s); c {
+	case "":
+		return ColorBySolid, nil
+	case ColorBySolid, ColorBySpeed, ColorByElevation, ColorByMeans:
+		return c, nil
+	default:
+		return "", fmt.Errorf("unknown color mode %q (expected solid, speed, elevation or means)", s)
+	}
+}
+
+// ParseColor validates a colour given as #rrggbb and returns it in lower
+// case.
+func ParseColor(s string) (string, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return "", fmt.Errorf("invalid color %q (expected #rrggbb)", s)
+	}
+	if _, err := strconv.ParseUint(s[1:], 16, 32); err != nil {
+		return "", fmt.Errorf("invalid color %q (expected #rrggbb)", s)
+	}
+	return strings.ToLower(s), nil
+}
+
+// gradientStops run from low (blue) through green and amber to high (red).
+var gradientStops = []color.RGBA{
+	{0x19, 0x71, 0xc2, 0xff},
+	{0x2f, 0x9e, 0x44, 0xff},
+	{0xf0, 0x8c, 0x00, 0xff},
+	{0xe0, 0x31, 0x31, 0xff},
+}
+
+// gradientLevels quantizes the gradient so neighbouring segments of
+// similar value share a colour and can be drawn as one path.
+const gradientLevels = 24
+
+// gradient returns the colour for f in [0, 1].
+func gradient(f float64) string {
+	f = math.Max(0, math.Min(1, f))
+	f = math.Round(f*gradientLevels) / gradientLevels
+
+	pos := f * float64(len(gradientStops)-1)
+	i := int(pos)
+	if i >= len(gradientStops)-1 {
+		i = len(gradientStops) - 2
+	}
+	t := pos - float64(i)
+	a, b := gradientStops[i], gradientStops[i+1]
+	lerp := func(x, y uint8) uint8 { return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t)) }
+	return hexColor(color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 0xff})
+}
+
+var meansColors = map[models.Means]string{
+	models.MeansWalking:    "#2f9e44",
+	models.MeansJogging:    "#f08c00",
+	models.MeansBicycle:    "#1971c2",
+	models.MeansMotorCycle: "#9c36b5",
+	models.MeansAutoMobile: "#e03131",
+	models.MeansTrain:      "#495057",
+	models.MeansMisc:       "#868e96",
+}
+
+// meansColor returns the colour of a means of transportation, or fallback
+// when it is unknown.
+func meansColor(m *models.Means, fallback string) string {
+	if m == nil {
+		return fallback
+	}
+	if c, ok := meansColors[*m]; ok {
+		return c
+	}
+	return fallback
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// parseHex parses "#rrggbb"; anything else is black.
+func parseHex(s string) color.RGBA {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{A: 0xff}
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{A: 0xff}
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}
+}
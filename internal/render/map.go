@@ -0,0 +1,208 @@
+package render
+
+import (
+	"fmt"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/stats"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// MaxSize is the largest width or height, in pixels, of an image or chart;
+// a PNG of MaxSize by MaxSize already takes 256 MiB to draw.
+const MaxSize = 8192
+
+// MapOptions controls track drawing.
+type MapOptions struct {
+	Width     int
+	Height    int
+	Padding   float64
+	Color     string
+	LineWidth float64
+	// ColorBy colours the line by speed, elevation or means instead of Color.
+	ColorBy ColorBy
+	// Markers draws Start and Goal markers.
+	Markers bool
+	// ScaleBar draws a distance scale in the bottom-left corner.
+	ScaleBar bool
+	// Lang is the language of the marker labels. PNG images, drawn with a
+	// bitmap font that only covers ASCII, are always labelled in English.
+	Lang i18n.Lang
+}
+
+// DefaultMapOptions returns the options used by the HTML report.
+func DefaultMapOptions() MapOptions {
+	return MapOptions{
+		Width:     640,
+		Height:    480,
+		Padding:   24,
+		Color:     "#d6336c",
+		LineWidth: 3,
+		ColorBy:   ColorBySolid,
+		Markers:   true,
+		ScaleBar:  true,
+	}
+}
+
+const (
+	mapBackground = "#f8f9fa"
+	startColor    = "#2b8a3e"
+	goalColor     = "#c92a2a"
+	scaleColor    = "#343a40"
+)
+
+// MapSVG draws the track as an SVG document.
+func MapSVG(w io.Writer, t *track.Track, opts MapOptions) error {
+	if len(t.Points) == 0 {
+		return fmt.Errorf("no data points provided")
+	}
+
+	s := &svgWriter{w: w}
+	s.open(opts.Width, opts.Height)
+	drawMap(svgCanvas{s}, t, opts)
+	s.close()
+	return s.err
+}
+
+// MapPNG draws the track as a PNG image.
+func MapPNG(w io.Writer, t *track.Track, opts MapOptions) error {
+	if len(t.Points) == 0 {
+		return fmt.Errorf("no data points provided")
+	}
+
+	c := newRasterCanvas(opts.Width, opts.Height)
+	opts.Lang = i18n.English
+	drawMap(c, t, opts)
+	if err := png.Encode(w, c.img); err != nil {
+		return fmt.Errorf("failed to write PNG: %w", err)
+	}
+	return nil
+}
+
+func drawMap(c canvas, t *track.Track, opts MapOptions) {
+	pr := NewProjection(t.Points, float64(opts.Width), float64(opts.Height), opts.Padding)
+	xs := make([]float64, len(t.Points))
+	ys := make([]float64, len(t.Points))
+	for i, p := range t.Points {
+		xs[i], ys[i] = pr.Point(p.Lat, p.Lon)
+	}
+
+	c.background(mapBackground)
+
+	colors := segmentColors(t, opts)
+	// Draw runs of equally coloured segments as one path.
+	for start := 0; start < len(xs)-1; {
+		end := start + 1
+		for end < len(xs)-1 && colors[end+1] == colors[start+1] {
+			end++
+		}
+		c.path(xs[start:end+1], ys[start:end+1], colors[start+1], opts.LineWidth)
+		start = end
+	}
+
+	if opts.ScaleBar {
+		drawScaleBar(c, pr, opts)
+	}
+
+	if opts.Markers {
+		last := len(xs) - 1
+		drawMarker(c, xs[last], ys[last], goalColor, opts.Lang.T("Goal"))
+		drawMarker(c, xs[0], ys[0], startColor, opts.Lang.T("Start"))
+	}
+}
+
+// segmentColors returns the colour of the segment ending at each point.
+// Index 0 has no segment and is unused.
+func segmentColors(t *track.Track, opts MapOptions) []string {
+	colors := make([]string, len(t.Points))
+
+	var values []float64
+	switch opts.ColorBy {
+	case ColorBySpeed:
+		values = stats.Speeds(t)
+	case ColorByElevation:
+		values = make([]float64, len(t.Points))
+		for i, p := range t.Points {
+			values[i] = math.NaN()
+			if p.HasEle {
+				values[i] = p.Ele
+			}
+		}
+	case ColorByMeans:
+		means := t.Points[0].Means
+		for i, p := range t.Points {
+			if p.Means != nil {
+				means = p.Means
+			}
+			colors[i] = meansColor(means, opts.Color)
+		}
+		return colors
+	default:
+		for i := range colors {
+			colors[i] = opts.Color
+		}
+		return colors
+	}
+
+	// NaN marks a point without a value, drawn in the plain colour.
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	for i, v := range values {
+		if math.IsNaN(v) {
+			colors[i] = opts.Color
+			continue
+		}
+		colors[i] = gradient(scale(v, lo, hi))
+	}
+	return colors
+}
+
+func drawMarker(c canvas, x, y float64, color, label string) {
+	c.circle(x, y, 6, color, "#ffffff", 2)
+	c.text(x, y-10, "middle", color, label)
+}
+
+// drawScaleBar draws the longest round distance that fits in a quarter of
+// the map width.
+func drawScaleBar(c canvas, pr *Projection, opts MapOptions) {
+	maxMeters := float64(opts.Width) / 4 * pr.MetersPerPixel()
+	meters := roundDistance(maxMeters)
+	if meters <= 0 {
+		return
+	}
+	length := meters / pr.MetersPerPixel()
+
+	x := opts.Padding / 2
+	y := float64(opts.Height) - opts.Padding/2
+	c.path([]float64{x, x, x + length, x + length}, []float64{y - 5, y, y, y - 5}, scaleColor, 1.5)
+	c.text(x+length/2, y-4, "middle", scaleColor, formatDistance(meters))
+}
+
+// roundDistance returns the largest 1, 2 or 5 × 10^n meters not above max.
+func roundDistance(max float64) float64 {
+	if max <= 0 {
+		return 0
+	}
+	mag := math.Pow(10, math.Floor(math.Log10(max)))
+	for _, f := range []float64{5, 2, 1} {
+		if f*mag <= max {
+			return f * mag
+		}
+	}
+	return mag
+}
+
+func formatDistance(meters float64) string {
+	if meters >= 1000 {
+		return strconv.FormatFloat(meters/1000, 'f', -1, 64) + " km"
+	}
+	return strconv.FormatFloat(meters, 'f', -1, 64) + " m"
+}
@@ -0,0 +1,160 @@
+package render
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/chocoby/zweg/internal/stats"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+const (
+	elevationColor = "#1971c2"
+	speedColor     = "#e8590c"
+	cadenceColor   = "#2f9e44"
+)
+
+// Metric selects what a profile chart plots.
+type Metric string
+
+const (
+	MetricElevation Metric = "elevation"
+	MetricSpeed     Metric = "speed"
+	MetricCadence   Metric = "cadence"
+)
+
+// ParseMetric validates a Metric name.
+func ParseMetric(s string) (Metric, error) {
+	switch m := Metric(s); m {
+	case MetricElevation, MetricSpeed, MetricCadence:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown metric %q (expected elevation, speed or cadence)", s)
+	}
+}
+
+// ProfileChart returns the chart for metric. Elevation is plotted against
+// distance, speed and cadence against elapsed time. With cadence set, step
+// cadence is overlaid on a secondary axis of the elevation or speed chart;
+// it is an error when the log records no steps. A chart with nothing to
+// plot fails with ErrNoData.
+func ProfileChart(t *track.Track, metric Metric, width, height int, cadence bool) (*Chart, error) {
+	if len(t.Points) == 0 {
+		return nil, fmt.Errorf("no data points provided")
+	}
+	if (cadence || metric == MetricCadence) && !stats.HasSteps(t) {
+		return nil, fmt.Errorf("no step counts (ws) recorded: %w", ErrNoData)
+	}
+
+	var c *Chart
+	switch metric {
+	case MetricElevation:
+		c = ElevationChart(t, width, height)
+	case MetricSpeed:
+		c = SpeedChart(t, width, height)
+	case MetricCadence:
+		return CadenceChart(t, width, height), nil
+	default:
+		return nil, fmt.Errorf("unknown metric %q", metric)
+	}
+	if _, _, _, _, ok := c.extent(false); !ok {
+		return nil, fmt.Errorf("no %s recorded: %w", metric, ErrNoData)
+	}
+
+	if cadence {
+		y := stats.Cadence(t)
+		if metric == MetricElevation {
+			y = withElevation(t, y)
+		}
+		s := cadenceSeries(c.Series[0].X, y)
+		s.Secondary = true
+		c.Series = append(c.Series, s)
+		c.Y2Label = "Cadence (steps/min)"
+	}
+	return c, nil
+}
+
+// ElevationChart plots elevation in meters against distance in kilometers.
+// Points without an elevation are left out.
+func ElevationChart(t *track.Track, width, height int) *Chart {
+	s := Series{Name: "Elevation", Color: elevationColor}
+	for _, d := range withElevation(t, stats.CumulativeDistances(t)) {
+		s.X = append(s.X, d/1000)
+	}
+	for _, ele := range stats.Elevations(t) {
+		if !math.IsNaN(ele) {
+			s.Y = append(s.Y, ele)
+		}
+	}
+	return &Chart{
+		Title:  "Elevation",
+		XLabel: "Distance (km)",
+		YLabel: "Elevation (m)",
+		Width:  width,
+		Height: height,
+		Series: []Series{s},
+	}
+}
+
+// SpeedChart plots speed in km/h against elapsed time.
+func SpeedChart(t *track.Track, width, height int) *Chart {
+	speeds := stats.Speeds(t)
+	s := Series{Name: "Speed", Color: speedColor, X: elapsedMinutes(t)}
+	for i := range t.Points {
+		s.Y = append(s.Y, speeds[i]*3.6)
+	}
+	return &Chart{
+		Title:   "Speed",
+		XLabel:  "Elapsed time (h:mm)",
+		YLabel:  "Speed (km/h)",
+		Width:   width,
+		Height:  height,
+		XFormat: formatMinutes,
+		Series:  []Series{s},
+	}
+}
+
+// CadenceChart plots step cadence in steps per minute against elapsed time.
+func CadenceChart(t *track.Track, width, height int) *Chart {
+	return &Chart{
+		Title:   "Cadence",
+		XLabel:  "Elapsed time (h:mm)",
+		YLabel:  "Cadence (steps/min)",
+		Width:   width,
+		Height:  height,
+		XFormat: formatMinutes,
+		Series:  []Series{cadenceSeries(elapsedMinutes(t), stats.Cadence(t))},
+	}
+}
+
+// cadenceSeries pairs cadence values with xs.
+func cadenceSeries(xs, cadence []float64) Series {
+	return Series{Name: "Cadence", Color: cadenceColor, X: xs, Y: cadence}
+}
+
+// withElevation keeps the values of the points of t that have an
+// elevation, the points ElevationChart plots.
+func withElevation(t *track.Track, values []float64) []float64 {
+	var out []float64
+	for i, ele := range stats.Elevations(t) {
+		if !math.IsNaN(ele) {
+			out = append(out, values[i])
+		}
+	}
+	return out
+}
+
+func elapsedMinutes(t *track.Track) []float64 {
+	start := t.Start()
+	out := make([]float64, len(t.Points))
+	for i, p := range t.Points {
+		out[i] = p.Time.Sub(start).Minutes()
+	}
+	return out
+}
+
+// formatMinutes formats elapsed minutes as h:mm.
+func formatMinutes(m float64) string {
+	total := int(m + 0.5)
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
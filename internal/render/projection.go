@@ -0,0 +1,83 @@
+// Package render draws tracks and profile charts as SVG without any
+// online map tiles.
+package render
+
+import (
+	"math"
+
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// Projection maps latitude/longitude to pixel coordinates with a local
+// equirectangular projection centred on the track. Distortion is negligible
+// at the scale of a single outing, and both axes share one meters-per-pixel
+// ratio so the track keeps its shape.
+type Projection struct {
+	cosLat  float64
+	minX    float64
+	maxY    float64
+	scale   float64 // pixels per meter
+	offsetX float64
+	offsetY float64
+}
+
+// NewProjection fits points into a width×height canvas with padding pixels
+// of margin on every side.
+func NewProjection(points []track.Point, width, height, padding float64) *Projection {
+	if len(points) == 0 {
+		return &Projection{cosLat: 1, scale: 1}
+	}
+
+	minLat, maxLat := points[0].Lat, points[0].Lat
+	for _, p := range points {
+		minLat = math.Min(minLat, p.Lat)
+		maxLat = math.Max(maxLat, p.Lat)
+	}
+	pr := &Projection{cosLat: math.Cos((minLat + maxLat) / 2 * math.Pi / 180)}
+
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		x, y := pr.meters(p.Lat, p.Lon)
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	innerW := math.Max(width-2*padding, 1)
+	innerH := math.Max(height-2*padding, 1)
+	spanX, spanY := maxX-minX, maxY-minY
+
+	pr.scale = math.Inf(1)
+	if spanX > 0 {
+		pr.scale = innerW / spanX
+	}
+	if spanY > 0 {
+		pr.scale = math.Min(pr.scale, innerH/spanY)
+	}
+	if math.IsInf(pr.scale, 1) {
+		// A single position: any scale works, pick 1 px per meter.
+		pr.scale = 1
+	}
+
+	pr.minX, pr.maxY = minX, maxY
+	pr.offsetX = padding + (innerW-spanX*pr.scale)/2
+	pr.offsetY = padding + (innerH-spanY*pr.scale)/2
+	return pr
+}
+
+// meters returns the projected position in meters east and north.
+func (pr *Projection) meters(lat, lon float64) (x, y float64) {
+	return geo.EarthRadius * lon * math.Pi / 180 * pr.cosLat, geo.EarthRadius * lat * math.Pi / 180
+}
+
+// Point returns the pixel position of lat/lon; y grows downwards.
+func (pr *Projection) Point(lat, lon float64) (x, y float64) {
+	mx, my := pr.meters(lat, lon)
+	return pr.offsetX + (mx-pr.minX)*pr.scale, pr.offsetY + (pr.maxY-my)*pr.scale
+}
+
+// MetersPerPixel returns the ground distance covered by one pixel.
+func (pr *Projection) MetersPerPixel() float64 {
+	return 1 / pr.scale
+}
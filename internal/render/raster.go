@@ -0,0 +1,125 @@
+package render
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// circleSegments is the number of polygon edges used to approximate circles.
+const circleSegments = 24
+
+// rasterCanvas draws anti-aliased shapes onto an RGBA image.
+type rasterCanvas struct {
+	img *image.RGBA
+}
+
+func newRasterCanvas(width, height int) *rasterCanvas {
+	return &rasterCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+}
+
+func (c *rasterCanvas) background(color string) {
+	draw.Draw(c.img, c.img.Bounds(), image.NewUniform(parseHex(color)), image.Point{}, draw.Src)
+}
+
+// fill composites the shapes accumulated in r with a solid colour. Shapes
+// added to one rasterizer merge rather than darken where they overlap, as
+// long as they share a winding direction.
+func (c *rasterCanvas) fill(r *vector.Rasterizer, color string) {
+	r.Draw(c.img, c.img.Bounds(), image.NewUniform(parseHex(color)), image.Point{})
+}
+
+func (c *rasterCanvas) rasterizer() *vector.Rasterizer {
+	b := c.img.Bounds()
+	return vector.NewRasterizer(b.Dx(), b.Dy())
+}
+
+func (c *rasterCanvas) path(xs, ys []float64, color string, width float64) {
+	r := c.rasterizer()
+	half := width / 2
+	for i := range xs {
+		addCircle(r, xs[i], ys[i], half)
+		if i == 0 {
+			continue
+		}
+		dx, dy := xs[i]-xs[i-1], ys[i]-ys[i-1]
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+		nx, ny := -dy/length*half, dx/length*half
+		addPolygon(r,
+			[2]float64{xs[i-1] + nx, ys[i-1] + ny},
+			[2]float64{xs[i] + nx, ys[i] + ny},
+			[2]float64{xs[i] - nx, ys[i] - ny},
+			[2]float64{xs[i-1] - nx, ys[i-1] - ny},
+		)
+	}
+	c.fill(r, color)
+}
+
+func (c *rasterCanvas) circle(x, y, radius float64, fill, stroke string, strokeWidth float64) {
+	if strokeWidth > 0 {
+		r := c.rasterizer()
+		addCircle(r, x, y, radius+strokeWidth/2)
+		c.fill(r, stroke)
+	}
+	r := c.rasterizer()
+	addCircle(r, x, y, radius-strokeWidth/2)
+	c.fill(r, fill)
+}
+
+func (c *rasterCanvas) text(x, y float64, anchor, color, s string) {
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(parseHex(color)),
+		Face: basicfont.Face7x13,
+	}
+	width := float64(d.MeasureString(s)) / 64
+	switch anchor {
+	case "middle":
+		x -= width / 2
+	case "end":
+		x -= width
+	}
+	d.Dot = fixed.P(int(math.Round(x)), int(math.Round(y)))
+	d.DrawString(s)
+}
+
+// addPolygon adds a closed polygon, normalised to a positive winding so
+// overlapping shapes accumulate instead of cancelling.
+func addPolygon(r *vector.Rasterizer, pts ...[2]float64) {
+	var area float64
+	for i := range pts {
+		j := (i + 1) % len(pts)
+		area += pts[i][0]*pts[j][1] - pts[j][0]*pts[i][1]
+	}
+	if area < 0 {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+
+	r.MoveTo(float32(pts[0][0]), float32(pts[0][1]))
+	for _, p := range pts[1:] {
+		r.LineTo(float32(p[0]), float32(p[1]))
+	}
+	r.ClosePath()
+}
+
+func addCircle(r *vector.Rasterizer, x, y, radius float64) {
+	if radius <= 0 {
+		return
+	}
+	pts := make([][2]float64, circleSegments)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / circleSegments
+		pts[i] = [2]float64{x + radius*math.Cos(a), y + radius*math.Sin(a)}
+	}
+	addPolygon(r, pts...)
+}
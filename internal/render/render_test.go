@@ -0,0 +1,347 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+func testTrack() *track.Track {
+	base := time.Unix(1609459200, 0).UTC()
+	return &track.Track{Points: []track.Point{
+		{Time: base, Lat: 35.000, Lon: 139.000, Ele: 10, HasEle: true},
+		{Time: base.Add(time.Minute), Lat: 35.001, Lon: 139.000, Ele: 20, HasEle: true},
+		{Time: base.Add(2 * time.Minute), Lat: 35.001, Lon: 139.002, Ele: 15, HasEle: true},
+	}}
+}
+
+// wellFormed fails the test when doc is not parseable XML.
+func wellFormed(t *testing.T, doc string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		if _, err := d.Token(); err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatalf("invalid SVG: %v\n%s", err, doc)
+		}
+	}
+}
+
+func TestProjection_KeepsAspectAndFits(t *testing.T) {
+	pts := testTrack().Points
+	pr := NewProjection(pts, 400, 300, 10)
+
+	for _, p := range pts {
+		x, y := pr.Point(p.Lat, p.Lon)
+		if x < 10-1e-9 || x > 390+1e-9 || y < 10-1e-9 || y > 290+1e-9 {
+			t.Errorf("Point(%v, %v) = (%v, %v), outside padded canvas", p.Lat, p.Lon, x, y)
+		}
+	}
+
+	// North is up: the second point is north of the first.
+	_, y0 := pr.Point(pts[0].Lat, pts[0].Lon)
+	_, y1 := pr.Point(pts[1].Lat, pts[1].Lon)
+	if y1 >= y0 {
+		t.Errorf("northward point y = %v, want less than %v", y1, y0)
+	}
+
+	// One scale for both axes: ~111 m north and ~182 m east.
+	x1, _ := pr.Point(pts[1].Lat, pts[1].Lon)
+	x2, _ := pr.Point(pts[2].Lat, pts[2].Lon)
+	ratio := (x2 - x1) / (y0 - y1)
+	if math.Abs(ratio-182.2/111.2) > 0.02 {
+		t.Errorf("east/north pixel ratio = %v, want about %v", ratio, 182.2/111.2)
+	}
+	if mpp := pr.MetersPerPixel(); math.Abs(mpp-182.2/380) > 0.01 {
+		t.Errorf("MetersPerPixel() = %v, want about %v", mpp, 182.2/380)
+	}
+}
+
+func TestProjection_SinglePoint(t *testing.T) {
+	pts := testTrack().Points[:1]
+	x, y := NewProjection(pts, 100, 100, 10).Point(pts[0].Lat, pts[0].Lon)
+	if x != 50 || y != 50 {
+		t.Errorf("single point at (%v, %v), want centred (50, 50)", x, y)
+	}
+}
+
+func TestMapSVG(t *testing.T) {
+	var b strings.Builder
+	if err := MapSVG(&b, testTrack(), DefaultMapOptions()); err != nil {
+		t.Fatalf("MapSVG: %v", err)
+	}
+	wellFormed(t, b.String())
+	for _, want := range []string{"<polyline", ">Start</text>", ">Goal</text>"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("SVG missing %q", want)
+		}
+	}
+
+	if err := MapSVG(&b, &track.Track{}, DefaultMapOptions()); err == nil {
+		t.Error("MapSVG(empty) error = nil, want error")
+	}
+
+	b.Reset()
+	opts := DefaultMapOptions()
+	opts.Lang = i18n.Japanese
+	if err := MapSVG(&b, testTrack(), opts); err != nil {
+		t.Fatalf("MapSVG: %v", err)
+	}
+	for _, want := range []string{">スタート</text>", ">ゴール</text>"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Japanese SVG missing %q", want)
+		}
+	}
+}
+
+func TestCharts(t *testing.T) {
+	for _, c := range []*Chart{ElevationChart(testTrack(), 600, 200), SpeedChart(testTrack(), 600, 200)} {
+		var b strings.Builder
+		if err := c.WriteSVG(&b); err != nil {
+			t.Fatalf("%s: WriteSVG: %v", c.Title, err)
+		}
+		wellFormed(t, b.String())
+		if !strings.Contains(b.String(), c.YLabel) {
+			t.Errorf("%s chart missing y label", c.Title)
+		}
+	}
+
+	tr := testTrack()
+	tr.Points[1].HasEle = false
+	if s := ElevationChart(tr, 600, 200).Series[0]; len(s.X) != 2 || len(s.Y) != 2 || s.Y[1] != 15 {
+		t.Errorf("elevation series = %v, %v, want the point without elevation left out", s.X, s.Y)
+	}
+
+	empty := &Chart{Title: "Empty", Width: 100, Height: 100}
+	if err := empty.WriteSVG(io.Discard); err == nil {
+		t.Error("WriteSVG(no data) error = nil, want error")
+	}
+}
+
+func TestNiceTicks(t *testing.T) {
+	tests := []struct {
+		lo, hi float64
+		want   []float64
+	}{
+		{0, 10, []float64{0, 2, 4, 6, 8, 10}},
+		{3, 97, []float64{0, 20, 40, 60, 80, 100}},
+		{0.1, 0.35, []float64{0.1, 0.15, 0.2, 0.25, 0.3, 0.35}},
+		{5, 5, []float64{4, 5, 6}},
+	}
+
+	for _, tt := range tests {
+		got := niceTicks(tt.lo, tt.hi, 5)
+		if len(got) != len(tt.want) {
+			t.Errorf("niceTicks(%v, %v) = %v, want %v", tt.lo, tt.hi, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+				t.Errorf("niceTicks(%v, %v) = %v, want %v", tt.lo, tt.hi, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFormatMinutes(t *testing.T) {
+	if got := formatMinutes(75); got != "1:15" {
+		t.Errorf("formatMinutes(75) = %q, want 1:15", got)
+	}
+}
+
+func TestMapPNG(t *testing.T) {
+	opts := DefaultMapOptions()
+	opts.Width, opts.Height = 200, 150
+	opts.ColorBy = ColorBySpeed
+
+	var b bytes.Buffer
+	if err := MapPNG(&b, testTrack(), opts); err != nil {
+		t.Fatalf("MapPNG: %v", err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatalf("png.Decode: %v", err)
+	}
+	if got := img.Bounds().Size(); got.X != 200 || got.Y != 150 {
+		t.Errorf("image size = %v, want 200x150", got)
+	}
+
+	// The Start marker is filled with its colour.
+	pr := NewProjection(testTrack().Points, 200, 150, opts.Padding)
+	x, y := pr.Point(35.000, 139.000)
+	if got := hexColor(color.RGBAModel.Convert(img.At(int(x), int(y))).(color.RGBA)); got != startColor {
+		t.Errorf("pixel at Start = %s, want %s", got, startColor)
+	}
+}
+
+func TestMapSVG_ColorBy(t *testing.T) {
+	walking, train := models.MeansWalking, models.MeansTrain
+	tr := testTrack()
+	tr.Points[0].Means = &walking
+	tr.Points[2].Means = &train
+
+	opts := DefaultMapOptions()
+	opts.ColorBy = ColorByMeans
+	var b strings.Builder
+	if err := MapSVG(&b, tr, opts); err != nil {
+		t.Fatalf("MapSVG: %v", err)
+	}
+	for _, want := range []string{meansColors[walking], meansColors[train]} {
+		if !strings.Contains(b.String(), `stroke="`+want+`"`) {
+			t.Errorf("SVG missing segment colour %s", want)
+		}
+	}
+	if got := strings.Count(b.String(), "<polyline"); got != 3 {
+		t.Errorf("polylines = %d, want 2 track segments and the scale bar", got)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	if got, err := ParseColor("#D6336C"); err != nil || got != "#d6336c" {
+		t.Errorf("ParseColor(#D6336C) = %q, %v, want #d6336c", got, err)
+	}
+	for _, s := range []string{"", "red", "#fff", "#12345g", `#000"/>`, "#+12345"} {
+		if _, err := ParseColor(s); err == nil {
+			t.Errorf("ParseColor(%q) error = nil, want error", s)
+		}
+	}
+}
+
+func TestParseColorBy(t *testing.T) {
+	for _, s := range []string{"", "solid", "speed", "elevation", "means"} {
+		if _, err := ParseColorBy(s); err != nil {
+			t.Errorf("ParseColorBy(%q) error = %v", s, err)
+		}
+	}
+	if _, err := ParseColorBy("rainbow"); err == nil {
+		t.Error("ParseColorBy(rainbow) error = nil, want error")
+	}
+}
+
+func TestGradient(t *testing.T) {
+	if got := gradient(0); got != hexColor(gradientStops[0]) {
+		t.Errorf("gradient(0) = %s, want %s", got, hexColor(gradientStops[0]))
+	}
+	if got := gradient(1); got != hexColor(gradientStops[len(gradientStops)-1]) {
+		t.Errorf("gradient(1) = %s, want %s", got, hexColor(gradientStops[len(gradientStops)-1]))
+	}
+}
+
+func TestScaleBarDistance(t *testing.T) {
+	tests := []struct {
+		max  float64
+		want float64
+		text string
+	}{
+		{45, 20, "20 m"},
+		{730, 500, "500 m"},
+		{1000, 1000, "1 km"},
+		{4800, 2000, "2 km"},
+	}
+	for _, tt := range tests {
+		got := roundDistance(tt.max)
+		if got != tt.want {
+			t.Errorf("roundDistance(%v) = %v, want %v", tt.max, got, tt.want)
+		}
+		if s := formatDistance(got); s != tt.text {
+			t.Errorf("formatDistance(%v) = %q, want %q", got, s, tt.text)
+		}
+	}
+}
+
+func TestProfileChart(t *testing.T) {
+	tr := testTrack()
+	for i, steps := range []int{0, 120, 260} {
+		tr.Points[i].Steps = steps
+	}
+
+	tests := []struct {
+		metric  Metric
+		cadence bool
+		want    []string
+	}{
+		{MetricElevation, false, []string{"Elevation (m)", "Distance (km)"}},
+		{MetricElevation, true, []string{"Elevation (m)", "Cadence (steps/min)", "rotate(90)"}},
+		{MetricSpeed, true, []string{"Speed (km/h)", "Cadence (steps/min)"}},
+		{MetricCadence, false, []string{"Cadence (steps/min)", "Elapsed time (h:mm)"}},
+	}
+	for _, tt := range tests {
+		c, err := ProfileChart(tr, tt.metric, 600, 200, tt.cadence)
+		if err != nil {
+			t.Fatalf("ProfileChart(%s, %v): %v", tt.metric, tt.cadence, err)
+		}
+		var b strings.Builder
+		if err := c.WriteSVG(&b); err != nil {
+			t.Fatalf("WriteSVG: %v", err)
+		}
+		wellFormed(t, b.String())
+		for _, want := range tt.want {
+			if !strings.Contains(b.String(), want) {
+				t.Errorf("%s chart (cadence %v) missing %q", tt.metric, tt.cadence, want)
+			}
+		}
+	}
+
+	if _, err := ProfileChart(testTrack(), MetricCadence, 600, 200, false); err == nil {
+		t.Error("ProfileChart(cadence) without steps error = nil, want error")
+	}
+	if _, err := ParseMetric("heart-rate"); err == nil {
+		t.Error("ParseMetric(heart-rate) error = nil, want error")
+	}
+}
+
+func TestProfileChart_CadenceWithoutElevation(t *testing.T) {
+	// The cadence overlay follows the elevation series, which leaves out
+	// the first point.
+	tr := testTrack()
+	tr.Points[0].HasEle = false
+	for i, steps := range []int{0, 100, 800} {
+		tr.Points[i].Steps = steps
+	}
+	c, err := ProfileChart(tr, MetricElevation, 600, 200, true)
+	if err != nil {
+		t.Fatalf("ProfileChart: %v", err)
+	}
+	elevation, cadence := c.Series[0], c.Series[1]
+	if len(cadence.X) != len(elevation.X) || !slices.Equal(cadence.Y, []float64{100, 700}) {
+		t.Errorf("cadence = %v at %v, want [100 700] at %v", cadence.Y, cadence.X, elevation.X)
+	}
+}
+
+func TestAlignedTicks(t *testing.T) {
+	tests := []struct {
+		lo, hi float64
+		n      int
+		want   []float64
+	}{
+		{0, 150, 5, []float64{0, 50, 100, 150, 200, 250}},
+		{0, 90, 3, []float64{0, 50, 100, 150}},
+		{12, 18, 2, []float64{10, 15, 20}},
+	}
+	for _, tt := range tests {
+		got := alignedTicks(tt.lo, tt.hi, tt.n)
+		if len(got) != len(tt.want) {
+			t.Errorf("alignedTicks(%v, %v, %d) = %v, want %v", tt.lo, tt.hi, tt.n, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+				t.Errorf("alignedTicks(%v, %v, %d) = %v, want %v", tt.lo, tt.hi, tt.n, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// svgWriter accumulates SVG markup and keeps the first write error, so
+// drawing code can stay free of error plumbing.
+type svgWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (s *svgWriter) printf(format string, args ...any) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = fmt.Fprintf(s.w, format, args...)
+}
+
+func (s *svgWriter) open(width, height int) {
+	s.printf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		width, height, width, height)
+}
+
+func (s *svgWriter) close() {
+	s.printf("</svg>\n")
+}
+
+func (s *svgWriter) text(x, y float64, anchor, fill, content string) {
+	s.printf(`<text x="%s" y="%s" text-anchor="%s" fill="%s">%s</text>`+"\n", num(x), num(y), anchor, fill, escape(content))
+}
+
+func escape(s string) string {
+	return html.EscapeString(s)
+}
+
+// num formats a coordinate with one decimal, which is plenty for pixels
+// and keeps output byte-for-byte stable.
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// polylinePoints formats xy pairs for a points attribute.
+func polylinePoints(xs, ys []float64) string {
+	var b strings.Builder
+	for i := range xs {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(num(xs[i]))
+		b.WriteByte(',')
+		b.WriteString(num(ys[i]))
+	}
+	return b.String()
+}
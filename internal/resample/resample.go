@@ -0,0 +1,163 @@
+// Package resample puts a track onto a regular time grid.
+//
+// Grid times start at the first point and advance by a fixed interval.
+// Each grid time falls between two recorded points; its values are either
+// interpolated between them or copied from the nearer one. Grid times
+// inside a recording gap longer than the maximum gap are skipped, so no
+// positions are invented where the device recorded nothing.
+package resample
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// Method selects how values at grid times are derived.
+type Method string
+
+const (
+	// Linear interpolates coordinates along the great circle and numeric
+	// fields linearly between the surrounding points.
+	Linear Method = "linear"
+	// Nearest copies the values of the point closest in time, which
+	// downsamples without creating any new positions.
+	Nearest Method = "nearest"
+)
+
+// ParseMethod validates a Method name; the empty string means Linear.
+func ParseMethod(s string) (Method, error) {
+	switch m := Method(s); m {
+	case "":
+		return Linear, nil
+	case Linear, Nearest:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown resample method %q (expected linear or nearest)", s)
+	}
+}
+
+// DefaultMaxGap is the longest recording gap bridged when Options.MaxGap is
+// zero. It is raised to twice the interval for coarse grids.
+const DefaultMaxGap = time.Minute
+
+// Options controls resampling.
+type Options struct {
+	Interval time.Duration
+	// MaxGap is the longest gap between recorded points that grid times
+	// may fall into. Defaults to DefaultMaxGap.
+	MaxGap time.Duration
+	Method Method
+}
+
+func (o *Options) maxGap() time.Duration {
+	gap := o.MaxGap
+	if gap <= 0 {
+		gap = max(DefaultMaxGap, 2*o.Interval)
+	}
+	return gap
+}
+
+// Track returns a copy of t with points at regular intervals, ending with
+// the last recorded point when it falls between grid times. Categorical
+// fields (means of transportation) hold their last recorded value; memos
+// are kept once, on the first emitted point at or after them. It is an
+// error when the points are not in time order.
+func Track(t *track.Track, opts Options) (*track.Track, error) {
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("invalid resample interval %s: must be positive", opts.Interval)
+	}
+	method, err := ParseMethod(string(opts.Method))
+	if err != nil {
+		return nil, err
+	}
+
+	out := &track.Track{Name: t.Name}
+	if len(t.Points) < 2 {
+		out.Points = append(out.Points, t.Points...)
+		return out, nil
+	}
+
+	for i := 1; i < len(t.Points); i++ {
+		if t.Points[i].Time.Before(t.Points[i-1].Time) {
+			return nil, fmt.Errorf("cannot resample: point %d is earlier than the point before it", i)
+		}
+	}
+
+	maxGap := opts.maxGap()
+	start := t.Points[0].Time
+	end := t.Points[len(t.Points)-1].Time
+
+	var means *models.Means
+	var memo string
+	next := 0 // index of the first point later than the previous grid time
+	seg := 0  // grid times fall in [Points[seg], Points[seg+1]]
+
+	// collect takes up the means and memos of the points up to g. Means
+	// holds its last recorded value; a memo waits for the next emitted
+	// point.
+	collect := func(g time.Time) {
+		for ; next < len(t.Points) && !t.Points[next].Time.After(g); next++ {
+			if m := t.Points[next].Means; m != nil {
+				means = m
+			}
+			if d := t.Points[next].Desc; d != "" {
+				if memo != "" {
+					memo += "; "
+				}
+				memo += d
+			}
+		}
+	}
+
+	for g := start; !g.After(end); g = g.Add(opts.Interval) {
+		for seg < len(t.Points)-2 && t.Points[seg+1].Time.Before(g) {
+			seg++
+		}
+		a, b := t.Points[seg], t.Points[seg+1]
+		collect(g)
+
+		span := b.Time.Sub(a.Time)
+		if span > maxGap && !g.Equal(a.Time) && !g.Equal(b.Time) {
+			continue
+		}
+
+		var p track.Point
+		switch method {
+		case Nearest:
+			if g.Sub(a.Time) <= b.Time.Sub(g) {
+				p = a
+			} else {
+				p = b
+			}
+		default:
+			f := 0.0
+			if span > 0 {
+				f = float64(g.Sub(a.Time)) / float64(span)
+			}
+			p = track.Interpolate(a, b, f)
+		}
+		p.Time = g
+		p.Means = means
+		p.Desc, memo = memo, ""
+
+		out.Points = append(out.Points, p)
+	}
+
+	// The grid rarely lands on the end of the track; finish at the last
+	// recorded point so neither the final stretch nor its memos are lost.
+	if len(out.Points) == 0 {
+		return out, nil
+	}
+	if last := out.Points[len(out.Points)-1]; last.Time.Before(end) {
+		collect(end)
+		p := t.Points[len(t.Points)-1]
+		p.Means = means
+		p.Desc = memo
+		out.Points = append(out.Points, p)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,172 @@
+package resample
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+var base = time.Unix(1609459200, 0).UTC()
+
+func at(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+func TestTrack_Linear(t *testing.T) {
+	walking, train := models.MeansWalking, models.MeansTrain
+	in := &track.Track{Name: "Walk", Points: []track.Point{
+		{Time: at(0), Lat: 35.000, Lon: 139, Ele: 10, HasEle: true, Speed: 1, Course: 350, Steps: 0, Means: &walking, Desc: "home"},
+		{Time: at(7), Lat: 35.007, Lon: 139, Ele: 17, HasEle: true, Speed: 2, Course: 10, Steps: 14},
+		{Time: at(10), Lat: 35.010, Lon: 139, Ele: 20, HasEle: true, Speed: 3, Course: 10, Steps: 20, Means: &train, Desc: "station"},
+	}}
+
+	out, err := Track(in, Options{Interval: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if out.Name != "Walk" {
+		t.Errorf("Name = %q, want Walk", out.Name)
+	}
+	if len(out.Points) != 3 {
+		t.Fatalf("got %d points, want 3 (0s, 5s, 10s)", len(out.Points))
+	}
+
+	mid := out.Points[1]
+	if !mid.Time.Equal(at(5)) {
+		t.Errorf("Time = %v, want %v", mid.Time, at(5))
+	}
+	if math.Abs(mid.Lat-35.005) > 1e-6 || math.Abs(mid.Ele-15) > 1e-9 {
+		t.Errorf("interpolated (lat, ele) = (%v, %v), want (35.005, 15)", mid.Lat, mid.Ele)
+	}
+	if want := 1 + 5.0/7; math.Abs(mid.Speed-want) > 1e-9 {
+		t.Errorf("Speed = %v, want %v", mid.Speed, want)
+	}
+	if want := 350 + 20*5.0/7 - 360; math.Abs(mid.Course-want) > 1e-9 {
+		t.Errorf("Course = %v, want %v (through north)", mid.Course, want)
+	}
+	if mid.Steps != 10 {
+		t.Errorf("Steps = %d, want 10", mid.Steps)
+	}
+	if mid.Means == nil || *mid.Means != walking {
+		t.Errorf("Means = %v, want held walking", mid.Means)
+	}
+	if mid.Desc != "" {
+		t.Errorf("Desc = %q, want memo only on its own grid point", mid.Desc)
+	}
+
+	if out.Points[0].Desc != "home" || out.Points[2].Desc != "station" {
+		t.Errorf("memos = %q, %q, want home, station", out.Points[0].Desc, out.Points[2].Desc)
+	}
+	if m := out.Points[2].Means; m == nil || *m != train {
+		t.Errorf("last Means = %v, want train", m)
+	}
+}
+
+func TestTrack_Nearest(t *testing.T) {
+	in := &track.Track{Points: []track.Point{
+		{Time: at(0), Lat: 35.000, Lon: 139},
+		{Time: at(1), Lat: 35.001, Lon: 139},
+		{Time: at(2), Lat: 35.002, Lon: 139},
+		{Time: at(3), Lat: 35.003, Lon: 139},
+		{Time: at(4), Lat: 35.004, Lon: 139},
+		{Time: at(5), Lat: 35.005, Lon: 139},
+	}}
+
+	out, err := Track(in, Options{Interval: 2 * time.Second, Method: Nearest})
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	// The last recorded point ends the track although it is off the grid.
+	want := []float64{35.000, 35.002, 35.004, 35.005}
+	if len(out.Points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(out.Points), len(want))
+	}
+	for i, p := range out.Points {
+		if p.Lat != want[i] {
+			t.Errorf("point %d lat = %v, want recorded %v", i, p.Lat, want[i])
+		}
+	}
+}
+
+func TestTrack_SkipsGaps(t *testing.T) {
+	in := &track.Track{Points: []track.Point{
+		{Time: at(0), Lat: 35.000, Lon: 139},
+		{Time: at(10), Lat: 35.001, Lon: 139},
+		{Time: at(300), Lat: 35.100, Lon: 139, Desc: "after tunnel"},
+		{Time: at(310), Lat: 35.101, Lon: 139},
+	}}
+
+	out, err := Track(in, Options{Interval: 5 * time.Second, MaxGap: 30 * time.Second})
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+
+	var times []int
+	for _, p := range out.Points {
+		times = append(times, int(p.Time.Sub(base).Seconds()))
+	}
+	want := []int{0, 5, 10, 300, 305, 310}
+	if len(times) != len(want) {
+		t.Fatalf("grid times = %v, want %v", times, want)
+	}
+	for i := range want {
+		if times[i] != want[i] {
+			t.Fatalf("grid times = %v, want %v", times, want)
+		}
+	}
+	if out.Points[3].Desc != "after tunnel" {
+		t.Errorf("memo after gap = %q, want %q", out.Points[3].Desc, "after tunnel")
+	}
+}
+
+func TestTrack_KeepsEnd(t *testing.T) {
+	walking, train := models.MeansWalking, models.MeansTrain
+	in := &track.Track{Points: []track.Point{
+		{Time: at(0), Lat: 35.000, Lon: 139, Means: &walking},
+		{Time: at(10), Lat: 35.010, Lon: 139},
+		{Time: at(12), Lat: 35.012, Lon: 139, Means: &train, Desc: "station"},
+		{Time: at(14), Lat: 35.014, Lon: 139, Desc: "platform"},
+	}}
+
+	out, err := Track(in, Options{Interval: 10 * time.Second})
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if len(out.Points) != 3 {
+		t.Fatalf("got %d points, want 3 (0s, 10s, 14s)", len(out.Points))
+	}
+	last := out.Points[2]
+	if !last.Time.Equal(at(14)) || last.Lat != 35.014 {
+		t.Errorf("last point = %v at %v, want the recorded end", last.Lat, last.Time)
+	}
+	if last.Desc != "station; platform" {
+		t.Errorf("Desc = %q, want the memos after the last grid time", last.Desc)
+	}
+	if last.Means == nil || *last.Means != train {
+		t.Errorf("Means = %v, want train", last.Means)
+	}
+}
+
+func TestTrack_Invalid(t *testing.T) {
+	in := &track.Track{Points: []track.Point{{Time: at(0)}, {Time: at(1)}}}
+	if _, err := Track(in, Options{}); err == nil {
+		t.Error("Track(zero interval) error = nil, want error")
+	}
+	if _, err := Track(in, Options{Interval: time.Second, Method: "cubic"}); err == nil {
+		t.Error("Track(cubic) error = nil, want error")
+	}
+	backwards := &track.Track{Points: []track.Point{{Time: at(10)}, {Time: at(5)}, {Time: at(0)}}}
+	if _, err := Track(backwards, Options{Interval: 5 * time.Second}); err == nil {
+		t.Error("Track(backwards clock) error = nil, want error")
+	}
+}
+
+func TestOptions_MaxGap(t *testing.T) {
+	if got := (&Options{Interval: 5 * time.Second}).maxGap(); got != DefaultMaxGap {
+		t.Errorf("maxGap() = %v, want %v", got, DefaultMaxGap)
+	}
+	if got := (&Options{Interval: 2 * time.Minute}).maxGap(); got != 4*time.Minute {
+		t.Errorf("maxGap() = %v, want 4m for a coarse grid", got)
+	}
+}
@@ -0,0 +1,244 @@
+// Package server exposes the converter over HTTP.
+//
+// Every endpoint takes a ZweiteGPS JSON log as the POST body:
+//
+//	POST /convert?format=gpx&track-name=...  converted document
+//	POST /stats                              track statistics as JSON
+//	POST /validate                           validation report as JSON
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/format"
+	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/privacy"
+	"github.com/chocoby/zweg/internal/stats"
+	"github.com/chocoby/zweg/internal/track"
+	"github.com/chocoby/zweg/internal/validate"
+)
+
+const (
+	// DefaultMaxBodyBytes limits request bodies to 32 MiB; a day-long log
+	// at one point per second takes 15 to 20 MiB.
+	DefaultMaxBodyBytes = 32 << 20
+	// DefaultTimeout bounds the handling of a single request.
+	DefaultTimeout = 30 * time.Second
+
+	defaultOutputFormat = "gpx"
+	shutdownTimeout     = 10 * time.Second
+)
+
+// Config holds server configuration.
+type Config struct {
+	// Addr is the TCP address to listen on, e.g. ":8080".
+	Addr string
+	// MaxBodyBytes is the largest accepted request body.
+	MaxBodyBytes int64
+	// Timeout bounds reading, handling and writing a single request.
+	Timeout time.Duration
+	// Formats resolves the format query parameter. Defaults to format.Default.
+	Formats *format.Registry
+	// GPX configures GPX-based output. Defaults to converter.DefaultConfig().
+	GPX *converter.Config
+	// PrivacyZones are removed from every track before it is converted or
+	// summarised.
+	PrivacyZones []privacy.Zone
+	// ErrorLog receives server errors. Defaults to the standard logger.
+	ErrorLog *log.Logger
+}
+
+// Server is an HTTP conversion server.
+type Server struct {
+	config *Config
+}
+
+// New creates a Server, filling unset Config fields with defaults.
+func New(config *Config) *Server {
+	cfg := Config{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Formats == nil {
+		cfg.Formats = format.Default
+	}
+	if cfg.GPX == nil {
+		cfg.GPX = converter.DefaultConfig()
+	}
+	if cfg.ErrorLog == nil {
+		cfg.ErrorLog = log.Default()
+	}
+	return &Server{config: &cfg}
+}
+
+// Handler returns the HTTP handler serving every endpoint.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /convert", s.handleConvert)
+	mux.HandleFunc("POST /stats", s.handleStats)
+	mux.HandleFunc("POST /validate", s.handleValidate)
+	return http.TimeoutHandler(mux, s.config.Timeout, `{"error":"request timed out"}`)
+}
+
+// ListenAndServe serves until ctx is cancelled, then shuts down gracefully.
+// onListen, if non-nil, is called with the bound address once listening.
+func (s *Server) ListenAndServe(ctx context.Context, onListen func(addr net.Addr)) error {
+	ln, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %q: %w", s.config.Addr, err)
+	}
+	if onListen != nil {
+		onListen(ln.Addr())
+	}
+
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: s.config.Timeout,
+		ReadTimeout:       s.config.Timeout,
+		// Leave room for the TimeoutHandler to write its own response.
+		WriteTimeout: s.config.Timeout + 5*time.Second,
+		ErrorLog:     s.config.ErrorLog,
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shut down server: %w", err)
+		}
+		return nil
+	}
+}
+
+// readPoints decodes the request body as a ZweiteGPS log, enforcing the
+// body size limit.
+func (s *Server) readPoints(w http.ResponseWriter, r *http.Request) ([]models.Point, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.config.MaxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit))
+			return nil, false
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err))
+		return nil, false
+	}
+
+	points, err := fileio.NewJSONReader().Decode(bytes.NewReader(body))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	return points, true
+}
+
+func (s *Server) readTrack(w http.ResponseWriter, r *http.Request) (*track.Track, bool) {
+	points, ok := s.readPoints(w, r)
+	if !ok {
+		return nil, false
+	}
+	t, err := track.FromZweite(points)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return nil, false
+	}
+	if privacy.Apply(t, s.config.PrivacyZones); len(t.Points) == 0 && len(points) > 0 {
+		writeError(w, http.StatusUnprocessableEntity, errors.New("all points lie inside privacy zones"))
+		return nil, false
+	}
+	return t, true
+}
+
+func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	name := query.Get("format")
+	if name == "" {
+		name = defaultOutputFormat
+	}
+	f, err := s.config.Formats.Output(name, "", defaultOutputFormat)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t, ok := s.readTrack(w, r)
+	if !ok {
+		return
+	}
+
+	trackName := query.Get("track-name")
+	if trackName == "" {
+		trackName = t.DefaultNameIn(s.config.GPX.Lang)
+	}
+
+	// Buffer the document so a conversion error still yields a clean status.
+	var buf bytes.Buffer
+	if err := f.Encode(r.Context(), &buf, t, &format.EncodeOptions{
+		TrackName: trackName,
+		GPX:       s.config.GPX,
+		Indent:    "  ",
+	}); err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	filename := t.Start().UTC().Format("20060102-150405") + f.Ext()
+	w.Header().Set("Content-Type", f.MediaType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if _, err := buf.WriteTo(w); err != nil {
+		s.config.ErrorLog.Printf("failed to write response: %v", err)
+	}
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.readTrack(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, stats.Compute(t))
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	points, ok := s.readPoints(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, validate.Points(points))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
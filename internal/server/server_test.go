@@ -0,0 +1,209 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/privacy"
+)
+
+const testLog = `[
+	{"tm":1609459200,"lo":139.7454,"la":35.6812,"al":"10","sp":"0","co":0,"th":0,"he":0,"ds":"0","tl":"Tokyo Run"},
+	{"tm":1609459260,"lo":139.7460,"la":35.6815,"al":"12","sp":"1.2","co":0,"th":0,"he":0,"ds":"60"}
+]`
+
+func do(t *testing.T, h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		body        string
+		wantStatus  int
+		wantType    string
+		wantContent string
+	}{
+		{
+			name:        "default GPX",
+			target:      "/convert",
+			body:        testLog,
+			wantStatus:  http.StatusOK,
+			wantType:    "application/gpx+xml",
+			wantContent: "<name>Tokyo Run</name>",
+		},
+		{
+			name:        "track name and format",
+			target:      "/convert?format=geojson&track-name=Lunch",
+			body:        testLog,
+			wantStatus:  http.StatusOK,
+			wantType:    "application/geo+json",
+			wantContent: `"name": "Lunch"`,
+		},
+		{
+			name:       "unknown format",
+			target:     "/convert?format=kml",
+			body:       testLog,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid JSON",
+			target:     "/convert",
+			body:       `{`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unparsable altitude",
+			target:     "/convert",
+			body:       `[{"tm":1609459200,"lo":139,"la":35,"al":"x"}]`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	h := New(nil).Handler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(t, h, http.MethodPost, tt.target, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantType != "" && rec.Header().Get("Content-Type") != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", rec.Header().Get("Content-Type"), tt.wantType)
+			}
+			if tt.wantContent != "" && !strings.Contains(rec.Body.String(), tt.wantContent) {
+				t.Errorf("body missing %q\n%s", tt.wantContent, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				var e map[string]string
+				if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil || e["error"] == "" {
+					t.Errorf("error body = %q, want JSON error", rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestConvert_Japanese(t *testing.T) {
+	gpx := converter.DefaultConfig()
+	gpx.Lang = i18n.Japanese
+	h := New(&Config{GPX: gpx}).Handler()
+
+	rec := do(t, h, http.MethodPost, "/convert", `[{"tm":1609459200,"lo":139,"la":35},{"tm":1609459260,"lo":139,"la":35.001}]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "<name>トラック</name>") {
+		t.Errorf("body missing the Japanese default name\n%s", rec.Body.String())
+	}
+}
+
+func TestConvert_ContentDisposition(t *testing.T) {
+	rec := do(t, New(nil).Handler(), http.MethodPost, "/convert", testLog)
+	if got, want := rec.Header().Get("Content-Disposition"), `attachment; filename="20210101-000000.gpx"`; got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+}
+
+func TestStats(t *testing.T) {
+	rec := do(t, New(nil).Handler(), http.MethodPost, "/stats", testLog)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got["points"] != 2.0 || got["duration_s"] != 60.0 {
+		t.Errorf("stats = %v", got)
+	}
+}
+
+func TestPrivacyZones(t *testing.T) {
+	h := New(&Config{PrivacyZones: []privacy.Zone{{Lat: 35.6812, Lon: 139.7454, Radius: 20}}}).Handler()
+
+	rec := do(t, h, http.MethodPost, "/stats", testLog)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got["points"] != 1.0 {
+		t.Errorf("points = %v, want 1 outside the zone", got["points"])
+	}
+
+	rec = do(t, h, http.MethodPost, "/convert", testLog)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `lat="35.6812"`) {
+		t.Errorf("status = %d, want the point inside the zone removed:\n%s", rec.Code, rec.Body.String())
+	}
+
+	h = New(&Config{PrivacyZones: []privacy.Zone{{Lat: 35.6812, Lon: 139.7454, Radius: 500}}}).Handler()
+	if rec := do(t, h, http.MethodPost, "/convert", testLog); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("all points in a zone: status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	rec := do(t, New(nil).Handler(), http.MethodPost, "/validate", `[{"tm":1609459200,"lo":139,"la":95,"al":"x"}]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+
+	var got struct {
+		Valid  bool `json:"valid"`
+		Errors int  `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Valid || got.Errors != 2 {
+		t.Errorf("report = %+v, want invalid with 2 errors", got)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	h := New(&Config{MaxBodyBytes: 16}).Handler()
+	rec := do(t, h, http.MethodPost, "/stats", testLog)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", rec.Code)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	rec := do(t, New(nil).Handler(), http.MethodGet, "/convert", "")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	h := New(&Config{Timeout: 10 * time.Millisecond}).Handler()
+	req := httptest.NewRequest(http.MethodPost, "/stats", io.MultiReader(strings.NewReader("["), slowReader{}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+}
+
+// slowReader blocks long enough for the handler timeout to fire.
+type slowReader struct{}
+
+func (slowReader) Read([]byte) (int, error) {
+	time.Sleep(50 * time.Millisecond)
+	return 0, io.EOF
+}
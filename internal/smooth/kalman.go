@@ -0,0 +1,119 @@
+package smooth
+
+// measurement is what was recorded along one axis at one point. A zero
+// variance means the value was not recorded.
+type measurement struct {
+	pos, posVar float64
+	vel, velVar float64
+}
+
+// state is a position and velocity along one axis with their covariance.
+type state struct {
+	x, v          float64
+	pxx, pxv, pvv float64
+}
+
+// predict moves s forward by dt seconds at constant velocity, adding the
+// uncertainty of random accelerations with variance q per second.
+func (s state) predict(dt, q float64) state {
+	dt2 := dt * dt
+	return state{
+		x:   s.x + s.v*dt,
+		v:   s.v,
+		pxx: s.pxx + 2*dt*s.pxv + dt2*s.pvv + q*dt2*dt/3,
+		pxv: s.pxv + dt*s.pvv + q*dt2/2,
+		pvv: s.pvv + q*dt,
+	}
+}
+
+// update corrects s with the recorded position and velocity, one at a
+// time. Updating them in turn is exact because their errors are
+// independent.
+func (s state) update(m measurement) state {
+	if m.posVar > 0 {
+		sv := s.pxx + m.posVar
+		kx, kv := s.pxx/sv, s.pxv/sv
+		r := m.pos - s.x
+		s = state{
+			x:   s.x + kx*r,
+			v:   s.v + kv*r,
+			pxx: (1 - kx) * s.pxx,
+			pxv: (1 - kx) * s.pxv,
+			pvv: s.pvv - kv*s.pxv,
+		}
+	}
+	if m.velVar > 0 {
+		sv := s.pvv + m.velVar
+		kx, kv := s.pxv/sv, s.pvv/sv
+		r := m.vel - s.v
+		s = state{
+			x:   s.x + kx*r,
+			v:   s.v + kv*r,
+			pxx: s.pxx - kx*s.pxv,
+			pxv: (1 - kv) * s.pxv,
+			pvv: (1 - kv) * s.pvv,
+		}
+	}
+	return s
+}
+
+// unknown is the variance of a value nothing is known about yet. It is
+// large against any distance or speed on Earth, yet small enough to keep
+// the arithmetic precise.
+const unknown = 1e12
+
+// filter returns the smoothed positions along one axis. dt[i] is the time
+// from point i-1 to point i; dt[0] is unused.
+func filter(ms []measurement, dt []float64, q float64) []float64 {
+	n := len(ms)
+	predicted := make([]state, n)
+	filtered := make([]state, n)
+
+	for i, m := range ms {
+		if i == 0 {
+			predicted[i] = state{pxx: unknown, pvv: unknown}
+		} else {
+			predicted[i] = filtered[i-1].predict(dt[i], q)
+		}
+		filtered[i] = predicted[i].update(m)
+	}
+
+	// Rauch-Tung-Striebel: carry what later points tell back to earlier
+	// ones.
+	smoothed := filtered[n-1]
+	out := make([]float64, n)
+	out[n-1] = smoothed.x
+	for i := n - 2; i >= 0; i-- {
+		f, p := filtered[i], predicted[i+1]
+		d := dt[i+1]
+		// C = P_f Fᵀ P_p⁻¹, with F = [[1, d], [0, 1]].
+		a := f.pxx + d*f.pxv // (P_f Fᵀ)[0][0]
+		b := f.pxv           // (P_f Fᵀ)[0][1]
+		c := f.pxv + d*f.pvv // (P_f Fᵀ)[1][0]
+		e := f.pvv           // (P_f Fᵀ)[1][1]
+		det := p.pxx*p.pvv - p.pxv*p.pxv
+		if det <= 0 {
+			smoothed = f
+			out[i] = f.x
+			continue
+		}
+		ixx, ixv, ivv := p.pvv/det, -p.pxv/det, p.pxx/det
+		c00, c01 := a*ixx+b*ixv, a*ixv+b*ivv
+		c10, c11 := c*ixx+e*ixv, c*ixv+e*ivv
+
+		dx, dv := smoothed.x-p.x, smoothed.v-p.v
+		dxx, dxv, dvv := smoothed.pxx-p.pxx, smoothed.pxv-p.pxv, smoothed.pvv-p.pvv
+		// P_s = P_f + C (P_s' - P_p) Cᵀ
+		m00, m01 := c00*dxx+c01*dxv, c00*dxv+c01*dvv
+		m10, m11 := c10*dxx+c11*dxv, c10*dxv+c11*dvv
+		smoothed = state{
+			x:   f.x + c00*dx + c01*dv,
+			v:   f.v + c10*dx + c11*dv,
+			pxx: f.pxx + m00*c00 + m01*c01,
+			pxv: f.pxv + m00*c10 + m01*c11,
+			pvv: f.pvv + m10*c10 + m11*c11,
+		}
+		out[i] = smoothed.x
+	}
+	return out
+}
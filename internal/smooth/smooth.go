@@ -0,0 +1,161 @@
+// Package smooth reduces the jitter of recorded positions.
+//
+// The Kalman smoother models the device as moving at a constant velocity
+// disturbed by random accelerations. Each recorded position is a noisy
+// measurement of where it is, weighted by the accuracy the device reported
+// for it, and the recorded speed and course are a measurement of how it
+// moves. A forward filter pass is followed by a Rauch-Tung-Striebel
+// backward pass, so that every point is estimated from the whole track
+// rather than only from the points before it. Timestamps are kept.
+package smooth
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// Method selects the smoother.
+type Method string
+
+// Kalman is a constant-velocity Kalman filter with a backward smoothing
+// pass.
+const Kalman Method = "kalman"
+
+// ParseMethod validates a Method name; the empty string means Kalman.
+func ParseMethod(s string) (Method, error) {
+	switch m := Method(s); m {
+	case "":
+		return Kalman, nil
+	case Kalman:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown smoothing method %q (expected kalman)", s)
+	}
+}
+
+// Defaults used when Options leave a value zero or a point does not
+// record its accuracy.
+const (
+	// DefaultAcceleration is the typical change of speed, in m/s², that
+	// the model allows between points. It suits walking and city traffic.
+	DefaultAcceleration = 1.0
+	// DefaultHorizontalAccuracy and DefaultVerticalAccuracy stand in, in
+	// meters, for a missing Ha and Va.
+	DefaultHorizontalAccuracy = 10.0
+	DefaultVerticalAccuracy   = 15.0
+	// DefaultSpeedAccuracy is the uncertainty, in m/s, of the velocity
+	// given by a recorded speed and course.
+	DefaultSpeedAccuracy = 1.0
+)
+
+// Options controls smoothing.
+type Options struct {
+	Method Method
+	// Acceleration defaults to DefaultAcceleration. Smaller values give a
+	// smoother path that follows turns more slowly.
+	Acceleration float64
+}
+
+func (o *Options) acceleration() float64 {
+	if o.Acceleration > 0 {
+		return o.Acceleration
+	}
+	return DefaultAcceleration
+}
+
+// Track returns a copy of t with smoothed positions and elevations. Points
+// without an elevation stay without one.
+func Track(t *track.Track, opts Options) (*track.Track, error) {
+	if _, err := ParseMethod(string(opts.Method)); err != nil {
+		return nil, err
+	}
+	if opts.Acceleration < 0 {
+		return nil, fmt.Errorf("invalid smoothing acceleration %v: must be positive", opts.Acceleration)
+	}
+
+	out := &track.Track{Name: t.Name, Points: append([]track.Point(nil), t.Points...)}
+	if len(out.Points) < 2 {
+		return out, nil
+	}
+	q := opts.acceleration() * opts.acceleration()
+
+	// Positions are filtered in meters east and north of the first point,
+	// which is accurate enough over the extent of a recorded track.
+	lat0, lon0 := t.Points[0].Lat, t.Points[0].Lon
+	metersPerDeg := geo.EarthRadius * math.Pi / 180
+	metersPerDegLon := metersPerDeg * math.Cos(lat0*math.Pi/180)
+
+	n := len(t.Points)
+	dt := make([]float64, n)
+	east, north := make([]measurement, n), make([]measurement, n)
+	known := 0
+	for i, p := range t.Points {
+		if i > 0 {
+			dt[i] = max(p.Time.Sub(t.Points[i-1].Time).Seconds(), 0)
+		}
+		// A negative accuracy marks a position the device itself
+		// considered invalid; it is predicted from its neighbours instead.
+		if p.HDOP >= 0 {
+			variance := square(accuracy(p.HDOP, DefaultHorizontalAccuracy))
+			east[i].pos, east[i].posVar = (p.Lon-lon0)*metersPerDegLon, variance
+			north[i].pos, north[i].posVar = (p.Lat-lat0)*metersPerDeg, variance
+			known++
+		}
+		// Zero is "not recorded" for speed, and iOS reports an unknown
+		// course as negative.
+		if p.Speed > 0 && p.Course >= 0 {
+			course := p.Course * math.Pi / 180
+			variance := square(DefaultSpeedAccuracy)
+			east[i].vel, east[i].velVar = p.Speed*math.Sin(course), variance
+			north[i].vel, north[i].velVar = p.Speed*math.Cos(course), variance
+		}
+	}
+
+	if known < 2 {
+		return out, nil
+	}
+
+	xs, ys := filter(east, dt, q), filter(north, dt, q)
+	for i := range out.Points {
+		out.Points[i].Lat = lat0 + ys[i]/metersPerDeg
+		out.Points[i].Lon = lon0 + xs[i]/metersPerDegLon
+	}
+
+	smoothElevation(out, dt, q)
+	return out, nil
+}
+
+// smoothElevation smooths the recorded elevations of t, in place. The
+// vertical speed is not recorded, so only the altitudes are measured.
+func smoothElevation(t *track.Track, dt []float64, q float64) {
+	up := make([]measurement, len(t.Points))
+	known := 0
+	for i, p := range t.Points {
+		if p.HasEle && p.VDOP >= 0 {
+			up[i].pos, up[i].posVar = p.Ele, square(accuracy(p.VDOP, DefaultVerticalAccuracy))
+			known++
+		}
+	}
+	if known < 2 {
+		return
+	}
+	zs := filter(up, dt, q)
+	for i := range t.Points {
+		if t.Points[i].HasEle {
+			t.Points[i].Ele = zs[i]
+		}
+	}
+}
+
+// accuracy returns a recorded accuracy, or def when it is not recorded.
+func accuracy(recorded, def float64) float64 {
+	if recorded > 0 {
+		return recorded
+	}
+	return def
+}
+
+func square(v float64) float64 { return v * v }
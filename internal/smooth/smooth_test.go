@@ -0,0 +1,151 @@
+package smooth
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/track"
+)
+
+var base = time.Unix(1609459200, 0).UTC()
+
+func at(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+// zigzag walks north at 1.5 m/s while its positions alternate 8 m east and
+// west of the path.
+func zigzag(n int) *track.Track {
+	const metersPerDeg = 111195.0
+	t := &track.Track{Name: "Walk"}
+	for i := range n {
+		offset := 8.0
+		if i%2 == 1 {
+			offset = -8
+		}
+		t.Points = append(t.Points, track.Point{
+			Time:   at(i * 2),
+			Lat:    35 + float64(i)*3/metersPerDeg,
+			Lon:    139 + offset/(metersPerDeg*math.Cos(35*math.Pi/180)),
+			Ele:    20 + offset,
+			HasEle: true,
+			HDOP:   10,
+			VDOP:   10,
+			Steps:  i * 4,
+		})
+	}
+	return t
+}
+
+// lateral returns the root mean square distance, in meters, of the points
+// from the path at longitude 139.
+func lateral(t *track.Track) float64 {
+	var sum float64
+	for _, p := range t.Points {
+		d := (p.Lon - 139) * 111195 * math.Cos(35*math.Pi/180)
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(t.Points)))
+}
+
+func TestTrack_Kalman(t *testing.T) {
+	in := zigzag(60)
+	out, err := Track(in, Options{Method: Kalman})
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if out.Name != "Walk" || len(out.Points) != len(in.Points) {
+		t.Fatalf("got %q with %d points, want Walk with %d", out.Name, len(out.Points), len(in.Points))
+	}
+	for i, p := range out.Points {
+		if !p.Time.Equal(in.Points[i].Time) || p.Steps != in.Points[i].Steps {
+			t.Fatalf("point %d: time %v, steps %d changed", i, p.Time, p.Steps)
+		}
+	}
+
+	if before, after := lateral(in), lateral(out); after > before/3 {
+		t.Errorf("lateral RMS = %.2f m after smoothing, want well below %.2f m", after, before)
+	}
+	// The progress along the path is kept.
+	if got, want := out.Points[30].Lat, in.Points[30].Lat; math.Abs(got-want) > 1.0/111195 {
+		t.Errorf("Lat = %v, want within 1 m of %v", got, want)
+	}
+	if ele := out.Points[30].Ele; math.Abs(ele-20) > 3 {
+		t.Errorf("Ele = %v, want close to 20", ele)
+	}
+	if lon := in.Points[1].Lon; math.Abs((lon-139)*111195*math.Cos(35*math.Pi/180)+8) > 1e-6 {
+		t.Errorf("input Lon = %v, want unchanged", lon)
+	}
+}
+
+func TestTrack_Accuracy(t *testing.T) {
+	// A point recorded with a poor accuracy moves further towards its
+	// neighbours than the same point recorded with a good one.
+	shift := func(ha float64) float64 {
+		in := zigzag(21)
+		for i := range in.Points {
+			in.Points[i].Lon = 139
+		}
+		in.Points[10].Lon = 139.001
+		in.Points[10].HDOP = ha
+		out, err := Track(in, Options{})
+		if err != nil {
+			t.Fatalf("Track: %v", err)
+		}
+		return out.Points[10].Lon - 139
+	}
+	if good, poor := shift(3), shift(50); poor >= good {
+		t.Errorf("offset kept with ha 50 = %v, want less than with ha 3 (%v)", poor, good)
+	}
+	if invalid := shift(-1); math.Abs(invalid) > 1e-7 {
+		t.Errorf("offset kept with ha -1 = %v, want position ignored", invalid)
+	}
+}
+
+func TestTrack_Velocity(t *testing.T) {
+	// Without positions in between, the recorded course and speed carry
+	// the track east rather than straight between the ends.
+	in := &track.Track{Points: []track.Point{
+		{Time: at(0), Lat: 35, Lon: 139, HDOP: 5},
+		{Time: at(10), Lat: 35, Lon: 139, HDOP: -1, Speed: 5, Course: 90},
+		{Time: at(20), Lat: 35, Lon: 139, HDOP: 5},
+	}}
+	out, err := Track(in, Options{})
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if out.Points[1].Lon <= 139 {
+		t.Errorf("Lon = %v, want east of 139", out.Points[1].Lon)
+	}
+}
+
+func TestTrack_Short(t *testing.T) {
+	in := &track.Track{Points: []track.Point{{Time: at(0), Lat: 35, Lon: 139}}}
+	out, err := Track(in, Options{})
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if len(out.Points) != 1 || out.Points[0] != in.Points[0] {
+		t.Errorf("got %+v, want the single point unchanged", out.Points)
+	}
+}
+
+func TestTrack_MissingElevation(t *testing.T) {
+	in := zigzag(10)
+	in.Points[4].HasEle, in.Points[4].Ele = false, 0
+	out, err := Track(in, Options{})
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if p := out.Points[4]; p.HasEle || p.Ele != 0 {
+		t.Errorf("point without elevation got (%v, %v)", p.Ele, p.HasEle)
+	}
+}
+
+func TestTrack_InvalidOptions(t *testing.T) {
+	if _, err := Track(zigzag(3), Options{Method: "median"}); err == nil {
+		t.Error("unknown method: want error")
+	}
+	if _, err := Track(zigzag(3), Options{Acceleration: -1}); err == nil {
+		t.Error("negative acceleration: want error")
+	}
+}
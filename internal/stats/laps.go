@@ -0,0 +1,232 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// Unit is a unit of distance for splits and pace.
+type Unit string
+
+// Units of distance.
+const (
+	Kilometer Unit = "km"
+	Mile      Unit = "mi"
+)
+
+// ParseUnit validates a Unit name; the empty string means Kilometer.
+func ParseUnit(s string) (Unit, error) {
+	switch u := Unit(strings.ToLower(s)); u {
+	case "":
+		return Kilometer, nil
+	case Kilometer, Mile:
+		return u, nil
+	default:
+		return "", fmt.Errorf("unknown distance unit %q (expected km or mi)", s)
+	}
+}
+
+// Meters returns the length of one u.
+func (u Unit) Meters() float64 {
+	if u == Mile {
+		return 1609.344
+	}
+	return 1000
+}
+
+// LapOptions controls how a track is divided into laps. Without Distance
+// or Time, it is split at every Unit.
+type LapOptions struct {
+	// Unit is the unit of the pace of each lap. It defaults to Kilometer.
+	Unit Unit
+	// Distance starts a new lap every Distance meters.
+	Distance float64
+	// Time starts a new lap every Time, and takes precedence over
+	// Distance.
+	Time time.Duration
+	// DeviceDistance measures distance by the cumulative distance the
+	// device recorded (ds) rather than between the recorded positions. It
+	// falls back to the positions for a log without ds.
+	DeviceDistance bool
+}
+
+// Lap is one section of a track. Its ends are interpolated between the
+// recorded points, so a 1 km split is exactly 1 km long.
+type Lap struct {
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"-"`
+	Distance float64       `json:"distance_m"`
+	// Unit is the unit of Pace.
+	Unit          Unit    `json:"unit"`
+	ElevationGain float64 `json:"elevation_gain_m"`
+	ElevationLoss float64 `json:"elevation_loss_m"`
+	// Cadence is the mean step cadence in steps per minute, or zero for a
+	// log without pedometer counts.
+	Cadence float64 `json:"cadence_spm,omitempty"`
+}
+
+// Pace returns the time the lap took per Unit, or zero for a lap without
+// distance.
+func (l Lap) Pace() time.Duration {
+	if l.Distance <= 0 {
+		return 0
+	}
+	return time.Duration(float64(l.Duration) * l.Unit.Meters() / l.Distance)
+}
+
+// MarshalJSON encodes Duration and Pace as seconds, like the durations in
+// Summary.
+func (l Lap) MarshalJSON() ([]byte, error) {
+	type plain Lap
+	return json.Marshal(struct {
+		plain
+		DurationSeconds float64 `json:"duration_s"`
+		PaceSeconds     float64 `json:"pace_s"`
+	}{
+		plain:           plain(l),
+		DurationSeconds: l.Duration.Seconds(),
+		PaceSeconds:     l.Pace().Seconds(),
+	})
+}
+
+// formatPace formats a pace as minutes and seconds per unit, e.g.
+// "5:12 /km".
+func formatPace(pace time.Duration, unit Unit) string {
+	sec := int(pace.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d /%s", sec/60, sec%60, unit)
+}
+
+// sample is the state of a track at one moment: a recorded point or a lap
+// boundary between two of them.
+type sample struct {
+	time     time.Time
+	distance float64 // meters from the start
+	ele      float64 // NaN when not known
+	steps    float64 // pedometer steps from the start
+}
+
+// lerp returns the sample a fraction f of the way from a to b. An
+// elevation is only interpolated between two known ones.
+func (a sample) lerp(b sample, f float64) sample {
+	return sample{
+		time:     a.time.Add(time.Duration(f * float64(b.time.Sub(a.time))).Round(time.Millisecond)),
+		distance: a.distance + f*(b.distance-a.distance),
+		ele:      a.ele + f*(b.ele-a.ele),
+		steps:    a.steps + f*(b.steps-a.steps),
+	}
+}
+
+// Laps divides t into laps as opts say. The last lap holds what is left
+// and is usually shorter.
+func Laps(t *track.Track, opts LapOptions) []Lap {
+	if len(t.Points) < 2 {
+		return nil
+	}
+	unit := opts.Unit
+	if unit == "" {
+		unit = Kilometer
+	}
+
+	samples := lapSamples(t, opts.DeviceDistance)
+	// progress is what laps are counted in: seconds or meters.
+	progress := func(s sample) float64 { return s.distance }
+	step := opts.Distance
+	if opts.Time > 0 {
+		progress = func(s sample) float64 { return s.time.Sub(samples[0].time).Seconds() }
+		step = opts.Time.Seconds()
+	} else if step <= 0 {
+		step = unit.Meters()
+	}
+
+	var laps []Lap
+	lap := []sample{samples[0]}
+	next := step
+	for i := 1; i < len(samples); i++ {
+		prev, s := samples[i-1], samples[i]
+		for progress(s) >= next && progress(s) > progress(prev) {
+			f := (next - progress(prev)) / (progress(s) - progress(prev))
+			boundary := prev.lerp(s, f)
+			laps = append(laps, newLap(append(lap, boundary), unit))
+			lap = []sample{boundary}
+			next += step
+		}
+		lap = append(lap, s)
+	}
+	if last := lap[len(lap)-1]; last.time.After(lap[0].time) || last.distance > lap[0].distance {
+		laps = append(laps, newLap(lap, unit))
+	}
+	return laps
+}
+
+// lapSamples returns a sample for every point of t.
+func lapSamples(t *track.Track, deviceDistance bool) []sample {
+	useDevice := false
+	if deviceDistance {
+		for _, p := range t.Points {
+			if p.Distance > 0 {
+				useDevice = true
+				break
+			}
+		}
+	}
+
+	elevations := Elevations(t)
+	out := make([]sample, len(t.Points))
+	for i, p := range t.Points {
+		s := sample{time: p.Time, ele: elevations[i]}
+		if i > 0 {
+			prev := t.Points[i-1]
+			s.distance = out[i-1].distance
+			if useDevice {
+				// The recorded distance never goes backwards, and a point
+				// without one has not moved.
+				s.distance = max(s.distance, p.Distance-t.Points[0].Distance)
+			} else {
+				s.distance += geo.Distance(prev.Lat, prev.Lon, p.Lat, p.Lon)
+			}
+			s.steps = out[i-1].steps
+			if p.Steps > prev.Steps {
+				s.steps += float64(p.Steps - prev.Steps)
+			}
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// newLap summarises the samples from the start to the end of a lap.
+func newLap(samples []sample, unit Unit) Lap {
+	first, last := samples[0], samples[len(samples)-1]
+	l := Lap{
+		Start:    first.time.UTC(),
+		End:      last.time.UTC(),
+		Duration: last.time.Sub(first.time),
+		Distance: last.distance - first.distance,
+		Unit:     unit,
+	}
+	prevEle := math.NaN()
+	for _, s := range samples {
+		if math.IsNaN(s.ele) {
+			continue
+		}
+		if !math.IsNaN(prevEle) {
+			if d := s.ele - prevEle; d > 0 {
+				l.ElevationGain += d
+			} else {
+				l.ElevationLoss -= d
+			}
+		}
+		prevEle = s.ele
+	}
+	if minutes := l.Duration.Minutes(); minutes > 0 {
+		l.Cadence = (last.steps - first.steps) / minutes
+	}
+	return l
+}
@@ -0,0 +1,298 @@
+// Package stats computes summary statistics for a track.
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// movingSpeed is the segment speed in m/s above which time counts as moving.
+const movingSpeed = 0.5
+
+// Bounds is the bounding box of a track in decimal degrees.
+type Bounds struct {
+	MinLat float64 `json:"min_lat"`
+	MinLon float64 `json:"min_lon"`
+	MaxLat float64 `json:"max_lat"`
+	MaxLon float64 `json:"max_lon"`
+}
+
+// Summary holds the statistics of one track.
+// Distances are in meters and speeds in meters per second.
+type Summary struct {
+	Points        int           `json:"points"`
+	Start         time.Time     `json:"start"`
+	End           time.Time     `json:"end"`
+	Duration      time.Duration `json:"-"`
+	MovingTime    time.Duration `json:"-"`
+	Distance      float64       `json:"distance_m"`
+	ElevationGain float64       `json:"elevation_gain_m"`
+	ElevationLoss float64       `json:"elevation_loss_m"`
+	MinElevation  float64       `json:"min_elevation_m"`
+	MaxElevation  float64       `json:"max_elevation_m"`
+	// HasElevation reports whether any point records an altitude; without
+	// one the elevation fields are all zero.
+	HasElevation bool     `json:"has_elevation"`
+	MaxSpeed     float64  `json:"max_speed_mps"`
+	AvgSpeed     float64  `json:"avg_speed_mps"`
+	MovingSpeed  float64  `json:"moving_speed_mps"`
+	Bounds       Bounds   `json:"bounds"`
+	Means        []string `json:"means,omitempty"`
+	// Stops and Laps are set by callers that detect stops or divide the
+	// track into laps; Compute leaves them empty.
+	Stops []Stop `json:"stops,omitempty"`
+	Laps  []Lap  `json:"laps,omitempty"`
+}
+
+// MarshalJSON encodes durations as seconds, which is what non-Go consumers expect.
+func (s Summary) MarshalJSON() ([]byte, error) {
+	type plain Summary
+	return json.Marshal(struct {
+		plain
+		DurationSeconds   float64 `json:"duration_s"`
+		MovingTimeSeconds float64 `json:"moving_time_s"`
+	}{
+		plain:             plain(s),
+		DurationSeconds:   s.Duration.Seconds(),
+		MovingTimeSeconds: s.MovingTime.Seconds(),
+	})
+}
+
+// Compute returns the Summary of t. Distance is the sum of haversine
+// distances between consecutive points, and every speed is derived from
+// it rather than from the recorded sp, so the maximum is never below the
+// average.
+func Compute(t *track.Track) Summary {
+	var s Summary
+	s.Points = len(t.Points)
+	if s.Points == 0 {
+		return s
+	}
+
+	first, last := t.Points[0], t.Points[len(t.Points)-1]
+	s.Start = first.Time.UTC()
+	s.End = last.Time.UTC()
+	s.Duration = last.Time.Sub(first.Time)
+	s.Bounds = Bounds{MinLat: first.Lat, MinLon: first.Lon, MaxLat: first.Lat, MaxLon: first.Lon}
+
+	// prevEle is the last recorded elevation; gain and loss bridge the
+	// points without one.
+	var prevEle *track.Point
+	seenMeans := make(map[string]bool)
+	for i, p := range t.Points {
+		s.Bounds.MinLat = math.Min(s.Bounds.MinLat, p.Lat)
+		s.Bounds.MinLon = math.Min(s.Bounds.MinLon, p.Lon)
+		s.Bounds.MaxLat = math.Max(s.Bounds.MaxLat, p.Lat)
+		s.Bounds.MaxLon = math.Max(s.Bounds.MaxLon, p.Lon)
+
+		if p.HasEle {
+			if prevEle == nil {
+				s.MinElevation, s.MaxElevation = p.Ele, p.Ele
+				s.HasElevation = true
+			} else if dEle := p.Ele - prevEle.Ele; dEle > 0 {
+				s.ElevationGain += dEle
+			} else {
+				s.ElevationLoss -= dEle
+			}
+			s.MinElevation = math.Min(s.MinElevation, p.Ele)
+			s.MaxElevation = math.Max(s.MaxElevation, p.Ele)
+			prevEle = &t.Points[i]
+		}
+
+		if p.Means != nil {
+			if name := p.Means.String(); name != "" && !seenMeans[name] {
+				seenMeans[name] = true
+				s.Means = append(s.Means, name)
+			}
+		}
+
+		if i == 0 {
+			continue
+		}
+		prev := t.Points[i-1]
+
+		d := geo.Distance(prev.Lat, prev.Lon, p.Lat, p.Lon)
+		s.Distance += d
+
+		dt := p.Time.Sub(prev.Time)
+		if dt <= 0 {
+			continue
+		}
+		segSpeed := d / dt.Seconds()
+		s.MaxSpeed = math.Max(s.MaxSpeed, segSpeed)
+		if segSpeed >= movingSpeed {
+			s.MovingTime += dt
+		}
+	}
+
+	if s.Duration > 0 {
+		s.AvgSpeed = s.Distance / s.Duration.Seconds()
+	}
+	if s.MovingTime > 0 {
+		s.MovingSpeed = s.Distance / s.MovingTime.Seconds()
+	}
+
+	return s
+}
+
+// CumulativeDistances returns, for every point, the haversine distance in
+// meters travelled since the first point.
+func CumulativeDistances(t *track.Track) []float64 {
+	out := make([]float64, len(t.Points))
+	for i := 1; i < len(t.Points); i++ {
+		prev, p := t.Points[i-1], t.Points[i]
+		out[i] = out[i-1] + geo.Distance(prev.Lat, prev.Lon, p.Lat, p.Lon)
+	}
+	return out
+}
+
+// Speeds returns the speed in m/s at every point: the recorded value when
+// present, otherwise the speed over the segment leading to the point.
+func Speeds(t *track.Track) []float64 {
+	out := make([]float64, len(t.Points))
+	for i, p := range t.Points {
+		if p.Speed > 0 || i == 0 {
+			out[i] = p.Speed
+			continue
+		}
+		prev := t.Points[i-1]
+		if dt := p.Time.Sub(prev.Time).Seconds(); dt > 0 {
+			out[i] = geo.Distance(prev.Lat, prev.Lon, p.Lat, p.Lon) / dt
+		}
+	}
+	return out
+}
+
+// labelWidth is the column width of the labels written by WriteText.
+const labelWidth = 16
+
+// WriteText writes s as an aligned, human-readable report in lang.
+func WriteText(w io.Writer, s Summary, lang i18n.Lang) error {
+	type line struct {
+		label string
+		value string
+	}
+	lines := []line{
+		{"Points", fmt.Sprintf("%d", s.Points)},
+		{"Start", s.Start.Format(time.RFC3339)},
+		{"End", s.End.Format(time.RFC3339)},
+		{"Duration", lang.Duration(s.Duration)},
+		{"Moving time", lang.Duration(s.MovingTime)},
+		{"Distance", fmt.Sprintf("%.2f km", s.Distance/1000)},
+		{"Elevation gain", fmt.Sprintf("%.1f m", s.ElevationGain)},
+		{"Elevation loss", fmt.Sprintf("%.1f m", s.ElevationLoss)},
+	}
+	if s.HasElevation {
+		lines = append(lines, line{"Elevation range", fmt.Sprintf("%.1f – %.1f m", s.MinElevation, s.MaxElevation)})
+	}
+	lines = append(lines,
+		line{"Average speed", fmt.Sprintf("%.2f km/h", s.AvgSpeed*3.6)},
+		line{"Moving speed", fmt.Sprintf("%.2f km/h", s.MovingSpeed*3.6)},
+		line{"Max speed", fmt.Sprintf("%.2f km/h", s.MaxSpeed*3.6)},
+	)
+	for _, m := range s.Means {
+		lines = append(lines, line{"Means", lang.T(m)})
+	}
+	for _, stop := range s.Stops {
+		where := stop.Name
+		if where == "" {
+			where = fmt.Sprintf("%.5f, %.5f", stop.Lat, stop.Lon)
+		}
+		lines = append(lines, line{"Stop", fmt.Sprintf("%s – %s (%s) %s",
+			stop.Arrival.Format(time.RFC3339), stop.Departure.Format(time.RFC3339), lang.Duration(stop.Duration), where)})
+	}
+	for i, lap := range s.Laps {
+		value := fmt.Sprintf("%d. %.2f %s, %s, %s, +%.1f/-%.1f m",
+			i+1, lap.Distance/lap.Unit.Meters(), lap.Unit, lang.Duration(lap.Duration), formatPace(lap.Pace(), lap.Unit), lap.ElevationGain, lap.ElevationLoss)
+		if lap.Cadence > 0 {
+			value += fmt.Sprintf(", %.0f spm", lap.Cadence)
+		}
+		lines = append(lines, line{"Lap", value})
+	}
+
+	for _, l := range lines {
+		if _, err := fmt.Fprintf(w, "%s %s\n", i18n.Pad(lang.Context("stats", l.label)+":", labelWidth), l.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	// seaLevelPressure is the standard atmosphere pressure at sea level in hPa.
+	seaLevelPressure = 1013.25
+	// minHectopascal separates kPa readings (~100) from hPa readings (~1000).
+	minHectopascal = 200
+)
+
+// pressureAltitude returns the standard-atmosphere altitude in meters for
+// a pressure in kPa or hPa.
+func pressureAltitude(pressure float64) float64 {
+	if pressure < minHectopascal {
+		pressure *= 10
+	}
+	return 44330 * (1 - math.Pow(pressure/seaLevelPressure, 1/5.255))
+}
+
+// Elevations returns the elevation in meters at every point. When the log
+// records atmospheric pressure, the barometric altitude is used for its
+// smoother relative changes, shifted so that it agrees on average with the
+// GPS altitude; points without pressure keep their GPS altitude. Points
+// with neither are NaN.
+func Elevations(t *track.Track) []float64 {
+	out := make([]float64, len(t.Points))
+	var sum, offset float64
+	var n int
+	for i, p := range t.Points {
+		out[i] = math.NaN()
+		if p.HasEle {
+			out[i] = p.Ele
+		}
+		if p.Pressure > 0 && p.HasEle {
+			sum += p.Ele - pressureAltitude(p.Pressure)
+			n++
+		}
+	}
+	if n > 0 {
+		offset = sum / float64(n)
+	}
+	for i, p := range t.Points {
+		if p.Pressure > 0 {
+			out[i] = pressureAltitude(p.Pressure) + offset
+		}
+	}
+	return out
+}
+
+// Cadence returns the step cadence in steps per minute at every point,
+// derived from the change in the pedometer count since the previous point.
+// A count that goes backwards (a pedometer reset) yields zero.
+func Cadence(t *track.Track) []float64 {
+	out := make([]float64, len(t.Points))
+	for i := 1; i < len(t.Points); i++ {
+		prev, p := t.Points[i-1], t.Points[i]
+		dt := p.Time.Sub(prev.Time).Minutes()
+		if dt <= 0 || p.Steps < prev.Steps {
+			continue
+		}
+		out[i] = float64(p.Steps-prev.Steps) / dt
+	}
+	return out
+}
+
+// HasSteps reports whether any point records a pedometer count.
+func HasSteps(t *track.Track) bool {
+	for _, p := range t.Points {
+		if p.Steps > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,457 @@
+package stats
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+func testTrack() *track.Track {
+	walking := models.MeansWalking
+	base := time.Unix(1609459200, 0).UTC()
+	// Roughly 111 m per 0.001 degree of latitude.
+	return &track.Track{Points: []track.Point{
+		{Time: base, Lat: 35.000, Lon: 139, Ele: 10, HasEle: true, Means: &walking},
+		{Time: base.Add(60 * time.Second), Lat: 35.001, Lon: 139, Ele: 15, HasEle: true},
+		{Time: base.Add(120 * time.Second), Lat: 35.001, Lon: 139, Ele: 12, HasEle: true},
+		{Time: base.Add(180 * time.Second), Lat: 35.002, Lon: 139.001, Ele: 20, HasEle: true, Speed: 4},
+	}}
+}
+
+func TestCompute(t *testing.T) {
+	s := Compute(testTrack())
+
+	if s.Points != 4 {
+		t.Errorf("Points = %d, want 4", s.Points)
+	}
+	if s.Duration != 3*time.Minute {
+		t.Errorf("Duration = %v, want 3m", s.Duration)
+	}
+	if s.MovingTime != 2*time.Minute {
+		t.Errorf("MovingTime = %v, want 2m (one stationary minute)", s.MovingTime)
+	}
+	if math.Abs(s.Distance-(111.2+142.6)) > 2 {
+		t.Errorf("Distance = %.1f, want about 254", s.Distance)
+	}
+	if s.ElevationGain != 13 || s.ElevationLoss != 3 {
+		t.Errorf("gain/loss = %v/%v, want 13/3", s.ElevationGain, s.ElevationLoss)
+	}
+	if s.MinElevation != 10 || s.MaxElevation != 20 {
+		t.Errorf("elevation range = %v-%v, want 10-20", s.MinElevation, s.MaxElevation)
+	}
+	// The recorded sp of 4 m/s is ignored in favour of the segment speeds
+	// the average is computed from.
+	if math.Abs(s.MaxSpeed-142.6/60) > 0.05 || s.MaxSpeed < s.AvgSpeed {
+		t.Errorf("MaxSpeed = %v, want about 2.38 and at least AvgSpeed %v", s.MaxSpeed, s.AvgSpeed)
+	}
+	if want := (Bounds{MinLat: 35, MinLon: 139, MaxLat: 35.002, MaxLon: 139.001}); s.Bounds != want {
+		t.Errorf("Bounds = %+v, want %+v", s.Bounds, want)
+	}
+	if len(s.Means) != 1 || s.Means[0] != "Walking" {
+		t.Errorf("Means = %v, want [Walking]", s.Means)
+	}
+}
+
+func TestCompute_MissingElevation(t *testing.T) {
+	tr := testTrack()
+	tr.Points[0].HasEle = false // would be a 10 m low point
+	tr.Points[2].HasEle = false // would be a 3 m dip
+
+	s := Compute(tr)
+	if s.ElevationGain != 5 || s.ElevationLoss != 0 {
+		t.Errorf("gain/loss = %v/%v, want 5/0 between the recorded 15 and 20", s.ElevationGain, s.ElevationLoss)
+	}
+	if s.MinElevation != 15 || s.MaxElevation != 20 {
+		t.Errorf("elevation range = %v-%v, want 15-20", s.MinElevation, s.MaxElevation)
+	}
+}
+
+func TestWriteText_NoElevation(t *testing.T) {
+	tr := testTrack()
+	for i := range tr.Points {
+		tr.Points[i].HasEle = false
+	}
+
+	s := Compute(tr)
+	if s.HasElevation {
+		t.Error("HasElevation = true for a track without altitudes")
+	}
+	var b strings.Builder
+	if err := WriteText(&b, s, i18n.English); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	if strings.Contains(b.String(), "Elevation range") {
+		t.Errorf("output has an elevation range without altitudes\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := WriteText(&b, Compute(testTrack()), i18n.English); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	if want := "Elevation range: 10.0 – 20.0 m"; !strings.Contains(b.String(), want) {
+		t.Errorf("output missing %q\n%s", want, b.String())
+	}
+}
+
+func TestCompute_Empty(t *testing.T) {
+	if s := Compute(&track.Track{}); s.Points != 0 || s.Distance != 0 {
+		t.Errorf("Compute(empty) = %+v", s)
+	}
+}
+
+func TestSummary_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Compute(testTrack()))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got["duration_s"] != 180.0 || got["moving_time_s"] != 120.0 {
+		t.Errorf("durations = %v/%v, want 180/120", got["duration_s"], got["moving_time_s"])
+	}
+	if got["points"] != 4.0 {
+		t.Errorf("points = %v, want 4", got["points"])
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var b strings.Builder
+	if err := WriteText(&b, Compute(testTrack()), i18n.English); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	for _, want := range []string{"Points:          4", "Duration:        3m0s", "Means:           Walking", "km/h"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q\n%s", want, b.String())
+		}
+	}
+}
+
+func TestWriteText_Japanese(t *testing.T) {
+	var b strings.Builder
+	if err := WriteText(&b, Compute(testTrack()), i18n.Japanese); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	// Labels are padded by display width, so values still line up.
+	for _, want := range []string{"ポイント数:      4", "所要時間:        3分0秒", "移動手段:        徒歩"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q\n%s", want, b.String())
+		}
+	}
+}
+
+// stopTrack walks north, stays 10 minutes at a café with the position
+// jittering by a few meters, pauses for 2 minutes at a crossing and walks
+// on. Each minute is one point, roughly 111 m apart while walking.
+func stopTrack() *track.Track {
+	base := time.Unix(1609459200, 0).UTC()
+	t := &track.Track{}
+	add := func(lat, lon float64, desc string) {
+		t.Points = append(t.Points, track.Point{
+			Time: base.Add(time.Duration(len(t.Points)) * time.Minute), Lat: lat, Lon: lon, Desc: desc,
+		})
+	}
+	add(35.000, 139, "")
+	add(35.001, 139, "")
+	add(35.002, 139, "Café")
+	for i := range 10 {
+		add(35.002+float64(i%3)*0.0001, 139+float64(i%2)*0.0001, "")
+	}
+	add(35.004, 139, "")
+	add(35.004, 139, "")
+	add(35.004, 139, "")
+	add(35.005, 139, "")
+	return t
+}
+
+func TestStops(t *testing.T) {
+	stops := Stops(stopTrack(), StopOptions{})
+	if len(stops) != 1 {
+		t.Fatalf("got %d stops, want 1 (the crossing is too short): %+v", len(stops), stops)
+	}
+	s := stops[0]
+	base := time.Unix(1609459200, 0).UTC()
+	if !s.Arrival.Equal(base.Add(2*time.Minute)) || !s.Departure.Equal(base.Add(12*time.Minute)) {
+		t.Errorf("stop = %v – %v, want 00:02 – 00:12", s.Arrival, s.Departure)
+	}
+	if s.Duration != 10*time.Minute || s.First != 2 || s.Last != 12 {
+		t.Errorf("Duration = %v, points %d–%d; want 10m, 2–12", s.Duration, s.First, s.Last)
+	}
+	if math.Abs(s.Lat-35.0021) > 0.0001 || math.Abs(s.Lon-139.00005) > 0.0001 {
+		t.Errorf("position = (%v, %v), want the middle of the stay", s.Lat, s.Lon)
+	}
+	if s.Name != "Café" {
+		t.Errorf("Name = %q, want the memo Café", s.Name)
+	}
+
+	stops = Stops(stopTrack(), StopOptions{MinDuration: 2 * time.Minute})
+	if len(stops) != 2 || stops[1].Name != "" || stops[1].First != 13 {
+		t.Errorf("with a 2m minimum got %+v, want the crossing as an unnamed second stop", stops)
+	}
+	if stops := Stops(stopTrack(), StopOptions{Radius: 5}); len(stops) != 0 {
+		t.Errorf("with a 5 m radius got %d stops, want none", len(stops))
+	}
+}
+
+func TestStop_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Stops(stopTrack(), StopOptions{})[0])
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got["duration_s"] != 600.0 || got["name"] != "Café" || got["arrival"] != "2021-01-01T00:02:00Z" {
+		t.Errorf("JSON = %s", data)
+	}
+}
+
+func TestWriteText_Stops(t *testing.T) {
+	st := stopTrack()
+	s := Compute(st)
+	s.Stops = Stops(st, StopOptions{MinDuration: 2 * time.Minute})
+
+	var b strings.Builder
+	if err := WriteText(&b, s, i18n.English); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	for _, want := range []string{
+		"Stop:            2021-01-01T00:02:00Z – 2021-01-01T00:12:00Z (10m0s) Café",
+		"Stop:            2021-01-01T00:13:00Z – 2021-01-01T00:15:00Z (2m0s) 35.00400, 139.00000",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q\n%s", want, b.String())
+		}
+	}
+}
+
+// runTrack runs north at 3 m/s for 2.5 km, a point every 10 s, climbing
+// 1 m and taking 28 steps between points. The device records 10% more
+// distance than the positions cover.
+func runTrack() *track.Track {
+	base := time.Unix(1609459200, 0).UTC()
+	t := &track.Track{}
+	for i := range 84 {
+		t.Points = append(t.Points, track.Point{
+			Time:     base.Add(time.Duration(i*10) * time.Second),
+			Lat:      35 + float64(i)*30/(geo.EarthRadius*math.Pi/180),
+			Lon:      139,
+			Ele:      float64(i),
+			HasEle:   true,
+			Distance: float64(i) * 33,
+			Steps:    100 + i*28,
+		})
+	}
+	return t
+}
+
+func TestLaps(t *testing.T) {
+	laps := Laps(runTrack(), LapOptions{})
+	if len(laps) != 3 {
+		t.Fatalf("got %d laps, want 2 full kilometers and the rest", len(laps))
+	}
+	first := laps[0]
+	if math.Abs(first.Distance-1000) > 1e-6 || math.Abs(first.Duration.Seconds()-1000.0/3) > 0.001 {
+		t.Errorf("first lap = %.3f m in %v, want 1000 m in 333.333s", first.Distance, first.Duration)
+	}
+	if pace := first.Pace(); math.Abs(pace.Seconds()-1000.0/3) > 0.01 {
+		t.Errorf("Pace = %v, want 5:33 per km", pace)
+	}
+	if math.Abs(first.ElevationGain-1000.0/30) > 1e-6 || first.ElevationLoss != 0 {
+		t.Errorf("elevation = +%v/-%v, want +33.3/-0", first.ElevationGain, first.ElevationLoss)
+	}
+	if math.Abs(first.Cadence-168) > 0.01 {
+		t.Errorf("Cadence = %v, want 168", first.Cadence)
+	}
+	if !laps[1].Start.Equal(first.End) {
+		t.Errorf("second lap starts at %v, want the end of the first %v", laps[1].Start, first.End)
+	}
+	if last := laps[2]; math.Abs(last.Distance-490) > 1e-6 || !last.End.Equal(time.Unix(1609459200+830, 0)) {
+		t.Errorf("last lap = %.3f m to %v, want the remaining 490 m", last.Distance, last.End)
+	}
+}
+
+func TestLaps_Options(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     LapOptions
+		laps     int
+		distance float64
+		duration time.Duration
+	}{
+		{"miles", LapOptions{Unit: Mile}, 2, 1609.344, 0},
+		{"distance", LapOptions{Distance: 400}, 7, 400, 0},
+		{"time", LapOptions{Time: 5 * time.Minute}, 3, 900, 5 * time.Minute},
+		{"device distance", LapOptions{DeviceDistance: true}, 3, 1000, 303030 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			laps := Laps(runTrack(), tt.opts)
+			if len(laps) != tt.laps {
+				t.Fatalf("got %d laps, want %d", len(laps), tt.laps)
+			}
+			if math.Abs(laps[0].Distance-tt.distance) > 1e-3 {
+				t.Errorf("Distance = %v, want %v", laps[0].Distance, tt.distance)
+			}
+			if tt.duration != 0 && (laps[0].Duration-tt.duration).Abs() > time.Millisecond {
+				t.Errorf("Duration = %v, want %v", laps[0].Duration, tt.duration)
+			}
+		})
+	}
+
+	if laps := Laps(runTrack(), LapOptions{Unit: Mile}); laps[0].Unit != Mile || math.Abs(laps[0].Pace().Seconds()-536.448) > 0.01 {
+		t.Errorf("mile lap pace = %v in %q, want 8:56 per mile", laps[0].Pace(), laps[0].Unit)
+	}
+	// A log without ds is measured between the positions.
+	noDs := runTrack()
+	for i := range noDs.Points {
+		noDs.Points[i].Distance = 0
+	}
+	if laps := Laps(noDs, LapOptions{DeviceDistance: true}); len(laps) != 3 {
+		t.Errorf("without ds got %d laps, want 3", len(laps))
+	}
+	if laps := Laps(&track.Track{Points: runTrack().Points[:1]}, LapOptions{}); laps != nil {
+		t.Errorf("single point got %v, want no laps", laps)
+	}
+}
+
+func TestParseUnit(t *testing.T) {
+	for in, want := range map[string]Unit{"": Kilometer, "km": Kilometer, "MI": Mile} {
+		if got, err := ParseUnit(in); err != nil || got != want {
+			t.Errorf("ParseUnit(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseUnit("furlong"); err == nil {
+		t.Error("ParseUnit(furlong): want error")
+	}
+}
+
+func TestWriteText_Laps(t *testing.T) {
+	rt := runTrack()
+	s := Compute(rt)
+	s.Laps = Laps(rt, LapOptions{})
+
+	var b strings.Builder
+	if err := WriteText(&b, s, i18n.English); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	for _, want := range []string{
+		"Lap:             1. 1.00 km, 5m33s, 5:33 /km, +33.3/-0.0 m, 168 spm",
+		"Lap:             3. 0.49 km, 2m43s, 5:33 /km, +16.3/-0.0 m, 168 spm",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output missing %q\n%s", want, b.String())
+		}
+	}
+}
+
+func TestCumulativeDistances(t *testing.T) {
+	got := CumulativeDistances(testTrack())
+	if len(got) != 4 || got[0] != 0 {
+		t.Fatalf("CumulativeDistances() = %v", got)
+	}
+	if math.Abs(got[1]-111.2) > 1 || got[2] != got[1] {
+		t.Errorf("CumulativeDistances() = %v, want [0 ~111 ~111 ...]", got)
+	}
+	if s := Compute(testTrack()); math.Abs(got[3]-s.Distance) > 1e-9 {
+		t.Errorf("last cumulative distance = %v, want total %v", got[3], s.Distance)
+	}
+}
+
+func TestSpeeds(t *testing.T) {
+	got := Speeds(testTrack())
+	if got[0] != 0 {
+		t.Errorf("speed[0] = %v, want 0", got[0])
+	}
+	if math.Abs(got[1]-111.2/60) > 0.05 {
+		t.Errorf("speed[1] = %v, want derived ~1.85", got[1])
+	}
+	if got[2] != 0 {
+		t.Errorf("speed[2] = %v, want 0 while stationary", got[2])
+	}
+	if got[3] != 4 {
+		t.Errorf("speed[3] = %v, want recorded 4", got[3])
+	}
+}
+
+func TestPressureAltitude(t *testing.T) {
+	tests := []struct {
+		pressure float64
+		want     float64
+	}{
+		{1013.25, 0},
+		{101.325, 0}, // kPa
+		{898.75, 1000},
+	}
+	for _, tt := range tests {
+		if got := pressureAltitude(tt.pressure); math.Abs(got-tt.want) > 1 {
+			t.Errorf("pressureAltitude(%v) = %.1f, want about %v", tt.pressure, got, tt.want)
+		}
+	}
+}
+
+func TestElevations(t *testing.T) {
+	tr := testTrack()
+	if got := Elevations(tr); got[1] != 15 {
+		t.Errorf("Elevations without pressure = %v, want GPS altitude", got)
+	}
+
+	// Barometric altitudes 0 m and ~8.3 m, calibrated to the GPS mean.
+	tr.Points[0].Pressure = 101.325
+	tr.Points[1].Pressure = 101.225
+	got := Elevations(tr)
+	if math.Abs(got[0]+got[1]-(10+15)) > 0.01 {
+		t.Errorf("calibrated elevations %v do not average the GPS altitudes", got[:2])
+	}
+	if math.Abs(got[1]-got[0]-8.3) > 0.1 {
+		t.Errorf("barometric climb = %.2f, want about 8.3", got[1]-got[0])
+	}
+	if got[2] != 12 {
+		t.Errorf("point without pressure = %v, want GPS altitude 12", got[2])
+	}
+
+	tr.Points[2].HasEle = false
+	if got := Elevations(tr); !math.IsNaN(got[2]) {
+		t.Errorf("point without pressure or altitude = %v, want NaN", got[2])
+	}
+}
+
+func TestCadence(t *testing.T) {
+	tr := testTrack()
+	if HasSteps(tr) {
+		t.Error("HasSteps = true for a track without steps")
+	}
+	for i, steps := range []int{100, 250, 250, 10} {
+		tr.Points[i].Steps = steps
+	}
+	if !HasSteps(tr) {
+		t.Error("HasSteps = false, want true")
+	}
+
+	want := []float64{0, 150, 0, 0}
+	if got := Cadence(tr); !equalFloats(got, want) {
+		t.Errorf("Cadence = %v, want %v", got, want)
+	}
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
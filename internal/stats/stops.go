@@ -0,0 +1,125 @@
+package stats
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/chocoby/zweg/internal/geo"
+	"github.com/chocoby/zweg/internal/track"
+)
+
+// Defaults used when StopOptions leave a value zero.
+const (
+	DefaultStopRadius   = 50.0 // meters
+	DefaultStopDuration = 5 * time.Minute
+)
+
+// StopOptions controls stop detection.
+type StopOptions struct {
+	// Radius is how far, in meters, the position may wander from where
+	// the stop began. It defaults to DefaultStopRadius.
+	Radius float64
+	// MinDuration is the shortest stay that counts as a stop. It defaults
+	// to DefaultStopDuration.
+	MinDuration time.Duration
+}
+
+func (o StopOptions) radius() float64 {
+	if o.Radius > 0 {
+		return o.Radius
+	}
+	return DefaultStopRadius
+}
+
+func (o StopOptions) minDuration() time.Duration {
+	if o.MinDuration > 0 {
+		return o.MinDuration
+	}
+	return DefaultStopDuration
+}
+
+// Stop is a place where the track stayed for a while.
+type Stop struct {
+	Arrival   time.Time     `json:"arrival"`
+	Departure time.Time     `json:"departure"`
+	Duration  time.Duration `json:"-"`
+	// Lat and Lon are the mean position of the points during the stop.
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+	// Name is the memo recorded nearest to the stop, if any.
+	Name string `json:"name,omitempty"`
+	// First and Last are the indexes of the points during the stop.
+	First int `json:"-"`
+	Last  int `json:"-"`
+}
+
+// MarshalJSON encodes Duration as seconds, like the durations in Summary.
+func (s Stop) MarshalJSON() ([]byte, error) {
+	type plain Stop
+	return json.Marshal(struct {
+		plain
+		DurationSeconds float64 `json:"duration_s"`
+	}{
+		plain:           plain(s),
+		DurationSeconds: s.Duration.Seconds(),
+	})
+}
+
+// Stops finds the places where t stayed within opts.Radius of a point for
+// at least opts.MinDuration. A stop begins at the first point of the stay
+// and ends at the last point still within the radius of it, so GPS jitter
+// while standing still does not split it. Stops are in time order and do
+// not overlap.
+func Stops(t *track.Track, opts StopOptions) []Stop {
+	radius, minDuration := opts.radius(), opts.minDuration()
+	var out []Stop
+	for i := 0; i < len(t.Points); {
+		anchor := t.Points[i]
+		j := i + 1
+		for j < len(t.Points) && geo.Distance(anchor.Lat, anchor.Lon, t.Points[j].Lat, t.Points[j].Lon) <= radius {
+			j++
+		}
+		if t.Points[j-1].Time.Sub(anchor.Time) < minDuration {
+			i++
+			continue
+		}
+		out = append(out, newStop(t, i, j-1, radius))
+		i = j
+	}
+	return out
+}
+
+// newStop describes the stay from point first to point last.
+func newStop(t *track.Track, first, last int, radius float64) Stop {
+	s := Stop{
+		Arrival:   t.Points[first].Time.UTC(),
+		Departure: t.Points[last].Time.UTC(),
+		Duration:  t.Points[last].Time.Sub(t.Points[first].Time),
+		First:     first,
+		Last:      last,
+	}
+	for _, p := range t.Points[first : last+1] {
+		s.Lat += p.Lat
+		s.Lon += p.Lon
+	}
+	n := float64(last - first + 1)
+	s.Lat /= n
+	s.Lon /= n
+
+	// The memo may have been written during the stay or on arriving or
+	// leaving, just outside of it.
+	best := -1.0
+	for i, p := range t.Points {
+		if p.Desc == "" {
+			continue
+		}
+		d := geo.Distance(s.Lat, s.Lon, p.Lat, p.Lon)
+		if (i < first || i > last) && d > radius {
+			continue
+		}
+		if best < 0 || d < best {
+			s.Name, best = p.Desc, d
+		}
+	}
+	return s
+}
@@ -0,0 +1,129 @@
+package track
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/chocoby/zweg/internal/geo"
+)
+
+// Interpolate returns the point a fraction f of the way from a to b: along
+// the great circle for the position, the short way round for bearings and
+// linearly for the other numeric fields. The elevation and bearings are
+// only known when both ends have one. Desc and Means are taken from a.
+func Interpolate(a, b Point, f float64) Point {
+	lerp := func(x, y float64) float64 { return x + (y-x)*f }
+
+	p := a
+	p.Time = a.Time.Add(time.Duration(float64(b.Time.Sub(a.Time)) * f))
+	p.Lat, p.Lon = geo.Interpolate(a.Lat, a.Lon, b.Lat, b.Lon, f)
+	p.Ele, p.HasEle = lerp(a.Ele, b.Ele), a.HasEle && b.HasEle
+	if !p.HasEle {
+		p.Ele = 0
+	}
+	p.Speed = lerp(a.Speed, b.Speed)
+	p.Course = lerpAngle(a.Course, b.Course, f)
+	p.Heading = lerpAngle(a.Heading, b.Heading, f)
+	p.Distance = lerp(a.Distance, b.Distance)
+	p.HDOP = lerp(a.HDOP, b.HDOP)
+	p.VDOP = lerp(a.VDOP, b.VDOP)
+	p.Pressure = lerp(a.Pressure, b.Pressure)
+	p.Steps = int(math.Round(lerp(float64(a.Steps), float64(b.Steps))))
+	return p
+}
+
+// lerpAngle interpolates between two bearings in degrees the short way round.
+// A negative bearing is unknown, and so is the result when either is.
+func lerpAngle(a, b, f float64) float64 {
+	if a < 0 || b < 0 {
+		return -1
+	}
+	d := math.Mod(b-a+540, 360) - 180
+	return math.Mod(a+d*f+360, 360)
+}
+
+// FillElevation gives the points without an elevation one interpolated
+// linearly, by distance travelled, between the nearest points before and
+// after them that have one. Points before the first or after the last known
+// elevation stay without. It returns the number of points filled.
+func (t *Track) FillElevation() int {
+	// dist is the distance travelled up to each point.
+	dist := make([]float64, len(t.Points))
+	for i := 1; i < len(t.Points); i++ {
+		a, b := t.Points[i-1], t.Points[i]
+		dist[i] = dist[i-1] + geo.Distance(a.Lat, a.Lon, b.Lat, b.Lon)
+	}
+
+	filled := 0
+	prev := -1 // last point with an elevation
+	for i, p := range t.Points {
+		if !p.HasEle {
+			continue
+		}
+		if prev >= 0 && i-prev > 1 {
+			a, b := t.Points[prev], p
+			for j := prev + 1; j < i; j++ {
+				// Fall back to the position in the gap when the points
+				// around it were recorded in one place.
+				f := float64(j-prev) / float64(i-prev)
+				if span := dist[i] - dist[prev]; span > 0 {
+					f = (dist[j] - dist[prev]) / span
+				}
+				t.Points[j].Ele = a.Ele + (b.Ele-a.Ele)*f
+				t.Points[j].HasEle = true
+				filled++
+			}
+		}
+		prev = i
+	}
+	return filled
+}
+
+// At returns the position at tm, interpolated between the recorded points
+// around it, and the index of the recorded point nearest in time. It fails
+// when tm is more than maxGap outside the track or falls in a recording
+// gap longer than maxGap. Points must be in time order.
+func (t *Track) At(tm time.Time, maxGap time.Duration) (Point, int, error) {
+	n := len(t.Points)
+	if n == 0 {
+		return Point{}, 0, fmt.Errorf("track has no points")
+	}
+
+	// i is the first point later than tm.
+	i := sort.Search(n, func(i int) bool { return t.Points[i].Time.After(tm) })
+	switch {
+	case i == 0:
+		first := t.Points[0]
+		if d := first.Time.Sub(tm); d > maxGap {
+			return Point{}, 0, fmt.Errorf("%s before the track starts", d)
+		}
+		p := first
+		p.Time = tm
+		return p, 0, nil
+	case i == n:
+		last := t.Points[n-1]
+		if d := tm.Sub(last.Time); d > maxGap {
+			return Point{}, n - 1, fmt.Errorf("%s after the track ends", d)
+		}
+		p := last
+		p.Time = tm
+		return p, n - 1, nil
+	}
+
+	a, b := t.Points[i-1], t.Points[i]
+	nearest := i - 1
+	if b.Time.Sub(tm) < tm.Sub(a.Time) {
+		nearest = i
+	}
+
+	span := b.Time.Sub(a.Time)
+	if tm.Equal(a.Time) {
+		return a, nearest, nil
+	}
+	if span > maxGap {
+		return Point{}, nearest, fmt.Errorf("in a %s recording gap", span)
+	}
+	return Interpolate(a, b, float64(tm.Sub(a.Time))/float64(span)), nearest, nil
+}
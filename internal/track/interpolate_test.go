@@ -0,0 +1,126 @@
+package track
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestInterpolate(t *testing.T) {
+	base := time.Unix(1609459200, 0).UTC()
+	a := Point{Time: base, Lat: 35, Lon: 139, Ele: 10, HasEle: true, Course: 350, Steps: 10, Desc: "memo"}
+	b := Point{Time: base.Add(10 * time.Second), Lat: 35.01, Lon: 139, Ele: 20, HasEle: true, Course: 30, Steps: 20}
+
+	p := Interpolate(a, b, 0.25)
+	if !p.Time.Equal(base.Add(2500 * time.Millisecond)) {
+		t.Errorf("Time = %v, want 2.5s after start", p.Time)
+	}
+	if math.Abs(p.Lat-35.0025) > 1e-6 || p.Lon != 139 {
+		t.Errorf("position = (%v, %v), want (35.0025, 139)", p.Lat, p.Lon)
+	}
+	if p.Ele != 12.5 || !p.HasEle || p.Steps != 13 || p.Desc != "memo" {
+		t.Errorf("Interpolate() = %+v", p)
+	}
+	b.HasEle = false
+	if p := Interpolate(a, b, 0.25); p.HasEle || p.Ele != 0 {
+		t.Errorf("Interpolate() to a point without elevation = %v, %v, want none", p.Ele, p.HasEle)
+	}
+	if math.Abs(p.Course-0) > 1e-9 {
+		t.Errorf("Course = %v, want 0 (the short way through north)", p.Course)
+	}
+	b.Course = -1
+	if p := Interpolate(a, b, 0.25); p.Course >= 0 {
+		t.Errorf("Course to an unknown course = %v, want unknown", p.Course)
+	}
+}
+
+func TestTrack_FillElevation(t *testing.T) {
+	known := func(lat, ele float64) Point { return Point{Lat: lat, Ele: ele, HasEle: true} }
+	missing := func(lat float64) Point { return Point{Lat: lat} }
+
+	tr := &Track{Points: []Point{
+		missing(35.000),
+		known(35.001, 100),
+		missing(35.002),
+		missing(35.004),
+		known(35.005, 140),
+		known(35.005, 150),
+		missing(35.005),
+		known(35.005, 170),
+		missing(35.006),
+	}}
+
+	if got := tr.FillElevation(); got != 3 {
+		t.Errorf("FillElevation() = %d, want 3", got)
+	}
+	want := []struct {
+		ele    float64
+		hasEle bool
+	}{
+		{0, false},
+		{100, true},
+		{110, true}, // a quarter of the distance
+		{130, true},
+		{140, true},
+		{150, true},
+		{160, true}, // no distance: halfway by position
+		{170, true},
+		{0, false},
+	}
+	for i, w := range want {
+		p := tr.Points[i]
+		if p.HasEle != w.hasEle || math.Abs(p.Ele-w.ele) > 1e-6 {
+			t.Errorf("point[%d] elevation = %v, %v, want %v, %v", i, p.Ele, p.HasEle, w.ele, w.hasEle)
+		}
+	}
+}
+
+func TestTrack_At(t *testing.T) {
+	base := time.Unix(1609459200, 0).UTC()
+	tr := &Track{Points: []Point{
+		{Time: base, Lat: 35.000, Lon: 139},
+		{Time: base.Add(10 * time.Second), Lat: 35.001, Lon: 139},
+		{Time: base.Add(20 * time.Second), Lat: 35.002, Lon: 139},
+		{Time: base.Add(20 * time.Minute), Lat: 35.100, Lon: 139},
+	}}
+
+	tests := []struct {
+		name    string
+		offset  time.Duration
+		wantLat float64
+		nearest int
+		wantErr bool
+	}{
+		{"on a point", 10 * time.Second, 35.001, 1, false},
+		{"between points", 13 * time.Second, 35.0013, 1, false},
+		{"nearer the later point", 18 * time.Second, 35.0018, 2, false},
+		{"shortly before the start", -30 * time.Second, 35.000, 0, false},
+		{"shortly after the end", 20*time.Minute + 30*time.Second, 35.100, 3, false},
+		{"long before the start", -time.Hour, 0, 0, true},
+		{"in a recording gap", 10 * time.Minute, 0, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, nearest, err := tr.At(base.Add(tt.offset), time.Minute)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("At() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("At() error = %v", err)
+			}
+			if math.Abs(p.Lat-tt.wantLat) > 1e-6 {
+				t.Errorf("At() lat = %v, want %v", p.Lat, tt.wantLat)
+			}
+			if nearest != tt.nearest {
+				t.Errorf("At() nearest = %d, want %d", nearest, tt.nearest)
+			}
+			if !p.Time.Equal(base.Add(tt.offset)) {
+				t.Errorf("At() time = %v, want %v", p.Time, base.Add(tt.offset))
+			}
+		})
+	}
+}
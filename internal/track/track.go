@@ -0,0 +1,169 @@
+// Package track defines the format-neutral track model that sits between
+// input readers and output writers.
+package track
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chocoby/zweg/internal/i18n"
+	"github.com/chocoby/zweg/internal/models"
+)
+
+// Point is a single recorded position. Zero values mean "not recorded"
+// except for Lat and Lon, which are always present.
+type Point struct {
+	Time     time.Time
+	Lat      float64
+	Lon      float64
+	Ele      float64 // meters; only meaningful when HasEle is set
+	HasEle   bool
+	Speed    float64 // meters per second
+	Course   float64 // degrees, true bearing of motion; negative when unknown
+	Heading  float64 // degrees, true heading of the device; negative when unknown
+	Distance float64 // cumulative meters as reported by the device
+	HDOP     float64
+	VDOP     float64
+	Pressure float64 // atmospheric pressure as recorded (kPa or hPa)
+	Steps    int     // pedometer count as recorded
+	Desc     string
+	Means    *models.Means
+}
+
+// Track is an ordered sequence of points with optional metadata.
+type Track struct {
+	// Name is the recorded title, if any. Writers fall back to a
+	// generated name when it is empty.
+	Name   string
+	Points []Point
+}
+
+// Start returns the time of the first point, or the zero time for an empty track.
+func (t *Track) Start() time.Time {
+	if len(t.Points) == 0 {
+		return time.Time{}
+	}
+	return t.Points[0].Time
+}
+
+// FirstMeans returns the first recorded means of transportation.
+// The bool is false when no point has one.
+func (t *Track) FirstMeans() (models.Means, bool) {
+	for _, p := range t.Points {
+		if p.Means != nil {
+			return *p.Means, true
+		}
+	}
+	return 0, false
+}
+
+// DefaultName returns the track name used when none is given explicitly:
+// the recorded name, then the English means name, then "Track".
+func (t *Track) DefaultName() string {
+	return t.DefaultNameIn(i18n.English)
+}
+
+// DefaultNameIn is like DefaultName with the means name and the final
+// fallback in lang.
+func (t *Track) DefaultNameIn(lang i18n.Lang) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	if m, ok := t.FirstMeans(); ok {
+		if name := m.String(); name != "" {
+			return lang.T(name)
+		}
+	}
+	return lang.T("Track")
+}
+
+// Errors returned by FromZweite for a point whose altitude, speed or
+// distance is not a number.
+var (
+	ErrBadAltitude = errors.New("failed to parse altitude")
+	ErrBadSpeed    = errors.New("failed to parse speed")
+	ErrBadDistance = errors.New("failed to parse distance")
+)
+
+// fieldErrors maps the JSON key of a malformed value to its error.
+var fieldErrors = map[string]error{
+	"al": ErrBadAltitude,
+	"sp": ErrBadSpeed,
+	"ds": ErrBadDistance,
+}
+
+// Malformed is a string-encoded numeric value of a ZweiteGPS point that
+// did not parse and was treated as not recorded.
+type Malformed struct {
+	Index int    // index of the point in the log
+	Field string // JSON key: "al", "sp" or "ds"
+	Value string
+}
+
+// FromZweite converts ZweiteGPS points to a Track. String-encoded numeric
+// fields are parsed here; an altitude, speed or distance that does not
+// parse is an error.
+func FromZweite(points []models.Point) (*Track, error) {
+	t, malformed := FromZweiteLenient(points)
+	if len(malformed) > 0 {
+		m := malformed[0]
+		return nil, fmt.Errorf("%w at point %d: %q is not a number", fieldErrors[m.Field], m.Index, m.Value)
+	}
+	return t, nil
+}
+
+// FromZweiteLenient is like FromZweite, but treats an unparsable value as
+// not recorded (an altitude leaves HasEle false, a speed or distance is
+// zero), so that one corrupted point does not lose the whole log. It
+// returns every value it could not parse, in point order.
+func FromZweiteLenient(points []models.Point) (*Track, []Malformed) {
+	t := &Track{
+		Name:   models.FirstTitle(points),
+		Points: make([]Point, 0, len(points)),
+	}
+
+	var malformed []Malformed
+	// parse returns the value of a field and whether it parsed.
+	parse := func(i int, field, value string, accessor func() (float64, error)) (float64, bool) {
+		v, err := accessor()
+		if err != nil {
+			malformed = append(malformed, Malformed{Index: i, Field: field, Value: value})
+			return 0, false
+		}
+		return v, true
+	}
+
+	for i := range points {
+		p := &points[i]
+		ele, ok := parse(i, "al", p.Al, p.Altitude)
+		speed, _ := parse(i, "sp", p.Sp, p.Speed)
+		distance, _ := parse(i, "ds", p.Ds, p.Distance)
+		t.Points = append(t.Points, Point{
+			Time:     p.TimestampIn(time.UTC),
+			Lat:      p.La,
+			Lon:      p.Lo,
+			Ele:      ele,
+			HasEle:   ok && p.HasAltitude(),
+			Speed:    speed,
+			Course:   float64(p.Co),
+			Heading:  float64(p.Th),
+			Distance: distance,
+			HDOP:     p.Ha,
+			VDOP:     p.Va,
+			Pressure: p.Ap,
+			Steps:    p.Ws,
+			Desc:     p.Dp,
+			Means:    p.Ms,
+		})
+	}
+
+	return t, malformed
+}
+
+// Shift moves every timestamp by d.
+func (t *Track) Shift(d time.Duration) {
+	for i := range t.Points {
+		t.Points[i].Time = t.Points[i].Time.Add(d)
+	}
+}
@@ -0,0 +1,129 @@
+package track
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chocoby/zweg/internal/models"
+)
+
+func TestFromZweite(t *testing.T) {
+	bicycle := models.MeansBicycle
+	points := []models.Point{
+		{Tm: 1609459200, La: 35.6812, Lo: 139.7454, Al: "10.5", Sp: "2.5", Ds: "0", Co: 90, Th: 80, Ha: 5, Va: 3, Dp: "memo", Ms: &bicycle},
+		{Tm: 1609459260, La: 35.6813, Lo: 139.7455, Al: "", Sp: "", Ds: "120.5", Ws: 42, Tl: "Ride"},
+	}
+
+	tr, err := FromZweite(points)
+	if err != nil {
+		t.Fatalf("FromZweite() unexpected error = %v", err)
+	}
+
+	if tr.Name != "Ride" {
+		t.Errorf("Name = %q, want Ride", tr.Name)
+	}
+	if !tr.Start().Equal(time.Unix(1609459200, 0)) {
+		t.Errorf("Start() = %v", tr.Start())
+	}
+
+	p := tr.Points[0]
+	if p.Ele != 10.5 || !p.HasEle || p.Speed != 2.5 || p.Course != 90 || p.Heading != 80 || p.HDOP != 5 || p.VDOP != 3 || p.Desc != "memo" {
+		t.Errorf("point[0] = %+v", p)
+	}
+	if p.Means == nil || *p.Means != models.MeansBicycle {
+		t.Errorf("point[0].Means = %v, want Bicycle", p.Means)
+	}
+
+	p = tr.Points[1]
+	if p.HasEle {
+		t.Errorf("point[1].HasEle = true, want false for an empty altitude")
+	}
+	if p.Speed != 0 {
+		t.Errorf("point[1].Speed = %v, want 0 for an empty value", p.Speed)
+	}
+	if p.Distance != 120.5 || p.Steps != 42 {
+		t.Errorf("point[1] = %+v", p)
+	}
+}
+
+func TestFromZweite_InvalidAltitude(t *testing.T) {
+	_, err := FromZweite([]models.Point{{Tm: 1, Al: "x"}})
+	if err == nil || !strings.Contains(err.Error(), "failed to parse altitude at point 0") {
+		t.Errorf("FromZweite() error = %v, want altitude error", err)
+	}
+	if !errors.Is(err, ErrBadAltitude) {
+		t.Errorf("FromZweite() error = %v, want ErrBadAltitude", err)
+	}
+	if strings.Count(err.Error(), "failed to parse") != 1 {
+		t.Errorf("FromZweite() error = %q, want a single prefix", err)
+	}
+}
+
+func TestFromZweite_InvalidSpeedAndDistance(t *testing.T) {
+	_, err := FromZweite([]models.Point{{Tm: 1, Al: "1"}, {Tm: 2, Sp: "fast"}})
+	if !errors.Is(err, ErrBadSpeed) || !strings.Contains(err.Error(), `at point 1: "fast"`) {
+		t.Errorf("FromZweite(bad speed) error = %v, want ErrBadSpeed at point 1", err)
+	}
+	_, err = FromZweite([]models.Point{{Tm: 1, Ds: "?"}})
+	if !errors.Is(err, ErrBadDistance) {
+		t.Errorf("FromZweite(bad distance) error = %v, want ErrBadDistance", err)
+	}
+}
+
+func TestFromZweiteLenient(t *testing.T) {
+	points := []models.Point{
+		{Tm: 1, Al: "10", Sp: "1", Ds: "0"},
+		{Tm: 2, Al: "x", Sp: "fast", Ds: "5"},
+		{Tm: 3, Al: "12", Sp: "2", Ds: "?"},
+	}
+
+	tr, malformed := FromZweiteLenient(points)
+	if len(tr.Points) != len(points) {
+		t.Fatalf("len(Points) = %d, want %d", len(tr.Points), len(points))
+	}
+	if p := tr.Points[1]; p.HasEle || p.Speed != 0 || p.Distance != 5 {
+		t.Errorf("point[1] = %+v, want unparsable values as not recorded", p)
+	}
+	want := []Malformed{{1, "al", "x"}, {1, "sp", "fast"}, {2, "ds", "?"}}
+	if !reflect.DeepEqual(malformed, want) {
+		t.Errorf("malformed = %+v, want %+v", malformed, want)
+	}
+
+	if _, malformed := FromZweiteLenient(points[:1]); malformed != nil {
+		t.Errorf("malformed = %+v, want none", malformed)
+	}
+}
+
+func TestTrack_DefaultName(t *testing.T) {
+	train := models.MeansTrain
+
+	tests := []struct {
+		name  string
+		track Track
+		want  string
+	}{
+		{"recorded name", Track{Name: "Commute", Points: []Point{{Means: &train}}}, "Commute"},
+		{"means fallback", Track{Points: []Point{{}, {Means: &train}}}, "Train"},
+		{"nothing recorded", Track{Points: []Point{{}}}, "Track"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.track.DefaultName(); got != tt.want {
+				t.Errorf("DefaultName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrack_Shift(t *testing.T) {
+	base := time.Unix(1609459200, 0).UTC()
+	tr := &Track{Points: []Point{{Time: base}, {Time: base.Add(time.Minute)}}}
+	tr.Shift(-9 * time.Hour)
+	if want := base.Add(-9 * time.Hour); !tr.Points[0].Time.Equal(want) || !tr.Points[1].Time.Equal(want.Add(time.Minute)) {
+		t.Errorf("Shift(-9h) times = %v, %v", tr.Points[0].Time, tr.Points[1].Time)
+	}
+}
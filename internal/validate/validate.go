@@ -0,0 +1,106 @@
+// Package validate checks ZweiteGPS points for values that would produce a
+// wrong or failed conversion.
+package validate
+
+import (
+	"fmt"
+
+	"github.com/chocoby/zweg/internal/models"
+)
+
+// Severity classifies an Issue.
+type Severity string
+
+const (
+	// SeverityError marks a problem that makes conversion fail or produce
+	// invalid output.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a suspicious value that is converted anyway.
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single finding. Index is the point index in the input.
+type Issue struct {
+	Index    int      `json:"index"`
+	Field    string   `json:"field"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Report is the result of validating one log.
+type Report struct {
+	Valid    bool    `json:"valid"`
+	Points   int     `json:"points"`
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+	Issues   []Issue `json:"issues"`
+}
+
+// String formats the issue as "point 3: warning: sp: speed ...".
+func (i Issue) String() string {
+	return fmt.Sprintf("point %d: %s: %s: %s", i.Index, i.Severity, i.Field, i.Message)
+}
+
+func (r *Report) add(index int, field string, sev Severity, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{
+		Index:    index,
+		Field:    field,
+		Severity: sev,
+		Message:  fmt.Sprintf(format, args...),
+	})
+	if sev == SeverityError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+}
+
+// Points validates points and returns a report. The log is valid when it
+// has at least one point and no error-level issues.
+func Points(points []models.Point) Report {
+	r := Report{Points: len(points), Issues: []Issue{}}
+
+	if len(points) == 0 {
+		r.add(-1, "", SeverityError, "no data points")
+	}
+
+	for i, p := range points {
+		if p.Tm <= 0 {
+			r.add(i, "tm", SeverityError, "timestamp %d is not a positive Unix time", p.Tm)
+		}
+		if p.La < -90 || p.La > 90 {
+			r.add(i, "la", SeverityError, "latitude %v is out of range [-90, 90]", p.La)
+		}
+		if p.Lo < -180 || p.Lo > 180 {
+			r.add(i, "lo", SeverityError, "longitude %v is out of range [-180, 180]", p.Lo)
+		}
+		if _, err := p.Altitude(); err != nil {
+			r.add(i, "al", SeverityError, "altitude %q is not a number", p.Al)
+		}
+		if _, err := p.Speed(); err != nil {
+			r.add(i, "sp", SeverityWarning, "speed %q is not a number", p.Sp)
+		}
+		if _, err := p.Distance(); err != nil {
+			r.add(i, "ds", SeverityWarning, "distance %q is not a number", p.Ds)
+		}
+		if p.Ms != nil && p.Ms.String() == "" {
+			r.add(i, "ms", SeverityWarning, "unknown means of transportation %d", int(*p.Ms))
+		}
+		if p.Ha < 0 || p.Va < 0 {
+			r.add(i, "ha", SeverityWarning, "negative accuracy (ha=%v, va=%v) marks an invalid fix", p.Ha, p.Va)
+		}
+
+		if i == 0 {
+			continue
+		}
+		switch prev := points[i-1].Tm; {
+		case p.Tm < prev:
+			r.add(i, "tm", SeverityWarning, "timestamp goes back %ds from the previous point", prev-p.Tm)
+		case p.Tm == prev:
+			r.add(i, "tm", SeverityWarning, "duplicate timestamp %d", p.Tm)
+		}
+	}
+
+	r.Valid = len(points) > 0 && r.Errors == 0
+	return r
+}
@@ -0,0 +1,62 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/chocoby/zweg/internal/models"
+)
+
+func TestPoints(t *testing.T) {
+	unknown := models.Means(42)
+
+	tests := []struct {
+		name       string
+		points     []models.Point
+		wantValid  bool
+		wantFields []string
+	}{
+		{
+			name:      "valid log",
+			points:    []models.Point{{Tm: 1, La: 35, Lo: 139, Al: "10", Sp: "1.0", Ds: "0"}, {Tm: 2, La: 35, Lo: 139}},
+			wantValid: true,
+		},
+		{
+			name:       "empty log",
+			points:     nil,
+			wantValid:  false,
+			wantFields: []string{""},
+		},
+		{
+			name:       "bad altitude and coordinates",
+			points:     []models.Point{{Tm: 1, La: 95, Lo: 200, Al: "abc"}},
+			wantValid:  false,
+			wantFields: []string{"la", "lo", "al"},
+		},
+		{
+			name:       "warnings only",
+			points:     []models.Point{{Tm: 5, Sp: "fast", Ms: &unknown}, {Tm: 4, Ds: "?"}, {Tm: 4, Ha: -1}},
+			wantValid:  true,
+			wantFields: []string{"sp", "ms", "ds", "tm", "ha", "tm"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Points(tt.points)
+			if r.Valid != tt.wantValid {
+				t.Errorf("Valid = %v, want %v (issues: %+v)", r.Valid, tt.wantValid, r.Issues)
+			}
+			if len(r.Issues) != len(tt.wantFields) {
+				t.Fatalf("issues = %+v, want fields %v", r.Issues, tt.wantFields)
+			}
+			for i, f := range tt.wantFields {
+				if r.Issues[i].Field != f {
+					t.Errorf("issue[%d].Field = %q, want %q", i, r.Issues[i].Field, f)
+				}
+			}
+			if r.Errors+r.Warnings != len(r.Issues) {
+				t.Errorf("Errors+Warnings = %d, want %d", r.Errors+r.Warnings, len(r.Issues))
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+//go:build linux
+
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_MODIFY | syscall.IN_CREATE
+
+// inotify reports changed files in a single directory.
+type inotify struct {
+	file   *os.File
+	events chan string
+	// done is closed by Close, so read stops even when nobody receives
+	// its events any more.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newNotifier(dir string) (notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify_init1: %w", err)
+	}
+	if _, err := syscall.InotifyAddWatch(fd, dir, inotifyMask); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("inotify_add_watch %q: %w", dir, err)
+	}
+
+	// A non-blocking descriptor is registered with the runtime poller, so
+	// Close unblocks a pending Read.
+	n := &inotify{
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan string, 64),
+		done:   make(chan struct{}),
+	}
+	go n.read(dir)
+	return n, nil
+}
+
+func (n *inotify) Events() <-chan string { return n.events }
+
+func (n *inotify) Close() error {
+	n.closeOnce.Do(func() { close(n.done) })
+	return n.file.Close()
+}
+
+func (n *inotify) read(dir string) {
+	defer close(n.events)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		nr, err := n.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= nr; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nameStart := off + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(ev.Len)
+			if nameEnd > nr {
+				break
+			}
+			name := string(bytes.TrimRight(buf[nameStart:nameEnd], "\x00"))
+			off = nameEnd
+
+			if name != "" && ev.Mask&syscall.IN_ISDIR == 0 {
+				select {
+				case n.events <- filepath.Join(dir, name):
+				case <-n.done:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const stateVersion = 1
+
+// fileSig identifies one version of a file's content well enough to tell
+// whether it changed since it was last converted.
+type fileSig struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+func sigOf(info fs.FileInfo) fileSig {
+	return fileSig{Size: info.Size(), ModTime: info.ModTime().UTC()}
+}
+
+// stateEntry records a successful conversion.
+type stateEntry struct {
+	fileSig
+	ConvertedAt time.Time `json:"converted_at"`
+}
+
+// state is the persisted set of already processed files, keyed by the
+// file name relative to the watched directory.
+type state struct {
+	Version int                    `json:"version"`
+	Files   map[string]*stateEntry `json:"files"`
+}
+
+func loadState(path string) (*state, error) {
+	s := &state{Version: stateVersion, Files: make(map[string]*stateEntry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file %q: %w", path, err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %q: %w", path, err)
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]*stateEntry)
+	}
+	return s, nil
+}
+
+// save writes the state atomically so a crash never leaves a truncated file.
+func (s *state) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".zweg-state-*")
+	if err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+func (s *state) done(name string, sig fileSig) bool {
+	e, ok := s.Files[name]
+	return ok && e.fileSig.Size == sig.Size && e.fileSig.ModTime.Equal(sig.ModTime)
+}
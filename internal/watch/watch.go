@@ -0,0 +1,235 @@
+// Package watch converts ZweiteGPS logs as they appear in a directory.
+//
+// Changes are picked up with inotify on Linux and by polling elsewhere, or
+// when inotify is unavailable (for example on some network file systems).
+// A file is only handed to the converter once its size and modification
+// time have stopped changing for the settle period, so logs that are still
+// being synced are not converted half-written. Successfully converted files
+// are recorded in a state file so a restart does not redo them.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultInterval is how often pending files are re-checked, and how
+	// often the directory is scanned when polling.
+	DefaultInterval = 2 * time.Second
+	// DefaultSettle is how long a file must stay unchanged before it is converted.
+	DefaultSettle = 5 * time.Second
+	// DefaultStateFile is the state file name used inside the watched directory.
+	DefaultStateFile = ".zweg-watch.json"
+)
+
+// notifier delivers the paths of files that changed in the watched directory.
+type notifier interface {
+	Events() <-chan string
+	Close() error
+}
+
+// Config holds watcher configuration.
+type Config struct {
+	// Dir is the directory to watch. Only *.json, *.json.gz and *.zip
+	// files directly inside it are considered; hidden files are ignored.
+	Dir string
+	// StateFile is where processed files are recorded. Defaults to
+	// DefaultStateFile inside Dir.
+	StateFile string
+	Interval  time.Duration
+	Settle    time.Duration
+	// Poll disables inotify and always scans the directory.
+	Poll bool
+	// Convert is called with the path of each settled, unprocessed file.
+	Convert func(path string) error
+	// Log receives progress and error messages. Nil discards them.
+	Log io.Writer
+}
+
+// pendingFile is a file seen changing that has not settled yet.
+type pendingFile struct {
+	sig   fileSig
+	since time.Time
+}
+
+// Watcher watches one directory.
+type Watcher struct {
+	config  Config
+	state   *state
+	pending map[string]*pendingFile
+	// failed remembers the version of a file whose conversion failed, so it
+	// is retried only after the file changes again.
+	failed map[string]fileSig
+}
+
+// New creates a Watcher, filling unset Config fields with defaults.
+func New(config *Config) *Watcher {
+	cfg := *config
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Settle <= 0 {
+		cfg.Settle = DefaultSettle
+	}
+	if cfg.StateFile == "" {
+		cfg.StateFile = filepath.Join(cfg.Dir, DefaultStateFile)
+	}
+	if cfg.Log == nil {
+		cfg.Log = io.Discard
+	}
+	return &Watcher{
+		config:  cfg,
+		pending: make(map[string]*pendingFile),
+		failed:  make(map[string]fileSig),
+	}
+}
+
+// Run watches until ctx is cancelled. Files already in the directory are
+// converted too unless the state file records them as processed.
+func (w *Watcher) Run(ctx context.Context) error {
+	info, err := os.Stat(w.config.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to watch %q: %w", w.config.Dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("failed to watch %q: not a directory", w.config.Dir)
+	}
+
+	w.state, err = loadState(w.config.StateFile)
+	if err != nil {
+		return err
+	}
+
+	var events <-chan string
+	if !w.config.Poll {
+		n, err := newNotifier(w.config.Dir)
+		if err != nil {
+			w.logf("inotify unavailable (%v), polling every %s", err, w.config.Interval)
+		} else {
+			defer func() { _ = n.Close() }()
+			events = n.Events()
+		}
+	}
+
+	if err := w.scan(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case path, ok := <-events:
+			if !ok {
+				w.logf("inotify stopped, polling every %s", w.config.Interval)
+				events = nil
+				continue
+			}
+			if w.wanted(filepath.Base(path)) {
+				w.observe(path)
+			}
+		case <-ticker.C:
+			if events == nil {
+				if err := w.scan(); err != nil {
+					w.logf("%v", err)
+				}
+			} else {
+				for path := range w.pending {
+					w.observe(path)
+				}
+			}
+			w.process(ctx)
+		}
+	}
+}
+
+// wanted reports whether a file name in the watched directory is a log.
+func (w *Watcher) wanted(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	if filepath.Join(w.config.Dir, name) == filepath.Clean(w.config.StateFile) {
+		return false
+	}
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".json", ".json.gz", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// scan observes every log in the directory.
+func (w *Watcher) scan() error {
+	entries, err := os.ReadDir(w.config.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to scan %q: %w", w.config.Dir, err)
+	}
+	for _, e := range entries {
+		if e.Type().IsRegular() && w.wanted(e.Name()) {
+			w.observe(filepath.Join(w.config.Dir, e.Name()))
+		}
+	}
+	return nil
+}
+
+// observe records the current version of path, restarting its settle
+// period whenever the file changed since it was last seen.
+func (w *Watcher) observe(path string) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		delete(w.pending, path)
+		return
+	}
+
+	sig := sigOf(info)
+	name := filepath.Base(path)
+	if w.state.done(name, sig) || w.failed[path] == sig {
+		delete(w.pending, path)
+		return
+	}
+
+	if p, ok := w.pending[path]; ok && p.sig == sig {
+		return
+	}
+	w.pending[path] = &pendingFile{sig: sig, since: time.Now()}
+}
+
+// process converts every pending file that has settled.
+func (w *Watcher) process(ctx context.Context) {
+	for path, p := range w.pending {
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(p.since) < w.config.Settle {
+			continue
+		}
+		delete(w.pending, path)
+
+		if err := w.config.Convert(path); err != nil {
+			w.failed[path] = p.sig
+			w.logf("%s: %v", path, err)
+			continue
+		}
+		delete(w.failed, path)
+
+		w.state.Files[filepath.Base(path)] = &stateEntry{fileSig: p.sig, ConvertedAt: time.Now().UTC()}
+		if err := w.state.save(w.config.StateFile); err != nil {
+			w.logf("%v", err)
+		}
+	}
+}
+
+func (w *Watcher) logf(format string, args ...any) {
+	_, _ = fmt.Fprintf(w.config.Log, "watch: "+format+"\n", args...)
+}
@@ -0,0 +1,165 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder collects the paths handed to Convert.
+type recorder struct {
+	mu    sync.Mutex
+	paths []string
+	fail  map[string]bool
+}
+
+func (r *recorder) convert(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths = append(r.paths, filepath.Base(path))
+	if r.fail[filepath.Base(path)] {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func (r *recorder) count(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, p := range r.paths {
+		if p == name {
+			n++
+		}
+	}
+	return n
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func startWatcher(t *testing.T, dir string, poll bool, rec *recorder) context.CancelFunc {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	w := New(&Config{
+		Dir:      dir,
+		Interval: 10 * time.Millisecond,
+		Settle:   40 * time.Millisecond,
+		Poll:     poll,
+		Convert:  rec.convert,
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	})
+	return cancel
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestWatcher_ConvertsNewAndModifiedFiles(t *testing.T) {
+	for _, poll := range []bool{true, false} {
+		name := "inotify"
+		if poll {
+			name = "polling"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "existing.json"), "[]")
+			writeFile(t, filepath.Join(dir, "notes.txt"), "ignored")
+
+			rec := &recorder{}
+			startWatcher(t, dir, poll, rec)
+
+			waitFor(t, func() bool { return rec.count("existing.json") == 1 })
+
+			writeFile(t, filepath.Join(dir, "new.json"), "[1]")
+			waitFor(t, func() bool { return rec.count("new.json") == 1 })
+
+			// A later modification is converted again.
+			time.Sleep(20 * time.Millisecond)
+			writeFile(t, filepath.Join(dir, "new.json"), "[1, 2]")
+			waitFor(t, func() bool { return rec.count("new.json") == 2 })
+
+			if n := rec.count("notes.txt"); n != 0 {
+				t.Errorf("non-JSON file converted %d times", n)
+			}
+			if n := rec.count(DefaultStateFile); n != 0 {
+				t.Errorf("state file converted %d times", n)
+			}
+		})
+	}
+}
+
+func TestWatcher_StateSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.json"), "[]")
+
+	first := &recorder{}
+	cancel := startWatcher(t, dir, true, first)
+	waitFor(t, func() bool { return first.count("a.json") == 1 })
+	waitFor(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, DefaultStateFile))
+		return err == nil
+	})
+	cancel()
+
+	second := &recorder{}
+	startWatcher(t, dir, true, second)
+	writeFile(t, filepath.Join(dir, "b.json"), "[]")
+	waitFor(t, func() bool { return second.count("b.json") == 1 })
+
+	if n := second.count("a.json"); n != 0 {
+		t.Errorf("already processed file converted again %d times after restart", n)
+	}
+}
+
+func TestWatcher_FailedFileRetriedOnlyAfterChange(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "bad.json"), "{")
+
+	rec := &recorder{fail: map[string]bool{"bad.json": true}}
+	startWatcher(t, dir, true, rec)
+	waitFor(t, func() bool { return rec.count("bad.json") == 1 })
+
+	time.Sleep(100 * time.Millisecond)
+	if n := rec.count("bad.json"); n != 1 {
+		t.Fatalf("unchanged failed file converted %d times, want 1", n)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	writeFile(t, filepath.Join(dir, "bad.json"), "{ still bad")
+	waitFor(t, func() bool { return rec.count("bad.json") == 2 })
+}
+
+func TestWatcher_NotADirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.json")
+	writeFile(t, path, "[]")
+
+	w := New(&Config{Dir: path, Convert: func(string) error { return nil }})
+	if err := w.Run(context.Background()); err == nil {
+		t.Error("Run() error = nil, want error for non-directory")
+	}
+}
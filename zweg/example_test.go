@@ -0,0 +1,84 @@
+package zweg_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/chocoby/zweg/zweg"
+)
+
+const exampleLog = `[
+	{"tm": 1609459200, "lo": 139.7454, "la": 35.6812, "al": "100.5", "sp": "0", "co": 0, "th": 0, "he": 0, "ds": "0", "tl": "Tokyo Run"},
+	{"tm": 1609459260, "lo": 139.7460, "la": 35.6815, "al": "101.0", "sp": "1.2", "co": 90, "th": 0, "he": 0, "ds": "60"}
+]`
+
+func ExampleDecode() {
+	points, err := zweg.Decode(context.Background(), strings.NewReader(exampleLog))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(len(points), "points")
+	fmt.Println(zweg.TrackName(points))
+	// Output:
+	// 2 points
+	// Tokyo Run
+}
+
+func ExampleConvert() {
+	ctx := context.Background()
+	points, err := zweg.Decode(ctx, strings.NewReader(exampleLog))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g, err := zweg.Convert(ctx, points, &zweg.Options{TrackName: "Lunch Walk", OmitWaypoints: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(g.Trk[0].Name)
+	fmt.Println(len(g.Trk[0].TrkSeg[0].TrkPt), "trackpoints,", len(g.Wpt), "waypoints")
+	// Output:
+	// Lunch Walk
+	// 2 trackpoints, 0 waypoints
+}
+
+func ExampleEncode() {
+	ctx := context.Background()
+	points := []zweg.Point{
+		{Tm: 1609459200, La: 35.6812, Lo: 139.7454, Al: "100.5"},
+	}
+
+	err := zweg.Encode(ctx, os.Stdout, points, &zweg.Options{
+		TrackName:     "Single",
+		Format:        zweg.FormatGPX,
+		OmitWaypoints: true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// <?xml version="1.0"?>
+	// <gpx version="1.1" creator="zweg - ZweiteGPS to GPX Converter" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns="http://www.topografix.com/GPX/1/1" xsi:schemaLocation="http://www.topografix.com/GPX/1/1 https://www.topografix.com/GPX/1/1/gpx.xsd">
+	//   <metadata>
+	//     <name>Single</name>
+	//     <desc>Distance: 0.00 km, Duration: 0s, Elevation gain: 0 m</desc>
+	//     <time>2021-01-01T00:00:00Z</time>
+	//     <bounds minlat="35.6812" minlon="139.7454" maxlat="35.6812" maxlon="139.7454"></bounds>
+	//   </metadata>
+	//   <trk>
+	//     <name>Single</name>
+	//     <desc>Distance: 0.00 km, Duration: 0s, Elevation gain: 0 m</desc>
+	//     <trkseg>
+	//       <trkpt lat="35.6812" lon="139.7454">
+	//         <ele>100.5</ele>
+	//         <time>2021-01-01T00:00:00Z</time>
+	//       </trkpt>
+	//     </trkseg>
+	//   </trk>
+	// </gpx>
+}
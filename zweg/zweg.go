@@ -0,0 +1,274 @@
+// Package zweg converts ZweiteGPS JSON logs to GPX and other track formats.
+//
+// It is the stable, importable API of the zweg command. A typical program
+// decodes a log, converts it and writes the result:
+//
+//	points, err := zweg.ReadFile(ctx, "20240101.json")
+//	if err != nil {
+//		return err
+//	}
+//	return zweg.Encode(ctx, w, points, &zweg.Options{TrackName: "Morning Run"})
+//
+// Every function takes a context.Context; long decodes and conversions stop
+// with ctx.Err() once the context is cancelled.
+package zweg
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/chocoby/zweg/internal/converter"
+	"github.com/chocoby/zweg/internal/fileio"
+	"github.com/chocoby/zweg/internal/format"
+	"github.com/chocoby/zweg/internal/models"
+	"github.com/chocoby/zweg/internal/track"
+	"github.com/twpayne/go-gpx"
+)
+
+// Point is a single ZweiteGPS trackpoint as found in the app's JSON export.
+type Point = models.Point
+
+// Means is the means of transportation recorded in a point's Ms field.
+type Means = models.Means
+
+// Means of transportation values as defined by the ZweiteGPS specification.
+const (
+	MeansWalking    = models.MeansWalking
+	MeansJogging    = models.MeansJogging
+	MeansBicycle    = models.MeansBicycle
+	MeansMotorCycle = models.MeansMotorCycle
+	MeansAutoMobile = models.MeansAutoMobile
+	MeansTrain      = models.MeansTrain
+	MeansMisc       = models.MeansMisc
+)
+
+// Errors returned by the functions of this package, to be matched with
+// errors.Is.
+var (
+	// ErrInputNotFound is returned by ReadFile for a missing file.
+	ErrInputNotFound = fileio.ErrInputNotFound
+	// ErrInvalidJSON is returned for a log that is not valid JSON.
+	ErrInvalidJSON = fileio.ErrInvalidJSON
+	// ErrEmptyLog is returned for a log without points.
+	ErrEmptyLog = fileio.ErrEmptyLog
+	// ErrBadArchive is returned by ReadFile for gzip input that cannot be
+	// decompressed.
+	ErrBadArchive = fileio.ErrBadArchive
+	// ErrNoPoints is returned when Convert or Encode are given no points.
+	ErrNoPoints = converter.ErrNoPoints
+	// ErrBadAltitude is returned for a point whose altitude is not a number.
+	ErrBadAltitude = converter.ErrBadAltitude
+	// ErrBadSpeed is returned for a point whose speed is not a number.
+	ErrBadSpeed = converter.ErrBadSpeed
+	// ErrBadDistance is returned for a point whose distance is not a
+	// number.
+	ErrBadDistance = converter.ErrBadDistance
+)
+
+// Format names an output format accepted by Encode.
+type Format string
+
+// Built-in output formats. Formats lists everything available.
+const (
+	FormatGPX     Format = "gpx"
+	FormatGeoJSON Format = "geojson"
+)
+
+// Formats returns the names of every output format Encode accepts.
+func Formats() []Format {
+	var out []Format
+	for _, f := range format.Default.Formats() {
+		if f.CanWrite() {
+			out = append(out, Format(f.Name))
+		}
+	}
+	return out
+}
+
+// Options controls conversion. The zero value (or a nil *Options) converts
+// to GPX with the same defaults as the zweg command.
+type Options struct {
+	// TrackName is the name of the track and the document. When empty it
+	// falls back to the recorded Tl, then the means name, then "Track".
+	TrackName string
+
+	// Format selects the output format for Encode. Defaults to FormatGPX.
+	// Any name returned by Formats is accepted.
+	Format Format
+
+	// Creator overrides the GPX creator attribute.
+	Creator string
+
+	// OmitWaypoints drops the Start and Goal waypoints.
+	OmitWaypoints bool
+
+	// Author, Copyright and License fill the GPX metadata author and
+	// copyright elements. Copyright is the holder's name.
+	Author    string
+	Copyright string
+	License   string
+
+	// Indent is the indentation used by Encode. Defaults to two spaces.
+	Indent string
+
+	// Lenient treats altitude, speed and distance values that are not
+	// numbers as not recorded. By default they fail with ErrBadAltitude,
+	// ErrBadSpeed or ErrBadDistance.
+	Lenient bool
+
+	// FillElevation interpolates the altitude of points that have none
+	// between the nearest points with one. Otherwise their elevation is
+	// left out.
+	FillElevation bool
+}
+
+func (o *Options) withDefaults() Options {
+	var opts Options
+	if o != nil {
+		opts = *o
+	}
+	if opts.Format == "" {
+		opts.Format = FormatGPX
+	}
+	return opts
+}
+
+func (o *Options) trackName(points []Point) string {
+	if o.TrackName != "" {
+		return o.TrackName
+	}
+	return TrackName(points)
+}
+
+// track converts points, honouring Lenient and FillElevation.
+func (o *Options) track(points []Point) (*track.Track, error) {
+	var t *track.Track
+	if o.Lenient {
+		t, _ = track.FromZweiteLenient(points)
+	} else {
+		var err error
+		if t, err = track.FromZweite(points); err != nil {
+			return nil, err
+		}
+	}
+	if o.FillElevation {
+		t.FillElevation()
+	}
+	return t, nil
+}
+
+func (o *Options) gpxConfig() *converter.Config {
+	cfg := converter.DefaultConfig()
+	if o.Creator != "" {
+		cfg.Creator = o.Creator
+	}
+	cfg.IncludeWaypoint = !o.OmitWaypoints
+	cfg.Author = o.Author
+	cfg.Copyright = o.Copyright
+	cfg.License = o.License
+	return cfg
+}
+
+// Decode parses a ZweiteGPS JSON log from r.
+// It returns an error when the JSON is malformed or contains no points.
+func Decode(ctx context.Context, r io.Reader) ([]Point, error) {
+	return fileio.NewJSONReader().Decode(&ctxReader{ctx: ctx, r: r})
+}
+
+// ReadFile opens and decodes the ZweiteGPS JSON log at name, which may be
+// gzip-compressed.
+func ReadFile(ctx context.Context, name string) ([]Point, error) {
+	f, err := fileio.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	r, err := fileio.Decompress(f)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(ctx, r)
+}
+
+// TrackName returns the name Convert uses when Options.TrackName is empty.
+func TrackName(points []Point) string {
+	return models.DefaultTrackName(points)
+}
+
+// Convert builds a GPX document from points. An elevation of exactly 0 m
+// is held as the smallest positive float, since go-gpx leaves out an <ele>
+// of 0; Encode writes it as <ele>0</ele>.
+func Convert(ctx context.Context, points []Point, opts *Options) (*gpx.GPX, error) {
+	o := opts.withDefaults()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, ErrNoPoints
+	}
+	t, err := o.track(points)
+	if err != nil {
+		return nil, err
+	}
+	return converter.New(o.gpxConfig()).ConvertTrack(ctx, t, o.trackName(points))
+}
+
+// Encode converts points and writes them to w in Options.Format.
+func Encode(ctx context.Context, w io.Writer, points []Point, opts *Options) error {
+	o := opts.withDefaults()
+
+	f, ok := format.Default.Lookup(string(o.Format))
+	if !ok || !f.CanWrite() {
+		return fmt.Errorf("unsupported format %q", o.Format)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		return ErrNoPoints
+	}
+
+	t, err := o.track(points)
+	if err != nil {
+		return err
+	}
+
+	indent := o.Indent
+	if indent == "" {
+		indent = "  "
+	}
+
+	return f.Encode(ctx, &ctxWriter{ctx: ctx, w: w}, t, &format.EncodeOptions{
+		TrackName: o.trackName(points),
+		GPX:       o.gpxConfig(),
+		Indent:    indent,
+	})
+}
+
+// ctxReader fails reads once its context is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// ctxWriter fails writes once its context is done.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w *ctxWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
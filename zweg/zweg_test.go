@@ -0,0 +1,134 @@
+package zweg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testLog = `[{"tm":1609459200,"lo":139.7454,"la":35.6812,"al":"10","sp":"0","co":0,"th":0,"he":0,"ds":"0","ms":2}]`
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantLen   int
+		errSubstr string
+	}{
+		{"valid", testLog, 1, ""},
+		{"empty array", `[]`, 0, "no data points found"},
+		{"invalid json", `{`, 0, "failed to parse JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := Decode(context.Background(), strings.NewReader(tt.input))
+			if tt.errSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Fatalf("Decode() error = %v, want substring %q", err, tt.errSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() unexpected error = %v", err)
+			}
+			if len(points) != tt.wantLen {
+				t.Errorf("Decode() points length = %d, want %d", len(points), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.json")
+	if err := os.WriteFile(path, []byte(testLog), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	points, err := ReadFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("ReadFile() unexpected error = %v", err)
+	}
+	if len(points) != 1 {
+		t.Errorf("ReadFile() points length = %d, want 1", len(points))
+	}
+
+	if _, err := ReadFile(context.Background(), filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, ErrInputNotFound) {
+		t.Errorf("ReadFile() error = %v, want ErrInputNotFound for missing file", err)
+	}
+}
+
+func TestConvert_TrackNameFallback(t *testing.T) {
+	points, err := Decode(context.Background(), strings.NewReader(testLog))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	g, err := Convert(context.Background(), points, nil)
+	if err != nil {
+		t.Fatalf("Convert() unexpected error = %v", err)
+	}
+	if got, want := g.Trk[0].Name, "Bicycle"; got != want {
+		t.Errorf("track name = %q, want %q", got, want)
+	}
+	if len(g.Wpt) != 2 {
+		t.Errorf("waypoints = %d, want 2", len(g.Wpt))
+	}
+}
+
+func TestEncode_UnsupportedFormat(t *testing.T) {
+	points := []Point{{Tm: 1609459200}}
+	err := Encode(context.Background(), &bytes.Buffer{}, points, &Options{Format: "kml"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Errorf("Encode() error = %v, want unsupported format", err)
+	}
+}
+
+func TestConvert_Lenient(t *testing.T) {
+	points := []Point{{Tm: 1609459200, Al: "10"}, {Tm: 1609459260, Al: "N/A"}}
+
+	if _, err := Convert(context.Background(), points, nil); !errors.Is(err, ErrBadAltitude) {
+		t.Errorf("Convert() error = %v, want ErrBadAltitude", err)
+	}
+	g, err := Convert(context.Background(), points, &Options{Lenient: true})
+	if err != nil {
+		t.Fatalf("Convert() with Lenient unexpected error = %v", err)
+	}
+	if pts := g.Trk[0].TrkSeg[0].TrkPt; len(pts) != 2 || pts[1].Ele != 0 {
+		t.Errorf("trkpts = %+v, want 2 with the second elevation not recorded", pts)
+	}
+}
+
+func TestConvert_FillElevation(t *testing.T) {
+	points := []Point{{Tm: 1609459200, La: 35, Al: "10"}, {Tm: 1609459260, La: 35.001}, {Tm: 1609459320, La: 35.002, Al: "20"}}
+
+	g, err := Convert(context.Background(), points, &Options{FillElevation: true})
+	if err != nil {
+		t.Fatalf("Convert() unexpected error = %v", err)
+	}
+	if pts := g.Trk[0].TrkSeg[0].TrkPt; len(pts) != 3 || math.Abs(pts[1].Ele-15) > 1e-6 {
+		t.Errorf("trkpts = %+v, want the missing elevation filled with 15", pts)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := Decode(ctx, strings.NewReader(testLog)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Decode() error = %v, want context.Canceled", err)
+	}
+
+	points := []Point{{Tm: 1609459200}}
+	if _, err := Convert(ctx, points, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Convert() error = %v, want context.Canceled", err)
+	}
+	if err := Encode(ctx, &bytes.Buffer{}, points, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Encode() error = %v, want context.Canceled", err)
+	}
+}